@@ -2,52 +2,265 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
+	"outtech105.com/transit_server/config"
+	"outtech105.com/transit_server/controllers"
 	"outtech105.com/transit_server/database"
 	"outtech105.com/transit_server/handler"
+	"outtech105.com/transit_server/models"
 )
 
 func main() {
-	// DB接続
-	db, err := database.ConnectDB(10)
+	// マイグレーションのサブコマンド(例: go run app.go migrate up)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		storage, err := config.LoadStorage()
+		if err != nil {
+			panic(err)
+		}
+		if err := runMigrate(context.Background(), storage, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// DB接続(駅・時刻表の取得先は設定により切り替える)
+	storage, err := config.LoadStorage()
+	if err != nil {
+		panic(err)
+	}
+	db, repos, err := openStorage(context.Background(), storage)
 	if err != nil {
 		panic(err)
 	}
 	defer db.Close()
 
+	// 経路探索の上限設定
+	searchLimits, err := config.LoadSearchLimits()
+	if err != nil {
+		panic(err)
+	}
+
+	// 運行日の境界設定
+	serviceDayStart, err := config.LoadServiceDayStart()
+	if err != nil {
+		panic(err)
+	}
+	models.SetServiceDayStart(serviceDayStart)
+
+	// 鉄道網ごとの索引(タイムゾーン・駅情報・管理用の認証情報)
+	networks, err := controllers.LoadNetworkIndexes(context.Background(), repos, db)
+	if err != nil {
+		panic(err)
+	}
+
 	// エンドポイントとサーバ起動
-	engine := setupRouter(db)
+	engine := setupRouter(db, repos, storage.InMemory, searchLimits, networks)
 	srv := createServer(engine)
 
 	// Graceful Shutdownの処理
 	gracefulShutdown(srv)
 }
 
+// 設定に応じてDB(MySQL・SQLite・PostgreSQL)に接続し、駅・時刻表の取得先を生成する
+// AUTO_MIGRATEの場合は、未適用のマイグレーションを適用してから使用する
+// STORAGE_IN_MEMORYの場合は、駅・時刻表を起動時にDBからメモリ上に読み込む
+func openStorage(ctx context.Context, storage config.Storage) (*sqlx.DB, models.Repositories, error) {
+	db, err := connectStorage(storage, storage.AutoMigrate)
+	if err != nil {
+		return nil, models.Repositories{}, err
+	}
+	if storage.AutoMigrate {
+		migrator, err := database.NewMigrator(db)
+		if err == nil {
+			err = migrateUp(ctx, migrator)
+		}
+		if err != nil {
+			db.Close()
+			return nil, models.Repositories{}, err
+		}
+	}
+
+	var repos models.Repositories
+	switch storage.Driver {
+	case config.DriverSQLite:
+		repos = models.NewSQLiteRepositories(db)
+	case config.DriverPostgres:
+		repos = models.NewPostgresRepositories(db)
+	default:
+		repos = models.NewMySQLRepositories(db)
+	}
+
+	if storage.InMemory {
+		repos, err = models.LoadMemoryRepositories(ctx, db)
+		if err != nil {
+			db.Close()
+			return nil, models.Repositories{}, err
+		}
+	}
+	return db, repos, nil
+}
+
+// 設定に応じてDB(MySQL・SQLite・PostgreSQL)に接続する
+// createの場合、SQLiteのDBファイルが存在しなければ作成する
+func connectStorage(storage config.Storage, create bool) (*sqlx.DB, error) {
+	switch storage.Driver {
+	case config.DriverSQLite:
+		return database.ConnectSQLite(storage.SQLitePath, create)
+	case config.DriverPostgres:
+		return database.ConnectPostgres(10)
+	default:
+		return database.ConnectDB(10)
+	}
+}
+
+// マイグレーションのサブコマンド(migrate [up|down [件数]|status])
+// 引数が無い場合は、未適用のマイグレーションをすべて適用する
+func runMigrate(ctx context.Context, storage config.Storage, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	steps := 1
+	switch command {
+	case "up", "status":
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments for %s", command)
+		}
+	case "down":
+		if len(args) > 2 {
+			return fmt.Errorf("too many arguments for %s", command)
+		}
+		if len(args) == 2 {
+			value, err := strconv.Atoi(args[1])
+			if err != nil || value < 1 {
+				return fmt.Errorf("invalid number of steps: %s", args[1])
+			}
+			steps = value
+		}
+	default:
+		return fmt.Errorf("unknown command: %s (usage: migrate [up|down [steps]|status])", command)
+	}
+
+	db, err := connectStorage(storage, command == "up")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("Reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Println("No migrations to revert")
+		}
+		return err
+	case "status":
+		applied, pending, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range applied {
+			fmt.Printf("applied  %04d_%s (%s)\n", m.Version, m.Name, m.AppliedAt.Format(time.RFC3339))
+		}
+		for _, m := range pending {
+			fmt.Printf("pending  %04d_%s\n", m.Version, m.Name)
+		}
+		return nil
+	default:
+		return migrateUp(ctx, migrator)
+	}
+}
+
+// 未適用のマイグレーションをすべて適用する
+func migrateUp(ctx context.Context, migrator *database.Migrator) error {
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	if len(applied) == 0 {
+		fmt.Println("Schema is up to date")
+	}
+	return nil
+}
+
 // ルーターの設定
-func setupRouter(db *sqlx.DB) *gin.Engine {
+// 鉄道網ごとのエンドポイントは、/api/v2/traffic/:network 以下に鉄道網の識別子(code)を指定する
+// inMemoryの場合、駅・時刻表を変更する管理用エンドポイントは(メモリ上の駅・時刻表に反映されないため)更新を受け付けない
+func setupRouter(db *sqlx.DB, repos models.Repositories, inMemory bool, searchLimits config.SearchLimits, networks map[string]*controllers.NetworkIndex) *gin.Engine {
 	engine := gin.Default()
 
 	root := engine.Group("/api/v2/traffic")
-	root.GET("/station", handler.GetStationsByKeyword(db))
-	root.GET("/station/:id", handler.GetStationByID(db))
-	root.POST("/search", handler.SearchTransitHandler(db))
+	root.GET("/networks", handler.GetNetworks(networks))
+
+	network := root.Group("/:network", handler.ResolveNetwork(networks))
+	network.GET("/station", handler.GetStationsByKeyword(repos))
+	network.GET("/station/:id", handler.GetStationByID())
+	network.GET("/station/:id/departures", handler.GetStationDepartures(db, repos))
+	network.GET("/station/:id/reachable", handler.GetReachableStations(db, repos, searchLimits))
+	network.POST("/search", handler.SearchTransitHandler(db, repos, searchLimits))
+	network.POST("/search/range", handler.SearchTransitRangeHandler(db, repos, searchLimits))
+	network.POST("/matrix", handler.SearchTravelTimeMatrixHandler(db, repos, searchLimits))
+	network.GET("/alerts", handler.GetServiceAlerts(db))
+	network.GET("/trains/positions", handler.GetTrainPositions(db, repos))
+	network.GET("/agencies", handler.GetAgencies())
+	network.GET("/lines", handler.GetLines(db))
+	network.GET("/line/:id", handler.GetLineByID(db))
+	network.GET("/line/:id/diagram.svg", handler.GetLineDiagram(db))
+
+	// 管理用エンドポイント(鉄道網ごとの認証情報でBasic認証、認証情報未設定の鉄道網では無効)
+	for code, index := range networks {
+		if !index.HasAdminAccount() {
+			log.Printf("Admin account of network %s is not set. Admin endpoints are disabled.", code)
+		}
+	}
+	admin := network.Group("/admin", handler.NetworkAdminAuth())
+	admin.GET("/disruptions", handler.GetDisruptions(db))
+	admin.POST("/cancellations", handler.CreateTrainCancellation(db))
+	admin.DELETE("/cancellations/:train_id/:date", handler.DeleteTrainCancellation(db))
+	admin.POST("/suspensions", handler.CreateSegmentSuspension(db))
+	admin.DELETE("/suspensions/:id", handler.DeleteSegmentSuspension(db))
+	admin.POST("/alerts", handler.CreateServiceAlert(db))
+	admin.DELETE("/alerts/:id", handler.DeleteServiceAlert(db))
+	admin.GET("/conflicts", handler.GetTimetableConflicts(db, repos))
+	admin.POST("/patterns/:id/expand", handler.ExpandServicePattern(db, inMemory))
 
 	return engine
 }
 
 // サーバの作成
+// シャットダウン開始時に、処理中のリクエストのコンテキストをキャンセルする
 func createServer(handler http.Handler) *http.Server {
-	return &http.Server{
+	baseCtx, cancel := context.WithCancel(context.Background())
+	srv := &http.Server{
 		Addr:    ":80",
 		Handler: handler,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
 	}
+	srv.RegisterOnShutdown(cancel)
+	return srv
 }
 
 // Graceful Shutdownの実装
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// 経路探索の上限(サーバ側の制限値)
+// リクエストで指定できる値はこの上限以下に限られ、未指定の場合は既定値を用いる
+type SearchLimits struct {
+	MaxTransfers         uint // 乗換回数の上限
+	MaxTravelMinutes     uint // 所要時間(分)の上限
+	MaxWaitMinutes       uint // 1回の乗換・出発での待ち時間(分)の上限
+	MaxResults           uint // 返却するルート数の上限
+	DefaultMaxResults    uint // max_results未指定時に返却するルート数
+	DefaultTravelMinutes uint // max_travel_minutes未指定時の所要時間(分)の上限
+	TimeoutSeconds       uint // 1回の探索の制限時間(秒)
+	MaxRangeMinutes      uint // 出発時刻の範囲を指定した探索での、範囲の長さ(分)の上限
+	MaxMatrixStations    uint // 所要時間行列の出発駅・到着駅それぞれの数の上限
+}
+
+// 環境変数から経路探索の上限を読み込む(未設定の項目は既定値)
+func LoadSearchLimits() (SearchLimits, error) {
+	limits := SearchLimits{
+		MaxTransfers:         5,
+		MaxTravelMinutes:     24 * 60,
+		MaxWaitMinutes:       120,
+		MaxResults:           20,
+		DefaultMaxResults:    5,
+		DefaultTravelMinutes: 6 * 60,
+		TimeoutSeconds:       10,
+		MaxRangeMinutes:      4 * 60,
+		MaxMatrixStations:    50,
+	}
+
+	for _, env := range []struct {
+		key   string
+		value *uint
+	}{
+		{"SEARCH_MAX_TRANSFERS", &limits.MaxTransfers},
+		{"SEARCH_MAX_TRAVEL_MINUTES", &limits.MaxTravelMinutes},
+		{"SEARCH_MAX_WAIT_MINUTES", &limits.MaxWaitMinutes},
+		{"SEARCH_MAX_RESULTS", &limits.MaxResults},
+		{"SEARCH_DEFAULT_MAX_RESULTS", &limits.DefaultMaxResults},
+		{"SEARCH_DEFAULT_TRAVEL_MINUTES", &limits.DefaultTravelMinutes},
+		{"SEARCH_TIMEOUT_SECONDS", &limits.TimeoutSeconds},
+		{"SEARCH_MAX_RANGE_MINUTES", &limits.MaxRangeMinutes},
+		{"SEARCH_MAX_MATRIX_STATIONS", &limits.MaxMatrixStations},
+	} {
+		valueString := os.Getenv(env.key)
+		if valueString == "" {
+			continue
+		}
+		value, err := strconv.ParseUint(valueString, 10, 32)
+		if err != nil {
+			return SearchLimits{}, fmt.Errorf("parse %s: %w", env.key, err)
+		}
+		*env.value = uint(value)
+	}
+
+	// 既定値は上限を超えないようにする
+	limits.DefaultMaxResults = min(limits.DefaultMaxResults, limits.MaxResults)
+	limits.DefaultTravelMinutes = min(limits.DefaultTravelMinutes, limits.MaxTravelMinutes)
+
+	return limits, nil
+}
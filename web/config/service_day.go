@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// 環境変数SERVICE_DAY_START(HH:MM形式)から、運行日の開始時刻を読み込む(未設定の場合は4:00)
+// この時刻より前の列車は、前日の運行日に属するものとして扱う
+func LoadServiceDayStart() (time.Duration, error) {
+	valueString := os.Getenv("SERVICE_DAY_START")
+	if valueString == "" {
+		return 4 * time.Hour, nil
+	}
+
+	var hours, minutes uint
+	if _, err := fmt.Sscanf(valueString, "%d:%d", &hours, &minutes); err != nil {
+		return 0, fmt.Errorf("parse SERVICE_DAY_START: %w", err)
+	}
+	if hours >= 24 || minutes >= 60 {
+		return 0, fmt.Errorf("parse SERVICE_DAY_START: out of range: %s", valueString)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
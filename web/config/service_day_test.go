@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadServiceDayStart(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", want: 4 * time.Hour},
+		{value: "00:00", want: 0},
+		{value: "03:30", want: 3*time.Hour + 30*time.Minute},
+		{value: "23:59", want: 23*time.Hour + 59*time.Minute},
+		{value: "24:00", wantErr: true},
+		{value: "04:60", wantErr: true},
+		{value: "four", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("SERVICE_DAY_START", tt.value)
+			got, err := LoadServiceDayStart()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("LoadServiceDayStart() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadServiceDayStart: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("LoadServiceDayStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
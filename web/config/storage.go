@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// DBの種類
+const (
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// 駅・時刻表などの保存先の設定
+type Storage struct {
+	Driver      string // DBの種類(mysql/sqlite/postgres)
+	SQLitePath  string // SQLiteのDBファイルのパス
+	InMemory    bool   // 駅・時刻表を起動時にメモリ上に読み込んで使用するか
+	AutoMigrate bool   // 起動時に未適用のマイグレーションを適用するか
+}
+
+// 環境変数STORAGE_DRIVER・SQLITE_PATH・STORAGE_IN_MEMORY・AUTO_MIGRATEから保存先の設定を読み込む(未設定の場合はMySQL)
+func LoadStorage() (Storage, error) {
+	storage := Storage{
+		Driver:     os.Getenv("STORAGE_DRIVER"),
+		SQLitePath: os.Getenv("SQLITE_PATH"),
+	}
+	if storage.Driver == "" {
+		storage.Driver = DriverMySQL
+	}
+	switch storage.Driver {
+	case DriverMySQL, DriverSQLite, DriverPostgres:
+	default:
+		return Storage{}, fmt.Errorf("parse STORAGE_DRIVER: unknown driver: %s", storage.Driver)
+	}
+	if storage.SQLitePath == "" {
+		storage.SQLitePath = "transit.db"
+	}
+
+	if valueString := os.Getenv("STORAGE_IN_MEMORY"); valueString != "" {
+		value, err := strconv.ParseBool(valueString)
+		if err != nil {
+			return Storage{}, fmt.Errorf("parse STORAGE_IN_MEMORY: %w", err)
+		}
+		storage.InMemory = value
+	}
+	if valueString := os.Getenv("AUTO_MIGRATE"); valueString != "" {
+		value, err := strconv.ParseBool(valueString)
+		if err != nil {
+			return Storage{}, fmt.Errorf("parse AUTO_MIGRATE: %w", err)
+		}
+		storage.AutoMigrate = value
+	}
+	return storage, nil
+}
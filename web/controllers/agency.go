@@ -0,0 +1,36 @@
+package controllers
+
+// 経路中で、同じ事業者の列車が連続する区間
+// 事業者未設定の列車の区間は、AgencyIDをnilとする
+type AgencySection struct {
+	AgencyID       *uint
+	FirstOperation int // Route.Operationsの添字(区間の最初の移動)
+	LastOperation  int // Route.Operationsの添字(区間の最後の移動)
+}
+
+// 経路の移動を、事業者ごとの連続する区間にまとめる
+// trainAgencyIDsは、列車IDから運行する事業者IDへの対応
+func AgencySectionsOf(route Route, trainAgencyIDs map[uint]uint) []AgencySection {
+	sections := make([]AgencySection, 0, 2)
+	for i, op := range route.Operations {
+		var agencyID *uint
+		if id, hasAgency := trainAgencyIDs[op.TrainID]; hasAgency {
+			agencyID = &id
+		}
+
+		if len(sections) > 0 && isSameAgency(sections[len(sections)-1].AgencyID, agencyID) {
+			sections[len(sections)-1].LastOperation = i
+			continue
+		}
+		sections = append(sections, AgencySection{AgencyID: agencyID, FirstOperation: i, LastOperation: i})
+	}
+	return sections
+}
+
+// 事業者ID(未設定はnil)が同じか
+func isSameAgency(a *uint, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
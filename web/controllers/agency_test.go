@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"outtech105.com/transit_server/models"
+)
+
+// 経路の移動を、事業者の連続する区間にまとめる(事業者未設定の列車も1つの区間とする)
+func TestAgencySectionsOf(t *testing.T) {
+	// 列車1・2は事業者10、列車3は事業者20、列車4・5は事業者未設定
+	trainAgencyIDs := map[uint]uint{1: 10, 2: 10, 3: 20}
+
+	tests := []struct {
+		name     string
+		trainIDs []uint // 経路の移動ごとの列車ID
+		want     []string
+	}{
+		{name: "single agency", trainIDs: []uint{1, 1, 2}, want: []string{"10 0-2"}},
+		{name: "agency changes", trainIDs: []uint{1, 3, 3}, want: []string{"10 0-0", "20 1-2"}},
+		{name: "same agency again", trainIDs: []uint{1, 3, 2}, want: []string{"10 0-0", "20 1-1", "10 2-2"}},
+		{name: "trains without agency", trainIDs: []uint{4, 5, 1}, want: []string{"- 0-1", "10 2-2"}},
+		{name: "no operations", trainIDs: []uint{}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := Route{Operations: make([]models.Operation, 0, len(tt.trainIDs))}
+			for i, trainID := range tt.trainIDs {
+				route.Operations = append(route.Operations, testOperation(trainID, uint(i+1), at(8, i*10), uint(i+2), at(8, i*10+5)))
+			}
+
+			got := make([]string, 0)
+			for _, section := range AgencySectionsOf(route, trainAgencyIDs) {
+				agency := "-"
+				if section.AgencyID != nil {
+					agency = fmt.Sprint(*section.AgencyID)
+				}
+				got = append(got, fmt.Sprintf("%s %d-%d", agency, section.FirstOperation, section.LastOperation))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("sections = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
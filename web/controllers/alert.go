@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"outtech105.com/transit_server/models"
+)
+
+// 経路が影響対象の駅・列車・路線を含み、経路の所要期間中に有効なお知らせを抽出
+// trainLineIDsは、列車IDから所属路線IDへの対応
+func AlertsForRoute(alerts []models.ServiceAlert, route Route, trainLineIDs map[uint]uint) []models.ServiceAlert {
+	if len(route.Operations) == 0 {
+		return []models.ServiceAlert{}
+	}
+	departDatetime := route.Operations[0].DepartDatetime
+	arriveDatetime := route.Operations[len(route.Operations)-1].ArriveDatetime
+
+	// 経路上の駅・列車・路線の集合
+	stationIDs := make(map[uint]struct{})
+	trainIDs := make(map[uint]struct{})
+	lineIDs := make(map[uint]struct{})
+	for _, op := range route.Operations {
+		stationIDs[op.DepartStationID] = struct{}{}
+		stationIDs[op.ArriveStationID] = struct{}{}
+		trainIDs[op.TrainID] = struct{}{}
+		if lineID, hasLine := trainLineIDs[op.TrainID]; hasLine {
+			lineIDs[lineID] = struct{}{}
+		}
+	}
+
+	routeAlerts := make([]models.ServiceAlert, 0, len(alerts))
+	for _, alert := range alerts {
+		if alert.ActiveFrom != nil && alert.ActiveFrom.After(arriveDatetime) {
+			continue
+		}
+		if alert.ActiveUntil != nil && !alert.ActiveUntil.After(departDatetime) {
+			continue
+		}
+		if containsAny(stationIDs, alert.StationIDs) || containsAny(trainIDs, alert.TrainIDs) || containsAny(lineIDs, alert.LineIDs) {
+			routeAlerts = append(routeAlerts, alert)
+		}
+	}
+	return routeAlerts
+}
+
+// IDの集合に、idsのいずれかが含まれるか
+func containsAny(set map[uint]struct{}, ids []uint) bool {
+	for _, id := range ids {
+		if _, isExists := set[id]; isExists {
+			return true
+		}
+	}
+	return false
+}
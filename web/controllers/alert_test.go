@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"outtech105.com/transit_server/models"
+)
+
+// 駅1→2の列車1(路線10)、駅2→3の列車2(路線未設定)を乗り継ぐ経路のお知らせ
+func TestAlertsForRoute(t *testing.T) {
+	route := Route{Operations: []models.Operation{
+		testOperation(1, 1, at(8, 0), 2, at(8, 10)),
+		testOperation(2, 2, at(8, 15), 3, at(8, 30)),
+	}}
+	trainLineIDs := map[uint]uint{1: 10}
+	timeOf := func(datetime time.Time) *time.Time { return &datetime }
+
+	tests := []struct {
+		name  string
+		alert models.ServiceAlert
+		want  bool
+	}{
+		{name: "station on route", alert: models.ServiceAlert{StationIDs: []uint{3}}, want: true},
+		{name: "station off route", alert: models.ServiceAlert{StationIDs: []uint{4}}, want: false},
+		{name: "train on route", alert: models.ServiceAlert{TrainIDs: []uint{2}}, want: true},
+		{name: "train off route", alert: models.ServiceAlert{TrainIDs: []uint{3}}, want: false},
+		{name: "line of train", alert: models.ServiceAlert{LineIDs: []uint{10}}, want: true},
+		{name: "other line", alert: models.ServiceAlert{LineIDs: []uint{20}}, want: false},
+		{name: "active from arrival", alert: models.ServiceAlert{StationIDs: []uint{1}, ActiveFrom: timeOf(at(8, 30))}, want: true},
+		{name: "active after arrival", alert: models.ServiceAlert{StationIDs: []uint{1}, ActiveFrom: timeOf(at(8, 31))}, want: false},
+		{name: "active until departure", alert: models.ServiceAlert{StationIDs: []uint{1}, ActiveUntil: timeOf(at(8, 0))}, want: false},
+		{name: "active until after departure", alert: models.ServiceAlert{StationIDs: []uint{1}, ActiveUntil: timeOf(at(8, 1))}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AlertsForRoute([]models.ServiceAlert{tt.alert}, route, trainLineIDs)
+			if (len(got) == 1) != tt.want {
+				t.Errorf("AlertsForRoute() = %d alerts, want attached = %v", len(got), tt.want)
+			}
+		})
+	}
+
+	if got := AlertsForRoute([]models.ServiceAlert{{StationIDs: []uint{1}}}, Route{}, trainLineIDs); len(got) != 0 {
+		t.Errorf("AlertsForRoute() for empty route = %d alerts, want none", len(got))
+	}
+}
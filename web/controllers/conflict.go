@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"outtech105.com/transit_server/models"
+)
+
+// ダイヤの矛盾の種類
+const (
+	ConflictSingleTrack = "single_track" // 単線区間での対向列車の競合
+	ConflictOvertaking  = "overtaking"   // 駅間での同方向列車の追い越し
+	ConflictHeadway     = "headway"      // 最小運転間隔未満の続行運転
+)
+
+// ダイヤの矛盾(2列車の区間移動の組)
+type Conflict struct {
+	Type       string
+	Operations [2]models.Operation
+}
+
+// 全列車のダイヤを検査し、物理的に実現不可能な運行の組を返す
+// NOTE: 日付を跨ぐ運行を考慮し、前日・翌日にずらした運行とも比較する
+func DetectTimetableConflicts(ctx context.Context, networkID uint, minHeadway time.Duration, repos models.Repositories, db *sqlx.DB) ([]Conflict, error) {
+	baseDatetime := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	operations, err := repos.Operations.GetTimetableOperations(ctx, networkID, baseDatetime)
+	if err != nil {
+		return nil, fmt.Errorf("getTimetableOperations: %w", err)
+	}
+
+	singleTrackSegments, err := models.GetSingleTrackSegments(ctx, db, networkID)
+	if err != nil {
+		return nil, fmt.Errorf("getSingleTrackSegments: %w", err)
+	}
+
+	// 駅間ごとに区間移動をまとめ、同一駅間の組のみ比較する
+	segmentOperations := make(map[[2]uint][]models.Operation)
+	for _, op := range operations {
+		key := models.SegmentKey(op.DepartStationID, op.ArriveStationID)
+		segmentOperations[key] = append(segmentOperations[key], op)
+	}
+
+	conflicts := make([]Conflict, 0, 10)
+	for key, ops := range segmentOperations {
+		_, isSingleTrack := singleTrackSegments[key]
+		for i := 0; i < len(ops); i++ {
+			for j := i + 1; j < len(ops); j++ {
+				if ops[i].TrainID == ops[j].TrainID {
+					continue
+				}
+				conflicts = append(conflicts, detectOperationConflicts(ops[i], ops[j], isSingleTrack, minHeadway)...)
+			}
+		}
+	}
+
+	// 出発時刻・列車ID順に並べる
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		a, b := conflicts[i].Operations[0], conflicts[j].Operations[0]
+		if !a.DepartDatetime.Equal(b.DepartDatetime) {
+			return a.DepartDatetime.Before(b.DepartDatetime)
+		}
+		if a.TrainID != b.TrainID {
+			return a.TrainID < b.TrainID
+		}
+		return conflicts[i].Operations[1].TrainID < conflicts[j].Operations[1].TrainID
+	})
+
+	return conflicts, nil
+}
+
+// 同一駅間を走る2列車の区間移動について、種類ごとに最初に見つかった矛盾を返す
+func detectOperationConflicts(a models.Operation, b models.Operation, isSingleTrack bool, minHeadway time.Duration) []Conflict {
+	found := make(map[string]Conflict)
+	for _, shift := range []time.Duration{-24 * time.Hour, 0, 24 * time.Hour} {
+		shifted := b
+		shifted.DepartDatetime = b.DepartDatetime.Add(shift)
+		shifted.ArriveDatetime = b.ArriveDatetime.Add(shift)
+
+		isSameDirection := a.DepartStationID == shifted.DepartStationID
+		isOverlapped := a.DepartDatetime.Before(shifted.ArriveDatetime) && shifted.DepartDatetime.Before(a.ArriveDatetime)
+
+		// 単線区間で、対向列車が同時に駅間にいる
+		if !isSameDirection && isSingleTrack && isOverlapped {
+			found[ConflictSingleTrack] = Conflict{Type: ConflictSingleTrack, Operations: [2]models.Operation{a, shifted}}
+		}
+		if !isSameDirection {
+			continue
+		}
+
+		// 先に出発した列車が、後から出発した列車より遅く到着する
+		departOrder := a.DepartDatetime.Compare(shifted.DepartDatetime)
+		arriveOrder := a.ArriveDatetime.Compare(shifted.ArriveDatetime)
+		if departOrder*arriveOrder < 0 {
+			found[ConflictOvertaking] = Conflict{Type: ConflictOvertaking, Operations: [2]models.Operation{a, shifted}}
+		}
+
+		// 出発・到着の間隔が最小運転間隔未満
+		if absDuration(a.DepartDatetime.Sub(shifted.DepartDatetime)) < minHeadway ||
+			absDuration(a.ArriveDatetime.Sub(shifted.ArriveDatetime)) < minHeadway {
+			found[ConflictHeadway] = Conflict{Type: ConflictHeadway, Operations: [2]models.Operation{a, shifted}}
+		}
+	}
+
+	conflicts := make([]Conflict, 0, len(found))
+	for _, conflictType := range []string{ConflictSingleTrack, ConflictOvertaking, ConflictHeadway} {
+		if conflict, isFound := found[conflictType]; isFound {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
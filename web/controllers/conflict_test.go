@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// 駅1→2の列車1(a)と、同じ駅間の列車2(b)の区間移動を、最小運転間隔3分で比較する
+func TestDetectOperationConflicts(t *testing.T) {
+	tests := []struct {
+		name          string
+		aDepart       time.Time
+		aArrive       time.Time
+		bFrom, bTo    uint
+		bDepart       time.Time
+		bArrive       time.Time
+		isSingleTrack bool
+		want          []string // 矛盾の種類と、比較した(ずらした)列車bの出発日時
+	}{
+		{
+			name:    "single track opposite",
+			aDepart: at(8, 0), aArrive: at(8, 10),
+			bFrom: 2, bTo: 1, bDepart: at(8, 5), bArrive: at(8, 15),
+			isSingleTrack: true,
+			want:          []string{"single_track 10-01 08:05"},
+		},
+		{
+			name:    "double track opposite",
+			aDepart: at(8, 0), aArrive: at(8, 10),
+			bFrom: 2, bTo: 1, bDepart: at(8, 5), bArrive: at(8, 15),
+			want: []string{},
+		},
+		{
+			name:    "single track opposite after arrival",
+			aDepart: at(8, 0), aArrive: at(8, 10),
+			bFrom: 2, bTo: 1, bDepart: at(8, 10), bArrive: at(8, 20),
+			isSingleTrack: true,
+			want:          []string{},
+		},
+		{
+			name:    "overtaking",
+			aDepart: at(8, 0), aArrive: at(8, 20),
+			bFrom: 1, bTo: 2, bDepart: at(8, 5), bArrive: at(8, 15),
+			want: []string{"overtaking 10-01 08:05"},
+		},
+		{
+			name:    "headway",
+			aDepart: at(8, 0), aArrive: at(8, 10),
+			bFrom: 1, bTo: 2, bDepart: at(8, 2), bArrive: at(8, 12),
+			want: []string{"headway 10-01 08:02"},
+		},
+		{
+			name:    "minimum headway",
+			aDepart: at(8, 0), aArrive: at(8, 10),
+			bFrom: 1, bTo: 2, bDepart: at(8, 3), bArrive: at(8, 13),
+			want: []string{},
+		},
+		{
+			name:    "overtaking within headway",
+			aDepart: at(8, 0), aArrive: at(8, 20),
+			bFrom: 1, bTo: 2, bDepart: at(8, 1), bArrive: at(8, 15),
+			isSingleTrack: true,
+			want:          []string{"overtaking 10-01 08:01", "headway 10-01 08:01"},
+		},
+		{
+			// 24:00以降に走る列車aと、翌日の0:00台に走る列車b
+			name:    "across midnight",
+			aDepart: at(23, 55), aArrive: at(23, 55).Add(10 * time.Minute),
+			bFrom: 1, bTo: 2, bDepart: at(0, 0), bArrive: at(0, 3),
+			want: []string{"overtaking 10-02 00:00", "headway 10-02 00:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := testOperation(1, 1, tt.aDepart, 2, tt.aArrive)
+			b := testOperation(2, tt.bFrom, tt.bDepart, tt.bTo, tt.bArrive)
+
+			conflicts := detectOperationConflicts(a, b, tt.isSingleTrack, 3*time.Minute)
+			got := make([]string, 0, len(conflicts))
+			for _, c := range conflicts {
+				if c.Operations[0].TrainID != 1 || c.Operations[1].TrainID != 2 {
+					t.Errorf("%s operations = trains %d and %d, want 1 and 2", c.Type, c.Operations[0].TrainID, c.Operations[1].TrainID)
+				}
+				got = append(got, fmt.Sprintf("%s %s", c.Type, c.Operations[1].DepartDatetime.Format("01-02 15:04")))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("conflicts = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"outtech105.com/transit_server/models"
+)
+
+// 発車案内の1行(運休情報付き)
+type Departure struct {
+	models.StationDeparture
+	Cancelled bool
+}
+
+// 駅の発車案内を、指定日時以降の発車の早い順にlimit件取得
+func GetStationDepartures(ctx context.Context, networkID uint, stationID uint, datetime time.Time, limit uint, repos models.Repositories, db *sqlx.DB) ([]Departure, error) {
+	stationDepartures, err := repos.Operations.GetStationDepartures(ctx, stationID, datetime, limit)
+	if err != nil {
+		return nil, fmt.Errorf("getStationDepartures: %w", err)
+	}
+
+	disruptions, err := models.GetDisruptions(ctx, db, networkID, datetime)
+	if err != nil {
+		return nil, fmt.Errorf("getDisruptions: %w", err)
+	}
+
+	departures := make([]Departure, len(stationDepartures))
+	for i, sd := range stationDepartures {
+		_, isCancelled := disruptions.CancellationOf(models.Operation{
+			TrainID:        sd.TrainID,
+			DepartDatetime: sd.DepartDatetime,
+			ServiceDate:    sd.ServiceDate,
+		})
+		departures[i] = Departure{StationDeparture: sd, Cancelled: isCancelled}
+	}
+	return departures, nil
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"outtech105.com/transit_server/models"
+)
+
+// 駅2の発車案内(列車1が8:10発駅3行き、列車3が8:15発駅4行き)に、運行日の運休を付与する
+func TestGetStationDepartures(t *testing.T) {
+	tests := []struct {
+		name      string
+		cancelled []models.TrainCancellation
+		limit     uint
+		want      []string // 列車ID 発車時刻 行先 運休の有無
+	}{
+		{
+			name:  "no cancellations",
+			limit: 10,
+			want:  []string{"1 08:10 3 false", "3 08:15 4 false"},
+		},
+		{
+			name:      "cancelled train",
+			cancelled: []models.TrainCancellation{{TrainID: 3, ServiceDate: at(0, 0)}},
+			limit:     10,
+			want:      []string{"1 08:10 3 false", "3 08:15 4 true"},
+		},
+		{
+			name:      "cancelled on another service date",
+			cancelled: []models.TrainCancellation{{TrainID: 3, ServiceDate: at(0, 0).AddDate(0, 0, 1)}},
+			limit:     10,
+			want:      []string{"1 08:10 3 false", "3 08:15 4 false"},
+		},
+		{
+			name:      "limit",
+			cancelled: []models.TrainCancellation{{TrainID: 3, ServiceDate: at(0, 0)}},
+			limit:     1,
+			want:      []string{"1 08:10 3 false"},
+		},
+	}
+	for _, backend := range []string{"memory", "sqlite"} {
+		for _, tt := range tests {
+			t.Run(backend+"/"+tt.name, func(t *testing.T) {
+				db := newSearchTestDB(t)
+				for _, c := range tt.cancelled {
+					if _, err := db.Exec(`INSERT INTO train_cancellations (train_id, service_date) VALUES (?, ?)`, c.TrainID, c.ServiceDate.Format("2006-01-02")); err != nil {
+						t.Fatalf("insert cancellation: %v", err)
+					}
+				}
+				repos := newSearchTestRepositories(t, backend, db)
+
+				departures, err := GetStationDepartures(context.Background(), 1, 2, at(8, 0), tt.limit, repos, db)
+				if err != nil {
+					t.Fatalf("GetStationDepartures: %v", err)
+				}
+				got := make([]string, 0, len(departures))
+				for _, d := range departures {
+					got = append(got, fmt.Sprintf("%d %s %d %v", d.TrainID, d.DepartDatetime.Format("15:04"), d.DestinationStationID, d.Cancelled))
+				}
+				if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Errorf("departures = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
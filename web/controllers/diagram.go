@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"outtech105.com/transit_server/models"
+)
+
+const defaultDiagramColor = "#333333"
+
+// 運行図表(時間-距離図)
+type Diagram struct {
+	Line     models.Line
+	Stations []DiagramStation
+	From     time.Duration // 描画範囲(0:00からの経過時間)
+	To       time.Duration
+	Trains   []DiagramTrain
+}
+
+// 運行図表の縦軸上の駅
+type DiagramStation struct {
+	Station  models.LineStation
+	Position float64 // 縦軸上の位置(キロ程、未設定の路線では駅順)
+}
+
+// 運行図表上の列車の折れ線(路線を外れる区間で分割される)
+type DiagramTrain struct {
+	TrainID   uint
+	TrainName *string
+	Color     string
+	Points    []DiagramPoint
+}
+
+type DiagramPoint struct {
+	Time     time.Duration // 0:00からの経過時間
+	Position float64
+}
+
+// 路線の運行図表を生成
+// NOTE: 日付を跨ぐ列車も描画されるよう、前日・翌日にずらした折れ線も範囲内であれば含める
+func BuildLineDiagram(ctx context.Context, networkID uint, lineID uint, from time.Duration, to time.Duration, db *sqlx.DB) (Diagram, error) {
+	line, err := models.GetLineByID(ctx, db, networkID, lineID)
+	if err != nil {
+		return Diagram{}, err
+	}
+
+	lineStations, err := models.GetLineStations(ctx, db, lineID)
+	if err != nil {
+		return Diagram{}, fmt.Errorf("getLineStations: %w", err)
+	}
+
+	// キロ程が設定されていれば距離、未設定であれば駅順を縦軸とする
+	useKm := false
+	for _, ls := range lineStations {
+		if ls.Km != lineStations[0].Km {
+			useKm = true
+			break
+		}
+	}
+	positions := make(map[uint]float64, len(lineStations))
+	stations := make([]DiagramStation, len(lineStations))
+	for i, ls := range lineStations {
+		position := float64(i)
+		if useKm {
+			position = ls.Km
+		}
+		positions[ls.ID] = position
+		stations[i] = DiagramStation{Station: ls, Position: position}
+	}
+
+	baseDatetime := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	operations, err := models.GetLineDiagramOperations(ctx, db, lineID, baseDatetime)
+	if err != nil {
+		return Diagram{}, fmt.Errorf("getLineDiagramOperations: %w", err)
+	}
+
+	// 列車ごとに、連続する区間を1本の折れ線にまとめる
+	trains := make([]DiagramTrain, 0, len(operations))
+	for i, op := range operations {
+		isContinued := i > 0 &&
+			operations[i-1].TrainID == op.TrainID &&
+			operations[i-1].Order+1 == op.Order
+		if !isContinued {
+			color := defaultDiagramColor
+			if op.Color != nil {
+				color = *op.Color
+			}
+			trains = append(trains, DiagramTrain{
+				TrainID:   op.TrainID,
+				TrainName: op.TrainName,
+				Color:     color,
+				Points: []DiagramPoint{
+					{Time: op.DepartDatetime.Sub(baseDatetime), Position: positions[op.DepartStationID]},
+				},
+			})
+		}
+
+		train := &trains[len(trains)-1]
+		departPoint := DiagramPoint{Time: op.DepartDatetime.Sub(baseDatetime), Position: positions[op.DepartStationID]}
+		if last := train.Points[len(train.Points)-1]; last != departPoint {
+			// 停車時間は水平線として描画される
+			train.Points = append(train.Points, departPoint)
+		}
+		train.Points = append(train.Points, DiagramPoint{
+			Time:     op.ArriveDatetime.Sub(baseDatetime),
+			Position: positions[op.ArriveStationID],
+		})
+	}
+
+	// 描画範囲にかかる折れ線のみを、前日・当日・翌日分について抽出
+	visibleTrains := make([]DiagramTrain, 0, len(trains))
+	for _, shift := range []time.Duration{-24 * time.Hour, 0, 24 * time.Hour} {
+		for _, train := range trains {
+			begin := train.Points[0].Time + shift
+			end := train.Points[len(train.Points)-1].Time + shift
+			if end < from || to < begin {
+				continue
+			}
+
+			shiftedPoints := make([]DiagramPoint, len(train.Points))
+			for i, point := range train.Points {
+				shiftedPoints[i] = DiagramPoint{Time: point.Time + shift, Position: point.Position}
+			}
+			train.Points = shiftedPoints
+			visibleTrains = append(visibleTrains, train)
+		}
+	}
+
+	return Diagram{
+		Line:     line,
+		Stations: stations,
+		From:     from,
+		To:       to,
+		Trains:   visibleTrains,
+	}, nil
+}
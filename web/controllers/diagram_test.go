@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 折れ線ごとの要約(列車ID: 時刻@位置の列)
+func diagramSummary(trains []DiagramTrain) []string {
+	summaries := make([]string, 0, len(trains))
+	for _, train := range trains {
+		summary := fmt.Sprintf("%d:", train.TrainID)
+		for _, point := range train.Points {
+			summary += fmt.Sprintf(" %s@%g", elapsedClock(point.Time), point.Position)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// 路線1(駅1→2→3、キロ程あり)と路線2(駅3→2、キロ程なし)を登録する
+// 列車5は路線1を外れて駅4へ向かい、戻ってくる。列車6は路線1の日付を跨ぐ列車、列車7は路線2の列車
+func newDiagramTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	db := newSearchTestDB(t)
+	exec := func(query string) {
+		t.Helper()
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("exec %q: %v", query, err)
+		}
+	}
+	exec(`INSERT INTO lines (id, network_id, name, name_en) VALUES (1, 1, '路線1', 'Line 1'), (2, 1, '路線2', 'Line 2')`)
+	exec(`INSERT INTO line_stations (line_id, station_id, sequence, km) VALUES (1, 1, 1, 0), (1, 2, 2, 5), (1, 3, 3, 12), (2, 3, 1, 0), (2, 2, 2, 0)`)
+	exec(`INSERT INTO trains (id, network_id, line_id) VALUES (5, 1, NULL), (6, 1, 1), (7, 1, 2)`)
+	exec(`INSERT INTO stop_times (train_id, stop_sequence, station_id, arr_time, dep_time) VALUES
+		(5, 1, 1, NULL, '08:30:00'), (5, 2, 2, '08:40:00', '08:42:00'), (5, 3, 3, '08:50:00', '08:50:00'),
+		(5, 4, 4, '09:00:00', '09:01:00'), (5, 5, 3, '09:10:00', '09:11:00'), (5, 6, 2, '09:20:00', NULL),
+		(6, 1, 2, NULL, '23:50:00'), (6, 2, 3, '24:10:00', NULL),
+		(7, 1, 3, NULL, '10:00:00'), (7, 2, 2, '10:10:00', NULL)`)
+	return db
+}
+
+// 列車ごとの連続する区間を1本の折れ線とし、路線を外れる区間で分割する
+// 描画範囲にかかる折れ線は、前日・翌日にずらしたものも含める
+func TestBuildLineDiagram(t *testing.T) {
+	db := newDiagramTestDB(t)
+
+	tests := []struct {
+		name     string
+		lineID   uint
+		from, to time.Duration
+		want     []string
+	}{
+		{
+			name:   "whole day",
+			lineID: 1,
+			from:   0,
+			to:     24 * time.Hour,
+			want: []string{
+				"6: -00:10@5 00:10@12",
+				"1: 08:00@0 08:10@5 08:20@12",
+				"5: 08:30@0 08:40@5 08:42@5 08:50@12",
+				"5: 09:11@12 09:20@5",
+				"6: 23:50@5 24:10@12",
+			},
+		},
+		{
+			name:   "after midnight",
+			lineID: 1,
+			from:   0,
+			to:     time.Hour,
+			want:   []string{"6: -00:10@5 00:10@12"},
+		},
+		{
+			name:   "past 24:00",
+			lineID: 1,
+			from:   23 * time.Hour,
+			to:     25 * time.Hour,
+			want:   []string{"6: 23:50@5 24:10@12"},
+		},
+		{
+			name:   "back on the line",
+			lineID: 1,
+			from:   9 * time.Hour,
+			to:     9*time.Hour + 15*time.Minute,
+			want:   []string{"5: 09:11@12 09:20@5"},
+		},
+		{
+			name:   "stations without km",
+			lineID: 2,
+			from:   8 * time.Hour,
+			to:     12 * time.Hour,
+			want: []string{
+				"1: 08:10@1 08:20@0",
+				"5: 08:42@1 08:50@0",
+				"5: 09:11@0 09:20@1",
+				"7: 10:00@0 10:10@1",
+			},
+		},
+		{
+			name:   "train of another line",
+			lineID: 2,
+			from:   23 * time.Hour,
+			to:     25 * time.Hour,
+			want:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := BuildLineDiagram(context.Background(), 1, tt.lineID, tt.from, tt.to, db)
+			if err != nil {
+				t.Fatalf("BuildLineDiagram: %v", err)
+			}
+			if got := diagramSummary(diagram.Trains); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("trains = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// 縦軸は、キロ程が設定されていれば距離、未設定であれば駅順とする
+func TestBuildLineDiagramStationPositions(t *testing.T) {
+	db := newDiagramTestDB(t)
+
+	tests := []struct {
+		name   string
+		lineID uint
+		want   string
+	}{
+		{name: "km", lineID: 1, want: "[1@0 2@5 3@12]"},
+		{name: "sequence", lineID: 2, want: "[3@0 2@1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := BuildLineDiagram(context.Background(), 1, tt.lineID, 0, 24*time.Hour, db)
+			if err != nil {
+				t.Fatalf("BuildLineDiagram: %v", err)
+			}
+			got := make([]string, 0, len(diagram.Stations))
+			for _, s := range diagram.Stations {
+				got = append(got, fmt.Sprintf("%d@%g", s.Station.ID, s.Position))
+			}
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("stations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+
+	"outtech105.com/transit_server/models"
+)
+
+// 探索中に回避した運休・運転見合わせの集合
+// NOTE: ルートに記録した集合は、子のルートと共有するため変更しない(追加する場合はmergedで複製する)
+type avoidedDisruptions struct {
+	cancellations map[string]models.TrainCancellation
+	suspensions   map[uint]models.SegmentSuspension
+}
+
+func newAvoidedDisruptions() *avoidedDisruptions {
+	return &avoidedDisruptions{
+		cancellations: make(map[string]models.TrainCancellation),
+		suspensions:   make(map[uint]models.SegmentSuspension),
+	}
+}
+
+// 運休・運転見合わせの影響を受ける移動か
+func isDisrupted(disruptions models.Disruptions, op models.Operation) bool {
+	if _, isCancelled := disruptions.CancellationOf(op); isCancelled {
+		return true
+	}
+	_, isSuspended := disruptions.SuspensionOf(op)
+	return isSuspended
+}
+
+// 運休・運転見合わせの影響を受ける移動であれば記録し、trueを返す
+func (a *avoidedDisruptions) check(disruptions models.Disruptions, op models.Operation) bool {
+	if c, isCancelled := disruptions.CancellationOf(op); isCancelled {
+		a.cancellations[fmt.Sprintf("%d/%s", c.TrainID, c.ServiceDate.Format("2006-01-02"))] = c
+		return true
+	}
+	if s, isSuspended := disruptions.SuspensionOf(op); isSuspended {
+		a.suspensions[s.ID] = s
+		return true
+	}
+	return false
+}
+
+// aにotherを加えた集合(どちらかがnilまたは空の場合は、もう一方をそのまま返す)
+func (a *avoidedDisruptions) merged(other *avoidedDisruptions) *avoidedDisruptions {
+	if other.isEmpty() {
+		return a
+	}
+	if a.isEmpty() {
+		return other
+	}
+	merged := newAvoidedDisruptions()
+	merged.add(a)
+	merged.add(other)
+	return merged
+}
+
+// otherの運休・運転見合わせを記録する
+func (a *avoidedDisruptions) add(other *avoidedDisruptions) {
+	if other == nil {
+		return
+	}
+	for key, c := range other.cancellations {
+		a.cancellations[key] = c
+	}
+	for id, s := range other.suspensions {
+		a.suspensions[id] = s
+	}
+}
+
+func (a *avoidedDisruptions) isEmpty() bool {
+	return a == nil || (len(a.cancellations) == 0 && len(a.suspensions) == 0)
+}
+
+// 記録した運休・運転見合わせを、日付・ID順のスライスで返す(nilの場合は空)
+func (a *avoidedDisruptions) list() ([]models.TrainCancellation, []models.SegmentSuspension) {
+	if a == nil {
+		return []models.TrainCancellation{}, []models.SegmentSuspension{}
+	}
+	cancellations := make([]models.TrainCancellation, 0, len(a.cancellations))
+	for _, c := range a.cancellations {
+		cancellations = append(cancellations, c)
+	}
+	sort.SliceStable(cancellations, func(i, j int) bool {
+		if !cancellations[i].ServiceDate.Equal(cancellations[j].ServiceDate) {
+			return cancellations[i].ServiceDate.Before(cancellations[j].ServiceDate)
+		}
+		return cancellations[i].TrainID < cancellations[j].TrainID
+	})
+
+	suspensions := make([]models.SegmentSuspension, 0, len(a.suspensions))
+	for _, s := range a.suspensions {
+		suspensions = append(suspensions, s)
+	}
+	sort.SliceStable(suspensions, func(i, j int) bool {
+		return suspensions[i].ID < suspensions[j].ID
+	})
+
+	return cancellations, suspensions
+}
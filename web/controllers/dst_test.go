@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"outtech105.com/transit_server/models"
+)
+
+// 夏時間のある地域(2024-03-10 2:00 ESTに開始、2024-11-03 2:00 EDTに終了)
+func newYorkLocation(t *testing.T) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	return location
+}
+
+// 駅1→2→3を、運行日の開始時刻(4:00)前の切替時間帯に走る列車1と、朝の列車2
+func newDSTRepositories(t *testing.T) models.Repositories {
+	t.Helper()
+	repos, err := models.NewMemoryRepositories(
+		map[uint][]models.Station{1: {{ID: 1}, {ID: 2}, {ID: 3}}},
+		[]models.MemoryTrain{
+			testMemoryTrain(1, []uint{1, 2, 3}, []string{"01:50:00", "02:10:00", "02:30:00"}),
+			testMemoryTrain(2, []uint{1, 2, 3}, []string{"05:00:00", "05:20:00", "05:40:00"}),
+		},
+	)
+	if err != nil {
+		t.Fatalf("NewMemoryRepositories: %v", err)
+	}
+	return repos
+}
+
+// 日時を、地域の時刻(壁時計)と時間帯名で表す
+func wallClock(datetime time.Time) string {
+	return datetime.Format("01-02 15:04 MST")
+}
+
+func TestServiceDayRangeDST(t *testing.T) {
+	newYork := newYorkLocation(t)
+	tests := []struct {
+		name      string
+		datetime  time.Time
+		wantStart string
+		wantEnd   string
+		wantHours float64
+	}{
+		{name: "spring", datetime: time.Date(2024, 3, 10, 3, 30, 0, 0, newYork), wantStart: "03-09 04:00 EST", wantEnd: "03-10 04:00 EDT", wantHours: 23},
+		{name: "after spring", datetime: time.Date(2024, 3, 10, 12, 0, 0, 0, newYork), wantStart: "03-10 04:00 EDT", wantEnd: "03-11 04:00 EDT", wantHours: 24},
+		{name: "fall", datetime: time.Date(2024, 11, 3, 1, 30, 0, 0, newYork).Add(time.Hour), wantStart: "11-02 04:00 EDT", wantEnd: "11-03 04:00 EST", wantHours: 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := ServiceDayRange(tt.datetime)
+			if wallClock(start) != tt.wantStart || wallClock(end) != tt.wantEnd {
+				t.Fatalf("ServiceDayRange(%v) = [%s, %s], want [%s, %s]", tt.datetime, wallClock(start), wallClock(end), tt.wantStart, tt.wantEnd)
+			}
+			if hours := end.Sub(start).Hours(); hours != tt.wantHours {
+				t.Errorf("service day length = %vh, want %vh", hours, tt.wantHours)
+			}
+		})
+	}
+}
+
+// 切替日の接続も、時刻表の時刻(壁時計)で発着し、到着が出発より前にならない
+func TestLoadConnectionsDST(t *testing.T) {
+	newYork := newYorkLocation(t)
+	repos := newDSTRepositories(t)
+
+	tests := []struct {
+		name        string
+		from        time.Time
+		until       time.Time
+		wantDeparts []string // 列車1の出発
+		wantArrives []string // 列車1の到着
+		wantDate    time.Time
+	}{
+		{
+			name:        "spring",
+			from:        time.Date(2024, 3, 10, 0, 0, 0, 0, newYork),
+			until:       time.Date(2024, 3, 10, 4, 0, 0, 0, newYork),
+			wantDeparts: []string{"03-10 01:50 EST", "03-10 03:10 EDT"},
+			wantArrives: []string{"03-10 03:10 EDT", "03-10 03:30 EDT"},
+			wantDate:    time.Date(2024, 3, 9, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:        "fall",
+			from:        time.Date(2024, 11, 3, 0, 0, 0, 0, newYork),
+			until:       time.Date(2024, 11, 3, 4, 0, 0, 0, newYork),
+			wantDeparts: []string{"11-03 01:50 EDT", "11-03 02:10 EST"},
+			wantArrives: []string{"11-03 02:10 EST", "11-03 02:30 EST"},
+			wantDate:    time.Date(2024, 11, 2, 0, 0, 0, 0, newYork),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connections, err := loadConnections(context.Background(), 1, repos, tt.from, tt.until)
+			if err != nil {
+				t.Fatalf("loadConnections: %v", err)
+			}
+			departs, arrives := make([]string, 0), make([]string, 0)
+			for _, c := range connections {
+				if c.op.TrainID != 1 {
+					continue
+				}
+				departs = append(departs, wallClock(c.op.DepartDatetime))
+				arrives = append(arrives, wallClock(c.op.ArriveDatetime))
+				if c.op.ArriveDatetime.Before(c.op.DepartDatetime) {
+					t.Errorf("arrival %s is before departure %s", wallClock(c.op.ArriveDatetime), wallClock(c.op.DepartDatetime))
+				}
+				if !c.op.ServiceDate.Equal(tt.wantDate) {
+					t.Errorf("service date = %v, want %v", c.op.ServiceDate, tt.wantDate)
+				}
+			}
+			if !slices.Equal(departs, tt.wantDeparts) || !slices.Equal(arrives, tt.wantArrives) {
+				t.Fatalf("train 1 = %v -> %v, want %v -> %v", departs, arrives, tt.wantDeparts, tt.wantArrives)
+			}
+		})
+	}
+}
+
+// 始発・終電探索は、切替日の時間帯の列車も運行日で絞り込む
+func TestServiceDaySearchDST(t *testing.T) {
+	newYork := newYorkLocation(t)
+	repos := newDSTRepositories(t)
+
+	// 運行日の列車のみで、駅1から駅3への経路を探索
+	search := func(t *testing.T, datetime time.Time) []Route {
+		t.Helper()
+		dayStart, dayEnd := ServiceDayRange(datetime)
+		connections, err := loadConnections(context.Background(), 1, repos, dayStart, dayEnd.Add(6*time.Hour))
+		if err != nil {
+			t.Fatalf("loadConnections: %v", err)
+		}
+		profile := newProfileSearch(newTestSearchContext(dayStart), serviceDayConnections(connections, models.ServiceDate(datetime)), 0)
+		routes, _ := profile.profileRoutes(context.Background(), 1, 3, dayStart, dayEnd.Add(6*time.Hour))
+		return routes
+	}
+	summary := func(routes []Route) []string {
+		summaries := make([]string, 0, len(routes))
+		for _, route := range routes {
+			summaries = append(summaries, wallClock(route.Operations[0].DepartDatetime)+" - "+wallClock(route.Operations[len(route.Operations)-1].ArriveDatetime))
+		}
+		return summaries
+	}
+
+	tests := []struct {
+		name      string
+		datetime  time.Time
+		wantFirst []string
+		wantLast  []string
+	}{
+		{
+			name:      "before spring",
+			datetime:  time.Date(2024, 3, 9, 12, 0, 0, 0, newYork),
+			wantFirst: []string{"03-09 05:00 EST - 03-09 05:40 EST"},
+			wantLast:  []string{"03-10 01:50 EST - 03-10 03:30 EDT"},
+		},
+		{
+			name:      "after spring",
+			datetime:  time.Date(2024, 3, 10, 12, 0, 0, 0, newYork),
+			wantFirst: []string{"03-10 05:00 EDT - 03-10 05:40 EDT"},
+			wantLast:  []string{"03-11 01:50 EDT - 03-11 02:30 EDT"},
+		},
+		{
+			name:      "before fall",
+			datetime:  time.Date(2024, 11, 2, 12, 0, 0, 0, newYork),
+			wantFirst: []string{"11-02 05:00 EDT - 11-02 05:40 EDT"},
+			wantLast:  []string{"11-03 01:50 EDT - 11-03 02:30 EST"},
+		},
+		{
+			name:      "after fall",
+			datetime:  time.Date(2024, 11, 3, 12, 0, 0, 0, newYork),
+			wantFirst: []string{"11-03 05:00 EST - 11-03 05:40 EST"},
+			wantLast:  []string{"11-04 01:50 EST - 11-04 02:30 EST"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes := search(t, tt.datetime)
+			if got := summary(firstTrainRoutes(routes)); !slices.Equal(got, tt.wantFirst) {
+				t.Errorf("first train = %v, want %v", got, tt.wantFirst)
+			}
+			if got := summary(lastTrainRoutes(routes)); !slices.Equal(got, tt.wantLast) {
+				t.Errorf("last train = %v, want %v", got, tt.wantLast)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"outtech105.com/transit_server/database"
+	"outtech105.com/transit_server/models"
+)
+
+// controllersのテストで共有する時刻・移動・列車・探索情報
+
+var testLocation = time.FixedZone("JST", 9*60*60)
+
+// 2024-10-01の時刻(HH:MM)
+func at(hour int, minute int) time.Time {
+	return time.Date(2024, 10, 1, hour, minute, 0, 0, testLocation)
+}
+
+// 0:00からの経過時間(HH:MM、前日は負)
+func elapsedClock(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+// 列車trainIDの、from駅からto駅への移動
+func testOperation(trainID uint, from uint, depart time.Time, to uint, arrive time.Time) models.Operation {
+	return models.Operation{
+		TrainID:         trainID,
+		Order:           1,
+		DepartStationID: from,
+		DepartDatetime:  depart,
+		ArriveStationID: to,
+		ArriveDatetime:  arrive,
+		ServiceDate:     time.Date(depart.Year(), depart.Month(), depart.Day(), 0, 0, 0, 0, depart.Location()),
+	}
+}
+
+// 区間移動から、出発時刻順の接続を生成(便は列車IDごとに1つ)
+func testConnections(operations ...models.Operation) []connection {
+	connections := make([]connection, 0, len(operations))
+	for _, op := range operations {
+		connections = append(connections, connection{op: op, trip: tripKey{trainID: op.TrainID}})
+	}
+	sortConnections(connections)
+	return connections
+}
+
+// 列車trainIDが、停車駅stationsを時刻timesに発着する区間移動(各駅の停車時間は0)
+func testTrain(trainID uint, stations []uint, times []time.Time) []models.Operation {
+	operations := make([]models.Operation, 0, len(stations)-1)
+	for i := 1; i < len(stations); i++ {
+		op := testOperation(trainID, stations[i-1], times[i-1], stations[i], times[i])
+		op.Order = uint(i)
+		operations = append(operations, op)
+	}
+	return operations
+}
+
+// 停車駅stationsに時刻timesで停車する列車(始発駅は発車時刻のみ、終着駅は到着時刻のみ)
+func testMemoryTrain(trainID uint, stations []uint, times []string) models.MemoryTrain {
+	train := models.MemoryTrain{ID: trainID, NetworkID: 1}
+	for i, stationID := range stations {
+		stopTime := models.StopTime{TrainID: trainID, StopSequence: uint(i + 1), StationID: stationID}
+		if i > 0 {
+			stopTime.ArriveTime = &times[i]
+		}
+		if i < len(stations)-1 {
+			stopTime.DepartTime = &times[i]
+		}
+		train.StopTimes = append(train.StopTimes, stopTime)
+	}
+	return train
+}
+
+// 条件の無い探索情報(出発日時departから、所要時間・待ち時間の上限は十分に長い)
+func newTestSearchContext(depart time.Time) *searchContext {
+	return &searchContext{
+		avoided:   newAvoidedDisruptions(),
+		avoidSta:  make(map[uint]struct{}),
+		depart:    depart,
+		maxTravel: 24 * time.Hour,
+		maxWait:   24 * time.Hour,
+	}
+}
+
+// 駅1〜4(駅3のみ段差あり)と、駅1→2→3の列車1、駅3→4の列車2、駅2→4の列車3、駅1→4の直通列車4
+var (
+	searchTestStations = []models.Station{
+		{ID: 1, Name: "駅1", StepFree: true},
+		{ID: 2, Name: "駅2", StepFree: true},
+		{ID: 3, Name: "駅3"},
+		{ID: 4, Name: "駅4", StepFree: true},
+	}
+	searchTestTrains = []models.MemoryTrain{
+		testMemoryTrain(1, []uint{1, 2, 3}, []string{"08:00:00", "08:10:00", "08:20:00"}),
+		testMemoryTrain(2, []uint{3, 4}, []string{"08:25:00", "08:40:00"}),
+		testMemoryTrain(3, []uint{2, 4}, []string{"08:15:00", "08:45:00"}),
+		testMemoryTrain(4, []uint{1, 4}, []string{"08:30:00", "09:00:00"}),
+	}
+)
+
+// 一時ディレクトリのSQLiteに最新のスキーマを作成し、探索用の駅・時刻表を登録する
+func newSearchTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	db, err := database.ConnectSQLite(filepath.Join(t.TempDir(), "test.db"), true)
+	if err != nil {
+		t.Fatalf("ConnectSQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	exec := func(query string, args ...any) {
+		t.Helper()
+		if _, err := db.Exec(query, args...); err != nil {
+			t.Fatalf("exec %q: %v", query, err)
+		}
+	}
+	for _, s := range searchTestStations {
+		exec(`INSERT INTO stations (id, network_id, name, name_en, step_free) VALUES (?, 1, ?, '', ?)`, s.ID, s.Name, s.StepFree)
+	}
+	for _, train := range searchTestTrains {
+		exec(`INSERT INTO trains (id, network_id) VALUES (?, 1)`, train.ID)
+		for _, st := range train.StopTimes {
+			exec(`INSERT INTO stop_times (train_id, stop_sequence, station_id, arr_time, dep_time) VALUES (?, ?, ?, ?, ?)`,
+				st.TrainID, st.StopSequence, st.StationID, st.ArriveTime, st.DepartTime)
+		}
+	}
+	return db
+}
+
+// 探索用の駅・時刻表のリポジトリ(backendは"memory"またはdbを使う"sqlite")
+func newSearchTestRepositories(t *testing.T, backend string, db *sqlx.DB) models.Repositories {
+	t.Helper()
+	if backend != "memory" {
+		return models.NewSQLiteRepositories(db)
+	}
+	repos, err := models.NewMemoryRepositories(map[uint][]models.Station{1: searchTestStations}, searchTestTrains)
+	if err != nil {
+		t.Fatalf("NewMemoryRepositories: %v", err)
+	}
+	return repos
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"outtech105.com/transit_server/models"
+)
+
+// 出発駅×到着駅の所要時間行列を探索するパラメータ
+// NOTE: 経由駅(ViaStationIDs)の指定には対応しない
+type MatrixSearchParams struct {
+	OriginStationIDs      []uint
+	DestinationStationIDs []uint
+	DepartDateTime        time.Time
+	SearchConstraints
+}
+
+// 所要時間行列の探索結果
+type MatrixSearchResult struct {
+	// Cells[i][j]は、OriginStationIDs[i]からDestinationStationIDs[j]への最早到着(到達できない場合はnil)
+	// 出発駅と到着駅が同じ場合は、出発日時に乗換0回で到着したものとする
+	Cells                [][]*ReachableStation
+	AvoidedCancellations []models.TrainCancellation
+	AvoidedSuspensions   []models.SegmentSuspension
+	Complete             bool // 全出発駅の探索を行えたか(期限切れの場合はfalse、未探索の行はすべてnil)
+}
+
+// 出発駅ごとに1回のCSAで全駅への最早到着を求め、到着駅の列を取り出して行列とする
+// 時刻表の取得・探索情報の生成は、全出発駅で共有する
+// NOTE: ctxの期限を過ぎた場合はエラーとせず、それまでに探索した行をComplete=falseとして返す
+func SearchTravelTimeMatrix(ctx context.Context, networkID uint, req MatrixSearchParams, repos models.Repositories, db *sqlx.DB) (MatrixSearchResult, error) {
+	cells := make([][]*ReachableStation, len(req.OriginStationIDs))
+	for i := range cells {
+		cells[i] = make([]*ReachableStation, len(req.DestinationStationIDs))
+	}
+
+	search, err := newSearchContext(ctx, networkID, repos, db, req.DepartDateTime, req.SearchConstraints)
+	if err != nil {
+		if isSearchTimeout(ctx, err) {
+			return MatrixSearchResult{Cells: cells, Complete: false}, nil
+		}
+		return MatrixSearchResult{}, err
+	}
+
+	connections, err := loadConnections(ctx, networkID, repos, req.DepartDateTime, req.DepartDateTime.Add(req.MaxTravel))
+	if err != nil {
+		if isSearchTimeout(ctx, err) {
+			return MatrixSearchResult{Cells: cells, Complete: false}, nil
+		}
+		return MatrixSearchResult{}, fmt.Errorf("loadConnections: %w", err)
+	}
+
+	profile := newProfileSearch(search, connections, req.MaxTransfers)
+
+	// ctxの期限を過ぎた場合は探索を打ち切り、それまでに探索した行のみ返す
+	complete := true
+	for i, originStationID := range req.OriginStationIDs {
+		if err := ctx.Err(); err != nil {
+			if !errors.Is(err, context.DeadlineExceeded) {
+				return MatrixSearchResult{}, err
+			}
+			complete = false
+			break
+		}
+
+		// 走査を打ち切った行は、到着が確定しないため未探索(nil)とする
+		stationLabels, _, scanned := profile.scanLabels(ctx, originStationID, req.DepartDateTime)
+		if !scanned {
+			complete = false
+			break
+		}
+		arrivals := make(map[uint]ReachableStation, len(stationLabels))
+		for _, reachable := range profile.earliestArrivals(originStationID, stationLabels) {
+			arrivals[reachable.StationID] = reachable
+		}
+		arrivals[originStationID] = ReachableStation{StationID: originStationID, ArriveDatetime: req.DepartDateTime}
+
+		for j, destinationStationID := range req.DestinationStationIDs {
+			if reachable, isReached := arrivals[destinationStationID]; isReached {
+				cells[i][j] = &reachable
+			}
+		}
+	}
+
+	cancellations, suspensions := search.avoided.list()
+	return MatrixSearchResult{
+		Cells:                cells,
+		AvoidedCancellations: cancellations,
+		AvoidedSuspensions:   suspensions,
+		Complete:             complete,
+	}, nil
+}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"outtech105.com/transit_server/models"
+)
+
+// 行列の要約(出発駅ごとに、到着駅の順の 到着時刻/乗換回数、到達できない組は-)
+func matrixSummary(cells [][]*ReachableStation) []string {
+	rows := make([]string, 0, len(cells))
+	for _, row := range cells {
+		summaries := make([]string, 0, len(row))
+		for _, cell := range row {
+			if cell == nil {
+				summaries = append(summaries, "-")
+				continue
+			}
+			summaries = append(summaries, fmt.Sprintf("%s/%d", cell.ArriveDatetime.Format("15:04"), cell.Transfers))
+		}
+		rows = append(rows, fmt.Sprint(summaries))
+	}
+	return rows
+}
+
+func TestSearchTravelTimeMatrix(t *testing.T) {
+	req := MatrixSearchParams{
+		OriginStationIDs:      []uint{1, 2},
+		DestinationStationIDs: []uint{4, 3, 1},
+		DepartDateTime:        at(7, 50),
+		SearchConstraints:     SearchConstraints{MaxTransfers: 2, MaxTravel: 3 * time.Hour, MaxWait: time.Hour},
+	}
+
+	tests := []struct {
+		name        string
+		cancelled   []uint // 2024-10-01に運休する列車ID
+		modify      func(c *SearchConstraints)
+		want        []string
+		wantAvoided []uint
+	}{
+		{
+			name: "all trains",
+			want: []string{
+				"[08:40/1 08:20/0 07:50/0]",
+				"[08:40/1 08:20/0 -]",
+			},
+			wantAvoided: []uint{},
+		},
+		{
+			name:      "cancelled train",
+			cancelled: []uint{2},
+			want: []string{
+				"[08:45/1 08:20/0 07:50/0]",
+				"[08:45/0 08:20/0 -]",
+			},
+			wantAvoided: []uint{2},
+		},
+		{
+			name:   "max travel",
+			modify: func(c *SearchConstraints) { c.MaxTravel = 40 * time.Minute },
+			want: []string{
+				"[- 08:20/0 07:50/0]",
+				"[- 08:20/0 -]",
+			},
+			wantAvoided: []uint{},
+		},
+	}
+
+	for _, backend := range []string{"memory", "sqlite"} {
+		for _, tt := range tests {
+			t.Run(backend+"/"+tt.name, func(t *testing.T) {
+				db := newSearchTestDB(t)
+				for _, trainID := range tt.cancelled {
+					if _, err := db.Exec(`INSERT INTO train_cancellations (train_id, service_date) VALUES (?, '2024-10-01')`, trainID); err != nil {
+						t.Fatalf("insert cancellation: %v", err)
+					}
+				}
+				repos := newSearchTestRepositories(t, backend, db)
+
+				r := req
+				if tt.modify != nil {
+					tt.modify(&r.SearchConstraints)
+				}
+				result, err := SearchTravelTimeMatrix(context.Background(), 1, r, repos, db)
+				if err != nil {
+					t.Fatalf("SearchTravelTimeMatrix: %v", err)
+				}
+				if !result.Complete {
+					t.Errorf("Complete = false, want true")
+				}
+				if got := matrixSummary(result.Cells); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Errorf("cells = %q, want %q", got, tt.want)
+				}
+				avoided := make([]uint, 0, len(result.AvoidedCancellations))
+				for _, c := range result.AvoidedCancellations {
+					avoided = append(avoided, c.TrainID)
+				}
+				if fmt.Sprint(avoided) != fmt.Sprint(tt.wantAvoided) {
+					t.Errorf("avoided = %v, want %v", avoided, tt.wantAvoided)
+				}
+			})
+		}
+	}
+}
+
+// 期限を過ぎた場合は、未探索の行をすべてnilとしてComplete=falseを返す
+func TestSearchTravelTimeMatrixTimeout(t *testing.T) {
+	db := newSearchTestDB(t)
+	repos, err := models.NewMemoryRepositories(map[uint][]models.Station{1: searchTestStations}, searchTestTrains)
+	if err != nil {
+		t.Fatalf("NewMemoryRepositories: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+
+	req := MatrixSearchParams{
+		OriginStationIDs:      []uint{1, 2},
+		DestinationStationIDs: []uint{4},
+		DepartDateTime:        at(7, 50),
+		SearchConstraints:     SearchConstraints{MaxTransfers: 2, MaxTravel: 3 * time.Hour, MaxWait: time.Hour},
+	}
+	result, err := SearchTravelTimeMatrix(ctx, 1, req, repos, db)
+	if err != nil {
+		t.Fatalf("SearchTravelTimeMatrix: %v", err)
+	}
+	if result.Complete {
+		t.Errorf("Complete = true, want false")
+	}
+	if got := matrixSummary(result.Cells); fmt.Sprint(got) != fmt.Sprint([]string{"[-]", "[-]"}) {
+		t.Errorf("cells = %q, want all nil", got)
+	}
+}
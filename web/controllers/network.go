@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"outtech105.com/transit_server/models"
+)
+
+// 鉄道網ごとのメモリ上の索引
+// 駅・事業者の情報は起動時に読み込み、存在確認・駅名の解決・情報の取得をDB問い合わせなしで行う
+// NOTE: 駅・事業者の情報をDBで変更した場合は、サーバの再起動が必要
+type NetworkIndex struct {
+	models.Network
+	Location       *time.Location // 時刻表の時刻のタイムゾーン
+	stations       map[uint]models.Station
+	stationsByName map[string][]models.Station
+	agencies       []models.Agency // ID順
+}
+
+// 全鉄道網の索引を読み込み、鉄道網の識別子(code)から索引への対応を返す
+func LoadNetworkIndexes(ctx context.Context, repos models.Repositories, db *sqlx.DB) (map[string]*NetworkIndex, error) {
+	networks, err := models.GetNetworks(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[string]*NetworkIndex, len(networks))
+	for _, network := range networks {
+		loc, err := network.Location()
+		if err != nil {
+			return nil, fmt.Errorf("network %s: %w", network.Code, err)
+		}
+		stations, err := repos.Stations.GetStations(ctx, network.ID)
+		if err != nil {
+			return nil, fmt.Errorf("network %s: %w", network.Code, err)
+		}
+		agencies, err := models.GetAgencies(ctx, db, network.ID)
+		if err != nil {
+			return nil, fmt.Errorf("network %s: %w", network.Code, err)
+		}
+
+		index := &NetworkIndex{
+			Network:        network,
+			Location:       loc,
+			stations:       make(map[uint]models.Station, len(stations)),
+			stationsByName: make(map[string][]models.Station, len(stations)),
+			agencies:       agencies,
+		}
+		for _, station := range stations {
+			index.stations[station.ID] = station
+			index.stationsByName[station.Name] = append(index.stationsByName[station.Name], station)
+		}
+		indexes[network.Code] = index
+	}
+	return indexes, nil
+}
+
+// 駅IDから駅情報を返す(鉄道網に属さない駅の場合はfalse)
+func (n *NetworkIndex) Station(id uint) (models.Station, bool) {
+	station, isFound := n.stations[id]
+	return station, isFound
+}
+
+// 駅名から完全一致検索で駅一覧を返す
+func (n *NetworkIndex) StationsByName(name string) []models.Station {
+	return n.stationsByName[name]
+}
+
+// 事業者の一覧をID順に返す
+func (n *NetworkIndex) Agencies() []models.Agency {
+	return n.agencies
+}
+
+// 事業者IDから事業者情報を返す(鉄道網に属さない事業者の場合はfalse)
+func (n *NetworkIndex) Agency(id uint) (models.Agency, bool) {
+	for _, agency := range n.agencies {
+		if agency.ID == id {
+			return agency, true
+		}
+	}
+	return models.Agency{}, false
+}
+
+// 管理用エンドポイントの認証情報が設定されているか
+func (n *NetworkIndex) HasAdminAccount() bool {
+	return n.AdminUser != nil && n.AdminPasswordHash != nil
+}
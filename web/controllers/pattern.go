@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"outtech105.com/transit_server/models"
+)
+
+var (
+	ErrInvalidServicePattern = errors.New("invalid service pattern")
+)
+
+// 運行パターンの展開結果
+type PatternExpansion struct {
+	Pattern  models.ServicePattern
+	Trains   []models.PatternTrain
+	TrainIDs []uint // dryRunの場合は空
+}
+
+// 運行パターンを列車・運行に展開し、dryRunでなければ生成済みの列車と置き換える
+func ExpandServicePattern(ctx context.Context, networkID uint, patternID uint, dryRun bool, db *sqlx.DB) (PatternExpansion, error) {
+	pattern, err := models.GetServicePatternByID(ctx, db, networkID, patternID)
+	if err != nil {
+		return PatternExpansion{}, err
+	}
+
+	trains, err := expandPatternTrains(pattern)
+	if err != nil {
+		return PatternExpansion{}, err
+	}
+
+	expansion := PatternExpansion{Pattern: pattern, Trains: trains, TrainIDs: []uint{}}
+	if dryRun {
+		return expansion, nil
+	}
+
+	expansion.TrainIDs, err = models.ReplacePatternTrains(ctx, db, pattern, trains)
+	if err != nil {
+		return PatternExpansion{}, fmt.Errorf("replacePatternTrains: %w", err)
+	}
+	return expansion, nil
+}
+
+// 運転間隔ごとに始発時刻を決め、停車駅・駅間所要時間から列車の運行を生成
+func expandPatternTrains(pattern models.ServicePattern) ([]models.PatternTrain, error) {
+	if len(pattern.Stops) < 2 {
+		return nil, fmt.Errorf("%w: at least 2 stops are required", ErrInvalidServicePattern)
+	}
+	for _, stop := range pattern.Stops[1:] {
+		if stop.Run <= 0 {
+			return nil, fmt.Errorf("%w: run time to stop %d must be positive", ErrInvalidServicePattern, stop.Sequence)
+		}
+	}
+
+	baseDatetime := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	trains := make([]models.PatternTrain, 0, 100)
+	for _, frequency := range pattern.Frequencies {
+		if frequency.Headway <= 0 {
+			return nil, fmt.Errorf("%w: headway must be positive", ErrInvalidServicePattern)
+		}
+
+		// 終了時刻が開始時刻以前の場合、日付を跨ぐ指定とみなす
+		end := frequency.End
+		if end <= frequency.Start {
+			end += 24 * time.Hour
+		}
+
+		for start := frequency.Start; start < end; start += frequency.Headway {
+			departDatetime := baseDatetime.Add(start)
+			operations := make([]models.Operation, 0, len(pattern.Stops)-1)
+			for i := 1; i < len(pattern.Stops); i++ {
+				arriveDatetime := departDatetime.Add(pattern.Stops[i].Run)
+				operations = append(operations, models.Operation{
+					Order:           uint(i),
+					DepartStationID: pattern.Stops[i-1].StationID,
+					DepartDatetime:  departDatetime,
+					ArriveStationID: pattern.Stops[i].StationID,
+					ArriveDatetime:  arriveDatetime,
+					DepartPlatform:  pattern.Stops[i-1].Platform,
+					ArrivePlatform:  pattern.Stops[i].Platform,
+				})
+				departDatetime = arriveDatetime.Add(pattern.Stops[i].Dwell)
+			}
+
+			train := models.PatternTrain{Operations: operations}
+			if pattern.TrainNamePrefix != "" {
+				name := pattern.TrainNamePrefix + baseDatetime.Add(start).Format("1504")
+				train.Name = &name
+			}
+			trains = append(trains, train)
+		}
+	}
+
+	return trains, nil
+}
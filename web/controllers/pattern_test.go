@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"outtech105.com/transit_server/models"
+)
+
+// 生成した列車の要約(列車名: 駅 出発-到着 駅 ...、時刻は最初の列車の運行日0:00からの経過時間)
+func patternTrainSummaries(trains []models.PatternTrain) []string {
+	if len(trains) == 0 {
+		return []string{}
+	}
+	first := trains[0].Operations[0].DepartDatetime
+	base := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, first.Location())
+
+	summaries := make([]string, 0, len(trains))
+	for _, train := range trains {
+		name := "-"
+		if train.Name != nil {
+			name = *train.Name
+		}
+		summary := name + ":"
+		for _, op := range train.Operations {
+			summary += fmt.Sprintf(" %d %s-%s", op.DepartStationID, elapsedClock(op.DepartDatetime.Sub(base)), elapsedClock(op.ArriveDatetime.Sub(base)))
+		}
+		summaries = append(summaries, summary+fmt.Sprintf(" %d", train.Operations[len(train.Operations)-1].ArriveStationID))
+	}
+	return summaries
+}
+
+func TestExpandPatternTrains(t *testing.T) {
+	// 駅1→2(10分、停車1分)→3(10分)
+	stops := []models.ServicePatternStop{
+		{Sequence: 1, StationID: 1},
+		{Sequence: 2, StationID: 2, Run: 10 * time.Minute, Dwell: time.Minute},
+		{Sequence: 3, StationID: 3, Run: 10 * time.Minute},
+	}
+	clock := func(hour, minute int) time.Duration {
+		return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+	}
+
+	tests := []struct {
+		name        string
+		prefix      string
+		stops       []models.ServicePatternStop
+		frequencies []models.ServiceFrequency
+		want        []string
+		wantErr     bool
+	}{
+		{
+			name:        "headway",
+			prefix:      "A",
+			stops:       stops,
+			frequencies: []models.ServiceFrequency{{Start: clock(8, 0), End: clock(8, 30), Headway: 15 * time.Minute}},
+			want: []string{
+				"A0800: 1 08:00-08:10 2 08:11-08:21 3",
+				"A0815: 1 08:15-08:25 2 08:26-08:36 3",
+			},
+		},
+		{
+			name:        "without train name prefix",
+			stops:       stops,
+			frequencies: []models.ServiceFrequency{{Start: clock(8, 0), End: clock(8, 10), Headway: 15 * time.Minute}},
+			want:        []string{"-: 1 08:00-08:10 2 08:11-08:21 3"},
+		},
+		{
+			name:   "multiple frequencies",
+			prefix: "A",
+			stops:  stops,
+			frequencies: []models.ServiceFrequency{
+				{Start: clock(7, 0), End: clock(7, 1), Headway: 30 * time.Minute},
+				{Start: clock(9, 0), End: clock(9, 1), Headway: 30 * time.Minute},
+			},
+			want: []string{
+				"A0700: 1 07:00-07:10 2 07:11-07:21 3",
+				"A0900: 1 09:00-09:10 2 09:11-09:21 3",
+			},
+		},
+		{
+			// 終了時刻が開始時刻より前の場合は、翌日の終了時刻まで
+			name:        "window across midnight",
+			prefix:      "A",
+			stops:       stops,
+			frequencies: []models.ServiceFrequency{{Start: clock(23, 50), End: clock(0, 20), Headway: 15 * time.Minute}},
+			want: []string{
+				"A2350: 1 23:50-24:00 2 24:01-24:11 3",
+				"A0005: 1 24:05-24:15 2 24:16-24:26 3",
+			},
+		},
+		{
+			name:        "window ending at midnight",
+			prefix:      "A",
+			stops:       stops,
+			frequencies: []models.ServiceFrequency{{Start: clock(23, 30), End: 0, Headway: 15 * time.Minute}},
+			want: []string{
+				"A2330: 1 23:30-23:40 2 23:41-23:51 3",
+				"A2345: 1 23:45-23:55 2 23:56-24:06 3",
+			},
+		},
+		{
+			name:        "window ending past 24:00",
+			prefix:      "A",
+			stops:       stops,
+			frequencies: []models.ServiceFrequency{{Start: clock(23, 50), End: clock(24, 10), Headway: 15 * time.Minute}},
+			want: []string{
+				"A2350: 1 23:50-24:00 2 24:01-24:11 3",
+				"A0005: 1 24:05-24:15 2 24:16-24:26 3",
+			},
+		},
+		{
+			name:        "single stop",
+			stops:       stops[:1],
+			frequencies: []models.ServiceFrequency{{Start: clock(8, 0), End: clock(9, 0), Headway: 15 * time.Minute}},
+			wantErr:     true,
+		},
+		{
+			name:        "zero run time",
+			stops:       []models.ServicePatternStop{stops[0], {Sequence: 2, StationID: 2}},
+			frequencies: []models.ServiceFrequency{{Start: clock(8, 0), End: clock(9, 0), Headway: 15 * time.Minute}},
+			wantErr:     true,
+		},
+		{
+			name:        "zero headway",
+			stops:       stops,
+			frequencies: []models.ServiceFrequency{{Start: clock(8, 0), End: clock(9, 0)}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := models.ServicePattern{TrainNamePrefix: tt.prefix, Stops: tt.stops, Frequencies: tt.frequencies}
+			trains, err := expandPatternTrains(pattern)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidServicePattern) {
+					t.Fatalf("expandPatternTrains() error = %v, want %v", err, ErrInvalidServicePattern)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandPatternTrains: %v", err)
+			}
+			if got := patternTrainSummaries(trains); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("trains = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
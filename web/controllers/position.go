@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"outtech105.com/transit_server/models"
+)
+
+// 走行中・停車中列車の位置
+type TrainPosition struct {
+	Operation models.Operation
+	TrainName *string
+	Progress  float64  // 出発駅(0)〜到着駅(1)間の進捗率(出発駅に停車中は0)
+	Lat       *float64 // 両端駅の座標が設定されている場合のみ補間する(停車中は出発駅の座標)
+	Lon       *float64
+}
+
+// 指定日時に走行中の列車の位置を、現在の区間の出発・到着時刻から線形補間して取得
+// 駅に停車中の列車は、その駅を出発する区間の進捗率0の位置とする
+// NOTE: 運休・運転見合わせの影響を受ける列車は除外する
+func GetTrainPositions(ctx context.Context, networkID uint, datetime time.Time, repos models.Repositories, db *sqlx.DB) ([]TrainPosition, error) {
+	runningOperations, err := repos.Operations.GetRunningOperations(ctx, networkID, datetime)
+	if err != nil {
+		return nil, fmt.Errorf("getRunningOperations: %w", err)
+	}
+
+	disruptions, err := models.GetDisruptions(ctx, db, networkID, datetime)
+	if err != nil {
+		return nil, fmt.Errorf("getDisruptions: %w", err)
+	}
+
+	positions := make([]TrainPosition, 0, len(runningOperations))
+	for _, op := range runningOperations {
+		if _, isCancelled := disruptions.CancellationOf(op.Operation); isCancelled {
+			continue
+		}
+		if _, isSuspended := disruptions.SuspensionOf(op.Operation); isSuspended {
+			continue
+		}
+
+		position := TrainPosition{
+			Operation: op.Operation,
+			TrainName: op.TrainName,
+			Progress:  progressBetween(op.DepartDatetime, op.ArriveDatetime, datetime),
+		}
+		switch {
+		case op.DepartLat != nil && op.DepartLon != nil && op.ArriveLat != nil && op.ArriveLon != nil:
+			lat := *op.DepartLat + (*op.ArriveLat-*op.DepartLat)*position.Progress
+			lon := *op.DepartLon + (*op.ArriveLon-*op.DepartLon)*position.Progress
+			position.Lat, position.Lon = &lat, &lon
+		case position.Progress == 0 && op.DepartLat != nil && op.DepartLon != nil:
+			// 出発駅に停車中は、到着駅の座標が無くても出発駅の座標とする
+			position.Lat, position.Lon = op.DepartLat, op.DepartLon
+		}
+		positions = append(positions, position)
+	}
+
+	return positions, nil
+}
+
+// from〜untilの期間における、datetimeの進捗率(0〜1)
+func progressBetween(from time.Time, until time.Time, datetime time.Time) float64 {
+	total := until.Sub(from)
+	if total <= 0 {
+		return 1
+	}
+	return min(max(float64(datetime.Sub(from))/float64(total), 0), 1)
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"outtech105.com/transit_server/models"
+)
+
+// 駅間の走行中は位置を補間し、駅に停車中はその駅(進捗率0)とする
+func TestGetTrainPositions(t *testing.T) {
+	db := newSearchTestDB(t)
+	lat1, lon1, lat2, lon2 := 35.0, 139.0, 35.1, 139.1
+	clock := func(s string) *string { return &s }
+	repos, err := models.NewMemoryRepositories(
+		map[uint][]models.Station{1: {
+			{ID: 1, Lat: &lat1, Lon: &lon1},
+			{ID: 2, Lat: &lat2, Lon: &lon2},
+			{ID: 3},
+		}},
+		[]models.MemoryTrain{{
+			ID:        1,
+			NetworkID: 1,
+			StopTimes: []models.StopTime{
+				{TrainID: 1, StopSequence: 1, StationID: 1, DepartTime: clock("08:00:00")},
+				{TrainID: 1, StopSequence: 2, StationID: 2, ArriveTime: clock("08:10:00"), DepartTime: clock("08:12:00")},
+				{TrainID: 1, StopSequence: 3, StationID: 3, ArriveTime: clock("08:20:00")},
+			},
+		}},
+	)
+	if err != nil {
+		t.Fatalf("NewMemoryRepositories: %v", err)
+	}
+
+	// 位置の要約(区間 進捗率 緯度,経度)
+	tests := []struct {
+		name string
+		hour int
+		min  int
+		want []string
+	}{
+		{name: "running", hour: 8, min: 5, want: []string{"#1 1>2 0.50 35.0500,139.0500"}},
+		{name: "arriving", hour: 8, min: 10, want: []string{"#2 2>3 0.00 35.1000,139.1000"}},
+		{name: "dwelling", hour: 8, min: 11, want: []string{"#2 2>3 0.00 35.1000,139.1000"}},
+		{name: "running without coordinates", hour: 8, min: 16, want: []string{"#2 2>3 0.50 -"}},
+		{name: "terminated", hour: 8, min: 20, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			positions, err := GetTrainPositions(context.Background(), 1, at(tt.hour, tt.min), repos, db)
+			if err != nil {
+				t.Fatalf("GetTrainPositions: %v", err)
+			}
+			got := make([]string, 0, len(positions))
+			for _, p := range positions {
+				location := "-"
+				if p.Lat != nil && p.Lon != nil {
+					location = fmt.Sprintf("%.4f,%.4f", *p.Lat, *p.Lon)
+				}
+				got = append(got, fmt.Sprintf("#%d %d>%d %.2f %s", p.Operation.Order, p.Operation.DepartStationID, p.Operation.ArriveStationID, p.Progress, location))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("positions = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,494 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"outtech105.com/transit_server/models"
+)
+
+// 出発時刻の範囲を指定した経路探索パラメータ
+// NOTE: 経由駅(ViaStationIDs)の指定には対応しない
+type TransitSearchParamsByRange struct {
+	DepartStationID uint
+	DepartFrom      time.Time
+	DepartUntil     time.Time
+	ArriveStationID uint
+	SearchConstraints
+}
+
+// 列車の運行日ごとの識別子
+// NOTE: 同じ列車でも、運行日が異なれば別の便として扱う
+type tripKey struct {
+	trainID uint
+	day     int
+}
+
+// Connection Scan Algorithm(CSA)における接続(1区間移動)
+type connection struct {
+	op   models.Operation
+	trip tripKey
+}
+
+// 駅・列車への到達状態(乗換回数と、到達に使った接続)
+type connectionLabel struct {
+	transfers int
+	conn      int // connectionsの添字(出発駅から乗車する場合は-1)
+}
+
+// 接続に乗車中の状態から到着駅に到着する、乗換回数の上限ごとの最早到着
+// arrivals[k]は乗換k回以内での最早到着(到達できない場合はゼロ値)、next[k]はその経路で次に乗る接続
+type connectionProfile struct {
+	arrivals []time.Time
+	next     []profileStep
+}
+
+// プロファイル上の経路で、接続の次に乗る接続
+type profileStep struct {
+	conn     int  // connectionsの添字(到着駅で降車する場合は-1)
+	transfer bool // 降車して乗り換えるか(falseの場合は乗ったまま移る)
+}
+
+// 駅の出発番線(乗換時間が同じになる出発の単位)
+type departureKey struct {
+	stationID   uint
+	platform    string
+	hasPlatform bool
+}
+
+// 出発時刻の範囲内で、他に優越されない経路をすべて探索するための情報
+// NOTE: 出発駅・到着駅は探索ごとに引数で指定し、探索中に状態を書き換えない
+type profileSearch struct {
+	search        *searchContext
+	connections   []connection
+	relationsTo   map[[2]uint][]uint // [乗ったまま移る先の列車ID, 駅ID]から、移る元の列車IDへの対応
+	relationsFrom map[[2]uint][]uint // [乗ったまま移る元の列車ID, 駅ID]から、移る先の列車IDへの対応
+	maxTransfers  int
+}
+
+// 探索の共有情報と接続から、プロファイル探索の情報を生成
+func newProfileSearch(search *searchContext, connections []connection, maxTransfers int) *profileSearch {
+	profile := &profileSearch{
+		search:        search,
+		connections:   connections,
+		relationsTo:   make(map[[2]uint][]uint),
+		relationsFrom: make(map[[2]uint][]uint),
+		maxTransfers:  maxTransfers,
+	}
+	for _, relation := range search.relations {
+		to := [2]uint{relation.ToTrainID, relation.StationID}
+		profile.relationsTo[to] = append(profile.relationsTo[to], relation.FromTrainID)
+		from := [2]uint{relation.FromTrainID, relation.StationID}
+		profile.relationsFrom[from] = append(profile.relationsFrom[from], relation.ToTrainID)
+	}
+	return profile
+}
+
+// 出発時刻の範囲を指定して、乗り換え案内を検索(プロファイル探索)
+// 接続を出発の遅い順に1回走査するプロファイルCSAで、出発が遅い・到着が早い・乗換が少ない、のいずれでも他に劣らない経路をすべて返す
+// NOTE: ctxの期限を過ぎた場合はエラーとせず、それまでに見つかった経路をComplete=falseとして返す
+func SearchTransitByRange(ctx context.Context, networkID uint, req TransitSearchParamsByRange, repos models.Repositories, db *sqlx.DB) (TransitSearchResult, error) {
+	search, err := newSearchContext(ctx, networkID, repos, db, req.DepartFrom, req.SearchConstraints)
+	if err != nil {
+		if isSearchTimeout(ctx, err) {
+			return TransitSearchResult{Complete: false}, nil
+		}
+		return TransitSearchResult{}, err
+	}
+
+	connections, err := loadConnections(ctx, networkID, repos, req.DepartFrom, req.DepartUntil.Add(req.MaxTravel))
+	if err != nil {
+		if isSearchTimeout(ctx, err) {
+			return search.result(nil, false), nil
+		}
+		return TransitSearchResult{}, fmt.Errorf("loadConnections: %w", err)
+	}
+
+	profile := newProfileSearch(search, connections, req.MaxTransfers)
+	routes, scanned := profile.profileRoutes(ctx, req.DepartStationID, req.ArriveStationID, req.DepartFrom, req.DepartUntil)
+	if !scanned && !isSearchTimeout(ctx, nil) {
+		return TransitSearchResult{}, ctx.Err()
+	}
+	return search.result(routes, scanned), nil
+}
+
+// 日時範囲内に出発する全列車の区間移動を、出発時刻順の接続として取得
+// NOTE: 夏時間の切替日も時刻表の時刻(壁時計)で発着するよう、日ごとにその日を基準として時刻を求める
+func loadConnections(ctx context.Context, networkID uint, repos models.Repositories, from time.Time, until time.Time) ([]connection, error) {
+	// 日付を跨ぐ列車を含めるため、fromの前日0:00から1日ずつ、その日を基準に列車ごとの時刻を求める
+	baseDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location()).AddDate(0, 0, -1)
+	connections := make([]connection, 0, 100)
+	for day := 0; !baseDate.AddDate(0, 0, day).After(until); day++ {
+		operations, err := repos.Operations.GetTimetableOperations(ctx, networkID, baseDate.AddDate(0, 0, day))
+		if err != nil {
+			return nil, fmt.Errorf("getTimetableOperations: %w", err)
+		}
+		for _, op := range operations {
+			if op.DepartDatetime.Before(from) || op.DepartDatetime.After(until) {
+				continue
+			}
+			connections = append(connections, connection{op: op, trip: tripKey{trainID: op.TrainID, day: day}})
+		}
+	}
+
+	sortConnections(connections)
+
+	return connections, nil
+}
+
+// 接続を出発時刻順(同時刻は到着時刻・列車ID・区間の順序の順)に並べ替える
+func sortConnections(connections []connection) {
+	sort.SliceStable(connections, func(i, j int) bool {
+		a, b := connections[i].op, connections[j].op
+		if !a.DepartDatetime.Equal(b.DepartDatetime) {
+			return a.DepartDatetime.Before(b.DepartDatetime)
+		}
+		if !a.ArriveDatetime.Equal(b.ArriveDatetime) {
+			return a.ArriveDatetime.Before(b.ArriveDatetime)
+		}
+		if a.TrainID != b.TrainID {
+			return a.TrainID < b.TrainID
+		}
+		return a.Order < b.Order
+	})
+}
+
+// 走査中にctxの期限を確認する間隔(接続の件数)
+const scanCheckInterval = 1024
+
+// 出発駅originをdepart以降に出発する場合に、降車して到達できる駅ごとの状態をCSAで求める
+// 駅IDから(到着時刻・乗換回数のパレート集合)への対応と、接続から経路上の直前の接続への対応、走査を最後まで行えたかを返す
+// NOTE: ctxの期限を過ぎた場合は走査を打ち切り、それまでの状態を返す(より早い到着が見つかっていない可能性がある)
+func (p *profileSearch) scanLabels(ctx context.Context, origin uint, depart time.Time) (map[uint][]connectionLabel, map[int]int, bool) {
+	s := p.search
+
+	stationLabels := make(map[uint][]connectionLabel) // 降車して駅に到達した状態(到着時刻・乗換回数のパレート集合)
+	tripLabels := make(map[tripKey]connectionLabel)   // 便に乗車中の状態
+	onboardArrivals := make(map[[2]uint]connectionLabel)
+	prev := make(map[int]int) // 接続から、経路上の直前の接続への対応
+
+	arrival := p.arrival
+
+	start := sort.Search(len(p.connections), func(i int) bool {
+		return !p.connections[i].op.DepartDatetime.Before(depart)
+	})
+	for i := start; i < len(p.connections); i++ {
+		if (i-start)%scanCheckInterval == 0 && ctx.Err() != nil {
+			return stationLabels, prev, false
+		}
+
+		c := p.connections[i]
+		if c.op.DepartDatetime.Sub(depart) > s.maxTravel {
+			break
+		}
+		if c.op.ArriveDatetime.Sub(depart) > s.maxTravel {
+			continue
+		}
+
+		// この接続に乗っている状態に、最小の乗換回数で到達する方法を求める
+		best := connectionLabel{transfers: -1}
+		better := func(l connectionLabel) {
+			if best.transfers < 0 || l.transfers < best.transfers {
+				best = l
+			}
+		}
+
+		// 同じ便に乗車中
+		if l, isOnboard := tripLabels[c.trip]; isOnboard {
+			better(l)
+		}
+		// 直通・分割・併合で、別の列車から乗ったまま移る
+		for _, fromTrainID := range p.relationsTo[[2]uint{c.op.TrainID, c.op.DepartStationID}] {
+			l, isOnboard := onboardArrivals[[2]uint{fromTrainID, c.op.DepartStationID}]
+			if !isOnboard || arrival(l.conn).After(c.op.DepartDatetime) || c.op.DepartDatetime.Sub(arrival(l.conn)) > s.maxWait {
+				continue
+			}
+			better(l)
+		}
+		// 出発駅から乗車、または乗換
+		if !c.op.NoBoarding {
+			if c.op.DepartStationID == origin && c.op.DepartDatetime.Sub(depart) <= s.maxWait {
+				better(connectionLabel{transfers: 0, conn: -1})
+			}
+			for _, l := range stationLabels[c.op.DepartStationID] {
+				last := p.connections[l.conn].op
+				if l.transfers+1 > p.maxTransfers || c.op.DepartDatetime.Sub(last.ArriveDatetime) > s.maxWait {
+					continue
+				}
+				if !s.canTransfer(last, c.op) {
+					continue
+				}
+				better(connectionLabel{transfers: l.transfers + 1, conn: l.conn})
+			}
+		}
+		if best.transfers < 0 || !s.isAllowed(c.op) {
+			continue
+		}
+		reached := connectionLabel{transfers: best.transfers, conn: i}
+		// 運休・運転見合わせの影響を受ける接続は使わず、降車して到着駅の状態を改善できた場合のみ回避したものとして記録する
+		if isDisrupted(s.disruptions, c.op) {
+			if !c.op.NoAlighting && !isDominatedLabel(stationLabels[c.op.ArriveStationID], reached, arrival) {
+				s.avoided.check(s.disruptions, c.op)
+			}
+			continue
+		}
+
+		prev[i] = best.conn
+		tripLabels[c.trip] = reached
+		onboardArrivals[[2]uint{c.op.TrainID, c.op.ArriveStationID}] = reached
+		if !c.op.NoAlighting {
+			stationLabels[c.op.ArriveStationID] = addParetoLabel(stationLabels[c.op.ArriveStationID], reached, arrival)
+		}
+	}
+
+	return stationLabels, prev, true
+}
+
+// 接続connの到着時刻
+func (p *profileSearch) arrival(conn int) time.Time {
+	return p.connections[conn].op.ArriveDatetime
+}
+
+// 出発駅originを[from, until]に出発し、到着駅destinationに到着する経路をプロファイルCSAで求める
+// 出発が遅い・到着が早い・乗換が少ない、のいずれでも他に劣らない経路と、走査を最後まで行えたかを返す
+// NOTE: ctxの期限を過ぎた場合は走査を打ち切り、それまでに走査した(出発の遅い)接続による経路のみ返す
+func (p *profileSearch) profileRoutes(ctx context.Context, origin uint, destination uint, from time.Time, until time.Time) ([]Route, bool) {
+	profiles, stationDepartures, scanned := p.scanProfiles(ctx, destination)
+
+	routes := make([]Route, 0, len(stationDepartures[origin]))
+	for _, conn := range stationDepartures[origin] {
+		op := p.connections[conn].op
+		if op.DepartDatetime.Before(from) || op.DepartDatetime.After(until) {
+			continue
+		}
+		arrivals := profiles[conn].arrivals
+		for transfers, arrival := range arrivals {
+			// 乗換を増やしても到着が早くならない場合は、乗換の少ない経路のみ残す
+			if arrival.IsZero() || (transfers > 0 && arrival.Equal(arrivals[transfers-1])) {
+				continue
+			}
+			if arrival.Sub(op.DepartDatetime) > p.search.maxTravel {
+				continue
+			}
+			routes = append(routes, p.profileRoute(profiles, conn, transfers))
+		}
+	}
+	return nonDominatedRoutes(routes), scanned
+}
+
+// 接続を出発の遅い順に走査し、接続ごとに到着駅destinationへの乗換回数の上限ごとの最早到着を求める
+// 接続から到着駅へのプロファイルへの対応と、駅IDから乗車して到着駅に到達できる接続(出発の遅い順)への対応、走査を最後まで行えたかを返す
+// 駅ごとの接続は、出発番線ごとに(出発・到着・乗換回数の)パレート集合となるものだけを残す
+// NOTE: 同じ番線のより遅い出発に劣る出発は、待ち時間の上限を超える場合でも乗換先の候補としない
+func (p *profileSearch) scanProfiles(ctx context.Context, destination uint) (map[int]*connectionProfile, map[uint][]int, bool) {
+	s := p.search
+
+	profiles := make(map[int]*connectionProfile)
+	stationDepartures := make(map[uint][]int)
+	bestArrivals := make(map[departureKey][]time.Time) // 出発番線ごとの、乗換回数の上限ごとの最早到着
+	tripNext := make(map[tripKey]int)                  // 便の、直前に走査した(次の区間の)接続
+	trainDepartures := make(map[[2]uint]int)           // [列車ID, 駅ID]から、直前に走査した(直後に出発する)接続
+
+	for i := len(p.connections) - 1; i >= 0; i-- {
+		if (len(p.connections)-1-i)%scanCheckInterval == 0 && ctx.Err() != nil {
+			return profiles, stationDepartures, false
+		}
+
+		c := p.connections[i]
+		arrivals := make([]time.Time, p.maxTransfers+1)
+		next := make([]profileStep, p.maxTransfers+1)
+		improve := func(transfers int, arrival time.Time, step profileStep) {
+			if arrival.IsZero() {
+				return
+			}
+			if arrivals[transfers].IsZero() || arrival.Before(arrivals[transfers]) {
+				arrivals[transfers], next[transfers] = arrival, step
+			}
+		}
+		onboard := func(conn int) {
+			if profile, isReachable := profiles[conn]; isReachable {
+				for k, arrival := range profile.arrivals {
+					improve(k, arrival, profileStep{conn: conn})
+				}
+			}
+		}
+
+		// 到着駅で降車
+		if c.op.ArriveStationID == destination && !c.op.NoAlighting {
+			for k := range arrivals {
+				improve(k, c.op.ArriveDatetime, profileStep{conn: -1})
+			}
+		}
+		// 同じ便に乗ったまま次の区間へ
+		if conn, hasNext := tripNext[c.trip]; hasNext {
+			onboard(conn)
+		}
+		// 直通・分割・併合で、別の列車に乗ったまま移る
+		for _, toTrainID := range p.relationsFrom[[2]uint{c.op.TrainID, c.op.ArriveStationID}] {
+			conn, hasDeparture := trainDepartures[[2]uint{toTrainID, c.op.ArriveStationID}]
+			if !hasDeparture {
+				continue
+			}
+			depart := p.connections[conn].op.DepartDatetime
+			if depart.Before(c.op.ArriveDatetime) || depart.Sub(c.op.ArriveDatetime) > s.maxWait {
+				continue
+			}
+			onboard(conn)
+		}
+		// 降車して乗換(乗換先は出発の早い順に、待ち時間の上限まで)
+		if !c.op.NoAlighting && p.maxTransfers > 0 {
+			departures := stationDepartures[c.op.ArriveStationID]
+			for j := len(departures) - 1; j >= 0; j-- {
+				conn := departures[j]
+				nextOp := p.connections[conn].op
+				if nextOp.DepartDatetime.Sub(c.op.ArriveDatetime) > s.maxWait {
+					break
+				}
+				if !s.canTransfer(c.op, nextOp) {
+					continue
+				}
+				for k := 1; k <= p.maxTransfers; k++ {
+					improve(k, profiles[conn].arrivals[k-1], profileStep{conn: conn, transfer: true})
+				}
+			}
+		}
+
+		tripNext[c.trip] = i
+		trainDepartures[[2]uint{c.op.TrainID, c.op.DepartStationID}] = i
+		if arrivals[p.maxTransfers].IsZero() || !s.isAllowed(c.op) {
+			continue
+		}
+		key := departureKey{stationID: c.op.DepartStationID}
+		if c.op.DepartPlatform != nil {
+			key.platform, key.hasPlatform = *c.op.DepartPlatform, true
+		}
+		best, isImproved := improvedArrivals(bestArrivals[key], arrivals)
+		// 運休・運転見合わせの影響を受ける接続は使わず、乗車して出発番線の最早到着を改善できた場合のみ回避したものとして記録する
+		if isDisrupted(s.disruptions, c.op) {
+			if !c.op.NoBoarding && isImproved {
+				s.avoided.check(s.disruptions, c.op)
+			}
+			continue
+		}
+		profiles[i] = &connectionProfile{arrivals: arrivals, next: next}
+
+		// 乗車できる場合は、同じ出発番線のより遅い出発に劣らなければ出発駅の乗換先に加える
+		if c.op.NoBoarding || !isImproved {
+			continue
+		}
+		bestArrivals[key] = best
+		stationDepartures[c.op.DepartStationID] = append(stationDepartures[c.op.DepartStationID], i)
+	}
+
+	return profiles, stationDepartures, true
+}
+
+// 乗換回数の上限ごとの最早到着bestを、arrivalsで改善した結果と、改善したかを返す
+// NOTE: 改善しない場合はbestをそのまま返し、改善する場合も元のbestは書き換えない
+func improvedArrivals(best []time.Time, arrivals []time.Time) ([]time.Time, bool) {
+	var improved []time.Time
+	for k, arrival := range arrivals {
+		if arrival.IsZero() || (best != nil && !best[k].IsZero() && !arrival.Before(best[k])) {
+			continue
+		}
+		if improved == nil {
+			improved = make([]time.Time, len(arrivals))
+			copy(improved, best)
+		}
+		improved[k] = arrival
+	}
+	if improved == nil {
+		return best, false
+	}
+	return improved, true
+}
+
+// 接続connに乗車し、乗換transfers回以内で到着駅に到着する経路を、プロファイルをたどって復元
+func (p *profileSearch) profileRoute(profiles map[int]*connectionProfile, conn int, transfers int) Route {
+	route := Route{
+		Operations:  make([]models.Operation, 0, 10),
+		ViaStations: make(map[uint]struct{}),
+	}
+	for conn >= 0 {
+		op := p.connections[conn].op
+		route.Operations = append(route.Operations, op)
+		route.ViaStations[op.DepartStationID] = struct{}{}
+		route.ViaStations[op.ArriveStationID] = struct{}{}
+
+		step := profiles[conn].next[transfers]
+		if step.transfer {
+			transfers--
+		}
+		conn = step.conn
+	}
+	return p.search.completeRoute(route)
+}
+
+// 到着時刻・乗換回数のパレート集合にラベルを追加
+// 既存のラベルに劣る場合は追加せず、新たなラベルに劣る既存のラベルは除く
+func addParetoLabel(labels []connectionLabel, l connectionLabel, arrival func(int) time.Time) []connectionLabel {
+	if isDominatedLabel(labels, l, arrival) {
+		return labels
+	}
+
+	kept := make([]connectionLabel, 0, len(labels)+1)
+	for _, existing := range labels {
+		if !arrival(l.conn).After(arrival(existing.conn)) && l.transfers <= existing.transfers {
+			continue
+		}
+		kept = append(kept, existing)
+	}
+	return append(kept, l)
+}
+
+// ラベルlが、既存のラベルのいずれかに(到着時刻・乗換回数のどちらでも)劣るか
+func isDominatedLabel(labels []connectionLabel, l connectionLabel, arrival func(int) time.Time) bool {
+	for _, existing := range labels {
+		if !arrival(existing.conn).After(arrival(l.conn)) && existing.transfers <= l.transfers {
+			return true
+		}
+	}
+	return false
+}
+
+// 出発が遅い・到着が早い・乗換が少ない、のいずれでも他に劣る経路を除き、出発時刻順に返す
+// 出発・到着時刻と乗換回数がすべて同じ経路は、先に見つかったものを残す
+func nonDominatedRoutes(routes []Route) []Route {
+	dominates := func(a Route, b Route) bool {
+		aDepart, bDepart := a.Operations[0].DepartDatetime, b.Operations[0].DepartDatetime
+		aArrive := a.Operations[len(a.Operations)-1].ArriveDatetime
+		bArrive := b.Operations[len(b.Operations)-1].ArriveDatetime
+		return !aDepart.Before(bDepart) && !aArrive.After(bArrive) && a.Transfers <= b.Transfers
+	}
+
+	kept := make([]Route, 0, len(routes))
+	for i, route := range routes {
+		isDominated := false
+		for j, other := range routes {
+			if i == j || !dominates(other, route) {
+				continue
+			}
+			// 同等の経路同士は、先に見つかったものを残す
+			if dominates(route, other) && i < j {
+				continue
+			}
+			isDominated = true
+			break
+		}
+		if !isDominated {
+			kept = append(kept, route)
+		}
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		departI, departJ := kept[i].Operations[0].DepartDatetime, kept[j].Operations[0].DepartDatetime
+		if !departI.Equal(departJ) {
+			return departI.Before(departJ)
+		}
+		return kept[i].Operations[len(kept[i].Operations)-1].ArriveDatetime.Before(kept[j].Operations[len(kept[j].Operations)-1].ArriveDatetime)
+	})
+	return kept
+}
@@ -0,0 +1,440 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"outtech105.com/transit_server/models"
+)
+
+// 経路の(出発時刻, 到着時刻, 乗換回数)
+func routeSummary(route Route) string {
+	return fmt.Sprintf("%s-%s/%d",
+		route.Operations[0].DepartDatetime.Format("15:04"),
+		route.Operations[len(route.Operations)-1].ArriveDatetime.Format("15:04"),
+		route.Transfers,
+	)
+}
+
+func routeSummaries(routes []Route) []string {
+	summaries := make([]string, 0, len(routes))
+	for _, route := range routes {
+		summaries = append(summaries, routeSummary(route))
+	}
+	return summaries
+}
+
+func TestProfileRoutes(t *testing.T) {
+	// 駅1→3の直通(遅い)、駅1→2→3の乗換(速い)、駅1→2の後続列車
+	operations := slices.Concat(
+		testTrain(1, []uint{1, 3}, []time.Time{at(8, 0), at(9, 0)}),
+		testTrain(2, []uint{1, 2}, []time.Time{at(8, 10), at(8, 20)}),
+		testTrain(3, []uint{2, 3}, []time.Time{at(8, 25), at(8, 40)}),
+		testTrain(4, []uint{1, 2}, []time.Time{at(8, 20), at(8, 30)}),
+	)
+
+	tests := []struct {
+		name         string
+		maxTransfers int
+		configure    func(s *searchContext)
+		from, until  time.Time
+		want         []string
+	}{
+		{
+			name:         "direct and transfer",
+			maxTransfers: 1,
+			configure:    func(s *searchContext) {},
+			from:         at(7, 0),
+			until:        at(9, 0),
+			want:         []string{"08:00-09:00/0", "08:10-08:40/1"},
+		},
+		{
+			name:         "no transfers allowed",
+			maxTransfers: 0,
+			configure:    func(s *searchContext) {},
+			from:         at(7, 0),
+			until:        at(9, 0),
+			want:         []string{"08:00-09:00/0"},
+		},
+		{
+			name:         "departure range",
+			maxTransfers: 1,
+			configure:    func(s *searchContext) {},
+			from:         at(8, 5),
+			until:        at(9, 0),
+			want:         []string{"08:10-08:40/1"},
+		},
+		{
+			name:         "transfer time",
+			maxTransfers: 1,
+			configure: func(s *searchContext) {
+				s.transfers = models.PlatformTransfers{{StationID: 2}: {Duration: 10 * time.Minute, StepFree: true}}
+			},
+			from:  at(7, 0),
+			until: at(9, 0),
+			want:  []string{"08:00-09:00/0"},
+		},
+		{
+			name:         "max wait",
+			maxTransfers: 1,
+			configure:    func(s *searchContext) { s.maxWait = 4 * time.Minute },
+			from:         at(7, 0),
+			until:        at(9, 0),
+			want:         []string{"08:00-09:00/0"},
+		},
+		{
+			name:         "max travel",
+			maxTransfers: 1,
+			configure:    func(s *searchContext) { s.maxTravel = 45 * time.Minute },
+			from:         at(7, 0),
+			until:        at(9, 0),
+			want:         []string{"08:10-08:40/1"},
+		},
+		{
+			name:         "cancelled train",
+			maxTransfers: 1,
+			configure: func(s *searchContext) {
+				s.disruptions.Cancellations = []models.TrainCancellation{{TrainID: 3, ServiceDate: at(0, 0)}}
+			},
+			from:  at(7, 0),
+			until: at(9, 0),
+			want:  []string{"08:00-09:00/0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSearchContext(tt.from)
+			tt.configure(s)
+			profile := newProfileSearch(s, testConnections(operations...), tt.maxTransfers)
+
+			routes, scanned := profile.profileRoutes(context.Background(), 1, 3, tt.from, tt.until)
+			if !scanned {
+				t.Fatal("scan was not completed")
+			}
+			if got := routeSummaries(routes); !slices.Equal(got, tt.want) {
+				t.Fatalf("routes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 運休した列車は、走査で到着駅の状態・出発番線の最早到着を改善できた場合のみ、回避したものとして記録する
+func TestProfileScansRecordImprovingDisruptions(t *testing.T) {
+	// 駅1→2の先発(速い)、後発、その間の出発(最も遅い到着)
+	operations := slices.Concat(
+		testTrain(1, []uint{1, 2}, []time.Time{at(8, 0), at(8, 20)}),
+		testTrain(2, []uint{1, 2}, []time.Time{at(8, 10), at(8, 30)}),
+		testTrain(3, []uint{1, 2}, []time.Time{at(8, 5), at(8, 40)}),
+	)
+	avoidedTrains := func(s *searchContext) []uint {
+		cancellations, _ := s.avoided.list()
+		trainIDs := make([]uint, 0, len(cancellations))
+		for _, c := range cancellations {
+			trainIDs = append(trainIDs, c.TrainID)
+		}
+		return trainIDs
+	}
+
+	tests := []struct {
+		name         string
+		cancelled    uint
+		wantLabels   []uint // 出発駅1・8:00以降の走査で記録する列車ID
+		wantProfiles []uint // 到着駅2への走査で記録する列車ID
+	}{
+		{name: "earliest arrival", cancelled: 1, wantLabels: []uint{1}, wantProfiles: []uint{1}},
+		{name: "latest departure", cancelled: 2, wantLabels: []uint{}, wantProfiles: []uint{2}},
+		{name: "dominated", cancelled: 3, wantLabels: []uint{}, wantProfiles: []uint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure := func(s *searchContext) {
+				s.disruptions.Cancellations = []models.TrainCancellation{{TrainID: tt.cancelled, ServiceDate: at(0, 0)}}
+			}
+
+			s := newTestSearchContext(at(8, 0))
+			configure(s)
+			profile := newProfileSearch(s, testConnections(operations...), 1)
+			if _, _, scanned := profile.scanLabels(context.Background(), 1, at(8, 0)); !scanned {
+				t.Fatal("scan was not completed")
+			}
+			if got := avoidedTrains(s); !slices.Equal(got, tt.wantLabels) {
+				t.Errorf("scanLabels avoided = %v, want %v", got, tt.wantLabels)
+			}
+
+			s = newTestSearchContext(at(8, 0))
+			configure(s)
+			profile = newProfileSearch(s, testConnections(operations...), 1)
+			if _, _, scanned := profile.scanProfiles(context.Background(), 2); !scanned {
+				t.Fatal("scan was not completed")
+			}
+			if got := avoidedTrains(s); !slices.Equal(got, tt.wantProfiles) {
+				t.Errorf("scanProfiles avoided = %v, want %v", got, tt.wantProfiles)
+			}
+		})
+	}
+}
+
+// 直通する列車へは、乗ったまま移る(乗換に数えない)
+func TestProfileRoutesThroughService(t *testing.T) {
+	operations := slices.Concat(
+		testTrain(1, []uint{1, 2}, []time.Time{at(8, 0), at(8, 10)}),
+		testTrain(2, []uint{2, 3}, []time.Time{at(8, 12), at(8, 30)}),
+	)
+	s := newTestSearchContext(at(8, 0))
+	relation := models.TrainRelation{FromTrainID: 1, ToTrainID: 2, StationID: 2}
+	s.relations = models.TrainRelations{{1, 2, 2}: relation}
+	profile := newProfileSearch(s, testConnections(operations...), 0)
+
+	routes, _ := profile.profileRoutes(context.Background(), 1, 3, at(8, 0), at(9, 0))
+	if got, want := routeSummaries(routes), []string{"08:00-08:30/0"}; !slices.Equal(got, want) {
+		t.Fatalf("routes = %v, want %v", got, want)
+	}
+	if got := routes[0].ThroughServices[0]; got != relation {
+		t.Errorf("through service = %+v, want %+v", got, relation)
+	}
+}
+
+// 探索ごとの出発駅・出発日時は、共有する探索情報を書き換えない
+func TestScanLabelsDoesNotMutateSearch(t *testing.T) {
+	operations := slices.Concat(
+		testTrain(1, []uint{1, 2, 3}, []time.Time{at(8, 0), at(8, 10), at(8, 20)}),
+	)
+	s := newTestSearchContext(at(7, 0))
+	profile := newProfileSearch(s, testConnections(operations...), 0)
+
+	for _, origin := range []uint{1, 2} {
+		stationLabels, _, _ := profile.scanLabels(context.Background(), origin, at(8, 0))
+		if _, isReached := stationLabels[3]; !isReached {
+			t.Errorf("station 3 is not reached from station %d", origin)
+		}
+	}
+	if !s.depart.Equal(at(7, 0)) {
+		t.Errorf("search depart = %v, want %v", s.depart, at(7, 0))
+	}
+}
+
+// 期限を過ぎた場合は、走査を打ち切ったことを返す
+func TestProfileRoutesTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+
+	s := newTestSearchContext(at(8, 0))
+	profile := newProfileSearch(s, testConnections(testTrain(1, []uint{1, 2}, []time.Time{at(8, 0), at(8, 10)})...), 0)
+	if _, scanned := profile.profileRoutes(ctx, 1, 2, at(8, 0), at(9, 0)); scanned {
+		t.Fatal("scan was completed after the deadline")
+	}
+}
+
+// プロファイルCSAの結果は、出発時刻ごとのCSAの結果から劣る経路を除いたものと一致する
+func TestProfileRoutesMatchesRepeatedScans(t *testing.T) {
+	const (
+		origin      = 1
+		destination = 6
+	)
+	for seed := int64(1); seed <= 200; seed++ {
+		random := rand.New(rand.NewSource(seed))
+		operations := make([]models.Operation, 0)
+		for trainID := uint(1); trainID <= 15; trainID++ {
+			stations := make([]uint, 0, 4)
+			for _, n := range random.Perm(6)[:2+random.Intn(3)] {
+				stations = append(stations, uint(n+1))
+			}
+			times := []time.Time{at(8, 0).Add(time.Duration(random.Intn(12)*5) * time.Minute)}
+			for range stations[1:] {
+				times = append(times, times[len(times)-1].Add(time.Duration(5+random.Intn(16))*time.Minute))
+			}
+			operations = append(operations, testTrain(trainID, stations, times)...)
+		}
+		maxTransfers := random.Intn(4)
+		from, until := at(8, 0), at(9, 0)
+
+		profile := newProfileSearch(newTestSearchContext(from), testConnections(operations...), maxTransfers)
+		routes, _ := profile.profileRoutes(context.Background(), origin, destination, from, until)
+		got := routeSummaries(routes)
+
+		// 出発駅の出発時刻ごとにCSAで到着駅への経路を求め、(出発, 到着, 乗換回数)のパレート集合とする
+		type summary struct {
+			depart, arrive time.Time
+			transfers      int
+		}
+		candidates := make([]summary, 0)
+		for _, c := range profile.connections {
+			if c.op.DepartStationID != origin || c.op.DepartDatetime.After(until) {
+				continue
+			}
+			stationLabels, prev, _ := profile.scanLabels(context.Background(), origin, c.op.DepartDatetime)
+			for _, l := range stationLabels[destination] {
+				first := l.conn
+				for prev[first] >= 0 {
+					first = prev[first]
+				}
+				if profile.connections[first].op.DepartDatetime.After(until) {
+					continue
+				}
+				candidates = append(candidates, summary{
+					depart:    profile.connections[first].op.DepartDatetime,
+					arrive:    profile.arrival(l.conn),
+					transfers: l.transfers,
+				})
+			}
+		}
+		wantSet := make(map[string]struct{})
+		for _, a := range candidates {
+			isDominated := slices.ContainsFunc(candidates, func(b summary) bool {
+				isBetter := !b.depart.Before(a.depart) && !b.arrive.After(a.arrive) && b.transfers <= a.transfers
+				isEqual := b.depart.Equal(a.depart) && b.arrive.Equal(a.arrive) && b.transfers == a.transfers
+				return isBetter && !isEqual
+			})
+			if !isDominated {
+				wantSet[fmt.Sprintf("%s-%s/%d", a.depart.Format("15:04"), a.arrive.Format("15:04"), a.transfers)] = struct{}{}
+			}
+		}
+		want := make([]string, 0, len(wantSet))
+		for s := range wantSet {
+			want = append(want, s)
+		}
+		sort.Strings(want)
+		sort.Strings(got)
+
+		if !slices.Equal(got, want) {
+			t.Errorf("seed %d (max transfers %d): routes = %v, want %v", seed, maxTransfers, got, want)
+		}
+	}
+}
+
+// 乗換回数transfersで、時刻departからarriveまでの経路
+func testRoute(depart time.Time, arrive time.Time, transfers int) Route {
+	return Route{
+		Operations: []models.Operation{testOperation(1, 1, depart, 2, arrive)},
+		Transfers:  transfers,
+	}
+}
+
+func TestNonDominatedRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []Route
+		want   []string
+	}{
+		{
+			name:   "empty",
+			routes: nil,
+			want:   []string{},
+		},
+		{
+			name: "later departure dominates",
+			routes: []Route{
+				testRoute(at(8, 0), at(9, 0), 0),
+				testRoute(at(8, 10), at(9, 0), 0),
+			},
+			want: []string{"08:10-09:00/0"},
+		},
+		{
+			name: "earlier arrival dominates",
+			routes: []Route{
+				testRoute(at(8, 0), at(9, 0), 0),
+				testRoute(at(8, 0), at(8, 50), 0),
+			},
+			want: []string{"08:00-08:50/0"},
+		},
+		{
+			name: "fewer transfers dominates",
+			routes: []Route{
+				testRoute(at(8, 0), at(9, 0), 2),
+				testRoute(at(8, 0), at(9, 0), 1),
+			},
+			want: []string{"08:00-09:00/1"},
+		},
+		{
+			name: "trade-offs are kept in departure order",
+			routes: []Route{
+				testRoute(at(8, 10), at(8, 40), 1),
+				testRoute(at(8, 0), at(9, 0), 0),
+				testRoute(at(8, 20), at(8, 55), 0),
+			},
+			want: []string{"08:10-08:40/1", "08:20-08:55/0"},
+		},
+		{
+			name: "same departure is ordered by arrival",
+			routes: []Route{
+				testRoute(at(8, 0), at(9, 0), 0),
+				testRoute(at(8, 0), at(8, 40), 1),
+			},
+			want: []string{"08:00-08:40/1", "08:00-09:00/0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := routeSummaries(nonDominatedRoutes(tt.routes)); !slices.Equal(got, tt.want) {
+				t.Fatalf("routes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 同等の経路同士は、先に見つかったものを残す
+func TestNonDominatedRoutesKeepsFirstOfEquals(t *testing.T) {
+	first, second := testRoute(at(8, 0), at(9, 0), 1), testRoute(at(8, 0), at(9, 0), 1)
+	first.Operations[0].TrainID, second.Operations[0].TrainID = 1, 2
+
+	routes := nonDominatedRoutes([]Route{first, second})
+	if len(routes) != 1 || routes[0].Operations[0].TrainID != 1 {
+		t.Fatalf("routes = %+v, want only the first route", routes)
+	}
+}
+
+func TestAddParetoLabel(t *testing.T) {
+	// 接続の添字ごとの到着時刻
+	arrivals := []time.Time{at(8, 0), at(8, 10), at(8, 20)}
+	arrival := func(conn int) time.Time { return arrivals[conn] }
+
+	tests := []struct {
+		name   string
+		labels []connectionLabel
+		label  connectionLabel
+		want   []connectionLabel
+	}{
+		{
+			name:  "first label",
+			label: connectionLabel{transfers: 1, conn: 1},
+			want:  []connectionLabel{{transfers: 1, conn: 1}},
+		},
+		{
+			name:   "dominated by earlier arrival",
+			labels: []connectionLabel{{transfers: 1, conn: 0}},
+			label:  connectionLabel{transfers: 1, conn: 1},
+			want:   []connectionLabel{{transfers: 1, conn: 0}},
+		},
+		{
+			name:   "dominated by equal label",
+			labels: []connectionLabel{{transfers: 1, conn: 1}},
+			label:  connectionLabel{transfers: 1, conn: 1},
+			want:   []connectionLabel{{transfers: 1, conn: 1}},
+		},
+		{
+			name:   "trade-off is added",
+			labels: []connectionLabel{{transfers: 2, conn: 0}},
+			label:  connectionLabel{transfers: 0, conn: 2},
+			want:   []connectionLabel{{transfers: 2, conn: 0}, {transfers: 0, conn: 2}},
+		},
+		{
+			name:   "dominated labels are removed",
+			labels: []connectionLabel{{transfers: 2, conn: 1}, {transfers: 1, conn: 2}, {transfers: 0, conn: 2}},
+			label:  connectionLabel{transfers: 1, conn: 0},
+			want:   []connectionLabel{{transfers: 0, conn: 2}, {transfers: 1, conn: 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addParetoLabel(tt.labels, tt.label, arrival); !slices.Equal(got, tt.want) {
+				t.Fatalf("labels = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"outtech105.com/transit_server/models"
+)
+
+// 出発駅から到達可能な駅を探索するパラメータ
+// NOTE: 経由駅(ViaStationIDs)の指定には対応しない
+type ReachableSearchParams struct {
+	DepartStationID uint
+	DepartDateTime  time.Time
+	SearchConstraints
+}
+
+// 到達可能な駅と、その最早到着
+type ReachableStation struct {
+	StationID      uint
+	ArriveDatetime time.Time
+	Transfers      int // 最早到着となる経路のうち、最小の乗換回数
+}
+
+// 到達可能な駅の探索結果
+type ReachableSearchResult struct {
+	Stations             []ReachableStation // 到着時刻順
+	AvoidedCancellations []models.TrainCancellation
+	AvoidedSuspensions   []models.SegmentSuspension
+	Complete             bool // 探索を最後まで行えたか(期限切れの場合はfalse)
+}
+
+// 出発駅・出発日時から、所要時間の上限(MaxTravel)以内に到達可能な全駅の最早到着を求める
+// 出発駅自体は結果に含めない
+// NOTE: ctxの期限を過ぎた場合はエラーとせず、それまでに到達した駅をComplete=falseとして返す
+func SearchReachableStations(ctx context.Context, networkID uint, req ReachableSearchParams, repos models.Repositories, db *sqlx.DB) (ReachableSearchResult, error) {
+	search, err := newSearchContext(ctx, networkID, repos, db, req.DepartDateTime, req.SearchConstraints)
+	if err != nil {
+		if isSearchTimeout(ctx, err) {
+			return ReachableSearchResult{Complete: false}, nil
+		}
+		return ReachableSearchResult{}, err
+	}
+
+	connections, err := loadConnections(ctx, networkID, repos, req.DepartDateTime, req.DepartDateTime.Add(req.MaxTravel))
+	if err != nil {
+		if isSearchTimeout(ctx, err) {
+			cancellations, suspensions := search.avoided.list()
+			return ReachableSearchResult{AvoidedCancellations: cancellations, AvoidedSuspensions: suspensions, Complete: false}, nil
+		}
+		return ReachableSearchResult{}, fmt.Errorf("loadConnections: %w", err)
+	}
+
+	// 到着駅を指定せず、1回のCSAで全駅への到達状態を求める
+	profile := newProfileSearch(search, connections, req.MaxTransfers)
+	stationLabels, _, scanned := profile.scanLabels(ctx, req.DepartStationID, req.DepartDateTime)
+	if !scanned && !isSearchTimeout(ctx, nil) {
+		return ReachableSearchResult{}, ctx.Err()
+	}
+
+	stations := profile.earliestArrivals(req.DepartStationID, stationLabels)
+	sort.SliceStable(stations, func(i, j int) bool {
+		if !stations[i].ArriveDatetime.Equal(stations[j].ArriveDatetime) {
+			return stations[i].ArriveDatetime.Before(stations[j].ArriveDatetime)
+		}
+		return stations[i].StationID < stations[j].StationID
+	})
+
+	cancellations, suspensions := search.avoided.list()
+	return ReachableSearchResult{
+		Stations:             stations,
+		AvoidedCancellations: cancellations,
+		AvoidedSuspensions:   suspensions,
+		Complete:             scanned,
+	}, nil
+}
+
+// 駅ごとの到達状態から、出発駅originを除く各駅の最早到着を求める
+func (p *profileSearch) earliestArrivals(origin uint, stationLabels map[uint][]connectionLabel) []ReachableStation {
+	stations := make([]ReachableStation, 0, len(stationLabels))
+	for stationID, labels := range stationLabels {
+		if stationID == origin || len(labels) == 0 {
+			continue
+		}
+
+		// パレート集合から、到着が最も早く(同時刻なら乗換が少ない)状態を選ぶ
+		earliest := labels[0]
+		for _, l := range labels[1:] {
+			arriveL, arriveEarliest := p.arrival(l.conn), p.arrival(earliest.conn)
+			if arriveL.Before(arriveEarliest) || (arriveL.Equal(arriveEarliest) && l.transfers < earliest.transfers) {
+				earliest = l
+			}
+		}
+		stations = append(stations, ReachableStation{
+			StationID:      stationID,
+			ArriveDatetime: p.arrival(earliest.conn),
+			Transfers:      earliest.transfers,
+		})
+	}
+	return stations
+}
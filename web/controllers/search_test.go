@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"outtech105.com/transit_server/models"
+)
+
+// 経路の要約(出発-到着/乗換回数 列車IDの列)
+func routeTrainsSummary(route Route) string {
+	trains := make([]uint, 0, len(route.Operations))
+	for _, op := range route.Operations {
+		if len(trains) == 0 || trains[len(trains)-1] != op.TrainID {
+			trains = append(trains, op.TrainID)
+		}
+	}
+	return fmt.Sprintf("%s %v", routeSummary(route), trains)
+}
+
+func TestSearchTransitByDepart(t *testing.T) {
+	db := newSearchTestDB(t)
+	memory, err := models.NewMemoryRepositories(map[uint][]models.Station{1: searchTestStations}, searchTestTrains)
+	if err != nil {
+		t.Fatalf("NewMemoryRepositories: %v", err)
+	}
+
+	// 経路は幅優先探索で到達した順(移動区間の少ない順)に返る
+	constraints := SearchConstraints{MaxTransfers: 2, MaxTravel: 3 * time.Hour, MaxWait: time.Hour}
+	tests := []struct {
+		name   string
+		depart time.Time
+		modify func(c *SearchConstraints)
+		want   []string
+	}{
+		{
+			name:   "all routes",
+			depart: at(7, 50),
+			want: []string{
+				"08:30-09:00/0 [4]",
+				"08:00-08:45/1 [1 3]",
+				"08:00-08:40/1 [1 2]",
+			},
+		},
+		{
+			name:   "after first train",
+			depart: at(8, 5),
+			want:   []string{"08:30-09:00/0 [4]"},
+		},
+		{
+			name:   "no transfers",
+			depart: at(7, 50),
+			modify: func(c *SearchConstraints) { c.MaxTransfers = 0 },
+			want:   []string{"08:30-09:00/0 [4]"},
+		},
+		{
+			name:   "wheelchair",
+			depart: at(7, 50),
+			modify: func(c *SearchConstraints) { c.Wheelchair = true },
+			want: []string{
+				"08:30-09:00/0 [4]",
+				"08:00-08:45/1 [1 3]",
+			},
+		},
+		{
+			name:   "avoid station",
+			depart: at(7, 50),
+			modify: func(c *SearchConstraints) { c.AvoidStationIDs = []uint{2} },
+			want:   []string{"08:30-09:00/0 [4]"},
+		},
+		{
+			name:   "short wait",
+			depart: at(7, 50),
+			modify: func(c *SearchConstraints) { c.MaxWait = 20 * time.Minute },
+			want: []string{
+				"08:00-08:45/1 [1 3]",
+				"08:00-08:40/1 [1 2]",
+			},
+		},
+	}
+
+	for _, backend := range []struct {
+		name  string
+		repos models.Repositories
+	}{
+		{name: "memory", repos: memory},
+		{name: "sqlite", repos: models.NewSQLiteRepositories(db)},
+	} {
+		for _, tt := range tests {
+			t.Run(backend.name+"/"+tt.name, func(t *testing.T) {
+				c := constraints
+				if tt.modify != nil {
+					tt.modify(&c)
+				}
+				req := TransitSearchParamsByDepart{DepartStationID: 1, DepartDateTime: tt.depart, ArriveStationID: 4, SearchConstraints: c}
+				result, err := SearchTransitByDepart(context.Background(), 1, req, backend.repos, db)
+				if err != nil {
+					t.Fatalf("SearchTransitByDepart: %v", err)
+				}
+				if !result.Complete {
+					t.Errorf("Complete = false, want true")
+				}
+				got := make([]string, 0, len(result.Routes))
+				for _, route := range result.Routes {
+					got = append(got, routeTrainsSummary(route))
+				}
+				if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Fatalf("routes = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+// 回避した運休は、より早い列車の代わりに別の列車を選んだルートにのみ記録する
+func TestSearchTransitByDepartRecordsAvoidedDisruptionsPerRoute(t *testing.T) {
+	db := newSearchTestDB(t)
+	if _, err := db.Exec(`INSERT INTO train_cancellations (train_id, service_date) VALUES (3, '2024-10-01')`); err != nil {
+		t.Fatalf("insert cancellation: %v", err)
+	}
+	// 運休する列車3(駅2→4)の代わりに乗れる、駅2→4の列車5
+	trains := append([]models.MemoryTrain{}, searchTestTrains...)
+	trains = append(trains, testMemoryTrain(5, []uint{2, 4}, []string{"08:20:00", "08:50:00"}))
+	repos, err := models.NewMemoryRepositories(map[uint][]models.Station{1: searchTestStations}, trains)
+	if err != nil {
+		t.Fatalf("NewMemoryRepositories: %v", err)
+	}
+
+	req := TransitSearchParamsByDepart{
+		DepartStationID:   1,
+		DepartDateTime:    at(7, 50),
+		ArriveStationID:   4,
+		SearchConstraints: SearchConstraints{MaxTransfers: 2, MaxTravel: 3 * time.Hour, MaxWait: time.Hour},
+	}
+	result, err := SearchTransitByDepart(context.Background(), 1, req, repos, db)
+	if err != nil {
+		t.Fatalf("SearchTransitByDepart: %v", err)
+	}
+
+	// ルートごとの、回避した運休の列車ID
+	avoidedTrains := func(cancellations []models.TrainCancellation) []uint {
+		trainIDs := make([]uint, 0, len(cancellations))
+		for _, c := range cancellations {
+			trainIDs = append(trainIDs, c.TrainID)
+		}
+		return trainIDs
+	}
+	got := make([]string, 0, len(result.Routes))
+	for _, route := range result.Routes {
+		got = append(got, fmt.Sprintf("%s avoided=%v", routeTrainsSummary(route), avoidedTrains(route.AvoidedCancellations)))
+	}
+	want := []string{
+		"08:30-09:00/0 [4] avoided=[]",
+		"08:00-08:50/1 [1 5] avoided=[3]",
+		"08:00-08:40/1 [1 2] avoided=[]",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("routes = %q, want %q", got, want)
+	}
+	if trainIDs := avoidedTrains(result.AvoidedCancellations); fmt.Sprint(trainIDs) != "[3]" {
+		t.Errorf("result avoided = %v, want [3]", trainIDs)
+	}
+
+	// ルートを絞り込んだ結果には、残したルートが回避した運休のみを含める
+	limited := result.WithRoutes(result.Routes[:1])
+	if len(limited.AvoidedCancellations) != 0 {
+		t.Errorf("limited result avoided = %v, want none", avoidedTrains(limited.AvoidedCancellations))
+	}
+}
+
+// 期限切れの場合は、エラーとせず未完了の結果を返す
+func TestSearchTransitByDepartTimeout(t *testing.T) {
+	db := newSearchTestDB(t)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	req := TransitSearchParamsByDepart{
+		DepartStationID:   1,
+		DepartDateTime:    at(7, 50),
+		ArriveStationID:   4,
+		SearchConstraints: SearchConstraints{MaxTransfers: 2, MaxTravel: 3 * time.Hour, MaxWait: time.Hour},
+	}
+	result, err := SearchTransitByDepart(ctx, 1, req, models.NewSQLiteRepositories(db), db)
+	if err != nil {
+		t.Fatalf("SearchTransitByDepart: %v", err)
+	}
+	if result.Complete {
+		t.Fatal("Complete = true, want false")
+	}
+}
+
+// 事業者を指定した場合は、指定した事業者の列車のみ使用する
+func TestSearchTransitByDepartAgencyFilter(t *testing.T) {
+	// 列車1・2は事業者1、列車3は事業者2、列車4は事業者未設定
+	tests := []struct {
+		name      string
+		agencyIDs []uint
+		want      []string
+	}{
+		{
+			name: "all agencies",
+			want: []string{"08:30-09:00/0 [4]", "08:00-08:45/1 [1 3]", "08:00-08:40/1 [1 2]"},
+		},
+		{
+			name:      "single agency",
+			agencyIDs: []uint{1},
+			want:      []string{"08:00-08:40/1 [1 2]"},
+		},
+		{
+			name:      "inter-agency transfer",
+			agencyIDs: []uint{1, 2},
+			want:      []string{"08:00-08:45/1 [1 3]", "08:00-08:40/1 [1 2]"},
+		},
+		{
+			name:      "agency without reachable trains",
+			agencyIDs: []uint{2},
+			want:      []string{},
+		},
+	}
+	for _, backend := range []string{"memory", "sqlite"} {
+		for _, tt := range tests {
+			t.Run(backend+"/"+tt.name, func(t *testing.T) {
+				db := newSearchTestDB(t)
+				for _, query := range []string{
+					`INSERT INTO agencies (id, network_id, name, name_en) VALUES (1, 1, '事業者1', 'Agency 1'), (2, 1, '事業者2', 'Agency 2')`,
+					`UPDATE trains SET agency_id = 1 WHERE id IN (1, 2)`,
+					`UPDATE trains SET agency_id = 2 WHERE id = 3`,
+				} {
+					if _, err := db.Exec(query); err != nil {
+						t.Fatalf("exec %q: %v", query, err)
+					}
+				}
+
+				req := TransitSearchParamsByDepart{
+					DepartStationID: 1,
+					DepartDateTime:  at(7, 50),
+					ArriveStationID: 4,
+					SearchConstraints: SearchConstraints{
+						AgencyIDs:    tt.agencyIDs,
+						MaxTransfers: 2,
+						MaxTravel:    3 * time.Hour,
+						MaxWait:      time.Hour,
+					},
+				}
+				result, err := SearchTransitByDepart(context.Background(), 1, req, newSearchTestRepositories(t, backend, db), db)
+				if err != nil {
+					t.Fatalf("SearchTransitByDepart: %v", err)
+				}
+				got := make([]string, 0, len(result.Routes))
+				for _, route := range result.Routes {
+					got = append(got, routeTrainsSummary(route))
+				}
+				if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Errorf("routes = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
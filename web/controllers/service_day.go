@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"outtech105.com/transit_server/models"
+)
+
+// 運行日を指定した経路探索パラメータ(始発・終電探索)
+// NOTE: 経由駅(ViaStationIDs)の指定には対応しない
+type TransitSearchParamsByServiceDay struct {
+	DepartStationID uint
+	ServiceDatetime time.Time // 運行日内の任意の日時(この日時が属する運行日を探索対象とする)
+	ArriveStationID uint
+	SearchConstraints
+}
+
+// datetimeが属する運行日の開始・終了日時を返す
+// 運行日の境界は、models.ServiceDayStartの時刻とする
+func ServiceDayRange(datetime time.Time) (time.Time, time.Time) {
+	serviceDate := models.ServiceDate(datetime)
+	return models.ServiceDatetime(serviceDate, models.ServiceDayStart()),
+		models.ServiceDatetime(serviceDate, models.ServiceDayStart()+24*time.Hour)
+}
+
+// 運行日の列車のみを使う経路のうち、出発駅を最も遅く出発する経路(終電)を検索
+// 出発時刻が同じ経路が複数ある場合は、到着が早い・乗換が少ない、のいずれでも他に劣らない経路をすべて返す
+// NOTE: ctxの期限を過ぎた場合はエラーとせず、それまでに見つかった経路をComplete=falseとして返す
+func SearchLastTrain(ctx context.Context, networkID uint, req TransitSearchParamsByServiceDay, repos models.Repositories, db *sqlx.DB) (TransitSearchResult, error) {
+	routes, search, complete, err := searchServiceDay(ctx, networkID, req, repos, db)
+	if err != nil {
+		return TransitSearchResult{}, err
+	}
+	if search == nil {
+		return TransitSearchResult{Complete: false}, nil
+	}
+	return search.result(lastTrainRoutes(routes), complete), nil
+}
+
+// 運行日の列車のみを使う経路のうち、到着駅に最も早く到着する経路(始発)を検索
+// 到着時刻が同じ経路が複数ある場合は、出発が遅い・乗換が少ない、のいずれでも他に劣らない経路をすべて返す
+// NOTE: ctxの期限を過ぎた場合はエラーとせず、それまでに見つかった経路をComplete=falseとして返す
+func SearchFirstTrain(ctx context.Context, networkID uint, req TransitSearchParamsByServiceDay, repos models.Repositories, db *sqlx.DB) (TransitSearchResult, error) {
+	routes, search, complete, err := searchServiceDay(ctx, networkID, req, repos, db)
+	if err != nil {
+		return TransitSearchResult{}, err
+	}
+	if search == nil {
+		return TransitSearchResult{Complete: false}, nil
+	}
+	return search.result(firstTrainRoutes(routes), complete), nil
+}
+
+// 運行日の列車の接続のみを使い、出発駅を運行日内に出発する経路をプロファイルCSAで求める
+// 出発が遅い・到着が早い・乗換が少ない、のいずれでも他に劣らない経路と探索の共有情報、走査を最後まで行えたかを返す
+// NOTE: 運行日の境界を跨いで運行する列車(24:00以降の時刻を持つ列車)も、列車の運行日で判定する
+// NOTE: ctxの期限を過ぎた場合はエラーとせず、走査を最後まで行えなかったものとして返す(探索の共有情報の生成前に期限を過ぎた場合はnil)
+func searchServiceDay(ctx context.Context, networkID uint, req TransitSearchParamsByServiceDay, repos models.Repositories, db *sqlx.DB) ([]Route, *searchContext, bool, error) {
+	dayStart, dayEnd := ServiceDayRange(req.ServiceDatetime)
+
+	search, err := newSearchContext(ctx, networkID, repos, db, dayStart, req.SearchConstraints)
+	if err != nil {
+		if isSearchTimeout(ctx, err) {
+			return nil, nil, false, nil
+		}
+		return nil, nil, false, err
+	}
+
+	connections, err := loadConnections(ctx, networkID, repos, dayStart, dayEnd.Add(req.MaxTravel))
+	if err != nil {
+		if isSearchTimeout(ctx, err) {
+			return nil, search, false, nil
+		}
+		return nil, nil, false, fmt.Errorf("loadConnections: %w", err)
+	}
+
+	// 他の運行日の列車の接続は使用しない
+	sameDayConnections := serviceDayConnections(connections, models.ServiceDate(req.ServiceDatetime))
+
+	profile := newProfileSearch(search, sameDayConnections, req.MaxTransfers)
+	routes, scanned := profile.profileRoutes(ctx, req.DepartStationID, req.ArriveStationID, dayStart, dayEnd.Add(req.MaxTravel))
+	if !scanned && !isSearchTimeout(ctx, nil) {
+		return nil, nil, false, ctx.Err()
+	}
+	return routes, search, scanned, nil
+}
+
+// 運行日がserviceDateの列車の接続のみを、順序を保って返す
+func serviceDayConnections(connections []connection, serviceDate time.Time) []connection {
+	sameDayConnections := make([]connection, 0, len(connections))
+	for _, c := range connections {
+		if c.op.ServiceDate.Equal(serviceDate) {
+			sameDayConnections = append(sameDayConnections, c)
+		}
+	}
+	return sameDayConnections
+}
+
+// パレート集合の経路から、出発が最も遅い経路(終電)を選ぶ
+func lastTrainRoutes(routes []Route) []Route {
+	var latest time.Time
+	for _, route := range routes {
+		if depart := route.Operations[0].DepartDatetime; depart.After(latest) {
+			latest = depart
+		}
+	}
+	selected := make([]Route, 0, 1)
+	for _, route := range routes {
+		if route.Operations[0].DepartDatetime.Equal(latest) {
+			selected = append(selected, route)
+		}
+	}
+	return nonDominatedRoutes(selected)
+}
+
+// パレート集合の経路から、到着が最も早い経路(始発)を選ぶ
+func firstTrainRoutes(routes []Route) []Route {
+	var earliest time.Time
+	for _, route := range routes {
+		if arrive := route.Operations[len(route.Operations)-1].ArriveDatetime; earliest.IsZero() || arrive.Before(earliest) {
+			earliest = arrive
+		}
+	}
+	selected := make([]Route, 0, 1)
+	for _, route := range routes {
+		if route.Operations[len(route.Operations)-1].ArriveDatetime.Equal(earliest) {
+			selected = append(selected, route)
+		}
+	}
+	return nonDominatedRoutes(selected)
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"outtech105.com/transit_server/models"
+)
+
+// 運行日serviceDateの列車として、区間移動の運行日を設定
+func onServiceDate(operations []models.Operation, serviceDate time.Time) []models.Operation {
+	for i := range operations {
+		operations[i].ServiceDate = serviceDate
+	}
+	return operations
+}
+
+func TestServiceDayConnections(t *testing.T) {
+	today, yesterday := at(0, 0), at(0, 0).AddDate(0, 0, -1)
+	connections := testConnections(slices.Concat(
+		// 前日の運行日の深夜列車(24:00以降の時刻)
+		onServiceDate(testTrain(1, []uint{1, 2}, []time.Time{at(0, 30), at(0, 50)}), yesterday),
+		onServiceDate(testTrain(2, []uint{1, 2}, []time.Time{at(5, 0), at(5, 20)}), today),
+		// 当日の運行日の深夜列車(日付を跨いで運行)
+		onServiceDate(testTrain(3, []uint{1, 2, 3}, []time.Time{at(23, 50), at(23, 59), at(24, 20)}), today),
+	)...)
+
+	tests := []struct {
+		name        string
+		serviceDate time.Time
+		want        []uint // 残る接続の列車ID(出発時刻順)
+	}{
+		{name: "today", serviceDate: today, want: []uint{2, 3, 3}},
+		{name: "yesterday", serviceDate: yesterday, want: []uint{1}},
+		{name: "no trains", serviceDate: today.AddDate(0, 0, 1), want: []uint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]uint, 0)
+			for _, c := range serviceDayConnections(connections, tt.serviceDate) {
+				got = append(got, c.op.TrainID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("trains = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastAndFirstTrainRoutes(t *testing.T) {
+	tests := []struct {
+		name      string
+		routes    []Route
+		wantLast  []string
+		wantFirst []string
+	}{
+		{
+			name:      "no routes",
+			routes:    nil,
+			wantLast:  []string{},
+			wantFirst: []string{},
+		},
+		{
+			name: "different trains",
+			routes: []Route{
+				testRoute(at(5, 0), at(5, 30), 0),
+				testRoute(at(23, 0), at(23, 40), 0),
+			},
+			wantLast:  []string{"23:00-23:40/0"},
+			wantFirst: []string{"05:00-05:30/0"},
+		},
+		{
+			name: "trade-offs at the same departure",
+			routes: []Route{
+				testRoute(at(23, 0), at(23, 30), 1),
+				testRoute(at(23, 0), at(23, 50), 0),
+				testRoute(at(22, 0), at(22, 20), 0),
+			},
+			wantLast:  []string{"23:00-23:30/1", "23:00-23:50/0"},
+			wantFirst: []string{"22:00-22:20/0"},
+		},
+		{
+			name: "trade-offs at the same arrival",
+			routes: []Route{
+				testRoute(at(5, 0), at(6, 0), 0),
+				testRoute(at(5, 20), at(6, 0), 1),
+			},
+			wantLast:  []string{"05:20-06:00/1"},
+			wantFirst: []string{"05:00-06:00/0", "05:20-06:00/1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := routeSummaries(lastTrainRoutes(tt.routes)); !slices.Equal(got, tt.wantLast) {
+				t.Errorf("last train = %v, want %v", got, tt.wantLast)
+			}
+			if got := routeSummaries(firstTrainRoutes(tt.routes)); !slices.Equal(got, tt.wantFirst) {
+				t.Errorf("first train = %v, want %v", got, tt.wantFirst)
+			}
+		})
+	}
+}
+
+// 始発・終電探索は、他の運行日の列車を使わない
+func TestServiceDayProfileRoutes(t *testing.T) {
+	today, yesterday := at(0, 0), at(0, 0).AddDate(0, 0, -1)
+	connections := serviceDayConnections(testConnections(slices.Concat(
+		onServiceDate(testTrain(1, []uint{1, 2}, []time.Time{at(0, 30), at(0, 50)}), yesterday),
+		onServiceDate(testTrain(2, []uint{1, 2}, []time.Time{at(5, 0), at(5, 20)}), today),
+		onServiceDate(testTrain(3, []uint{1, 2}, []time.Time{at(6, 0), at(6, 20)}), today),
+		onServiceDate(testTrain(4, []uint{1, 2}, []time.Time{at(24, 10), at(24, 30)}), today),
+	)...), today)
+
+	profile := newProfileSearch(newTestSearchContext(at(0, 0)), connections, 0)
+	routes, _ := profile.profileRoutes(context.Background(), 1, 2, at(0, 0), at(24, 0).Add(24*time.Hour))
+
+	if got, want := routeSummaries(lastTrainRoutes(routes)), []string{"00:10-00:30/0"}; !slices.Equal(got, want) {
+		t.Errorf("last train = %v, want %v", got, want)
+	}
+	if got, want := routeSummaries(firstTrainRoutes(routes)), []string{"05:00-05:20/0"}; !slices.Equal(got, want) {
+		t.Errorf("first train = %v, want %v", got, want)
+	}
+}
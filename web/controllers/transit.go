@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -13,16 +14,250 @@ var (
 	ErrQueueEmpty = errors.New("queue is empty")
 )
 
+// 経路探索の条件(出発・到着駅と日時以外)
+type SearchConstraints struct {
+	Wheelchair      bool          // 車いす利用(段差のある駅・乗換を使用しない)
+	ViaStationIDs   []uint        // 経由する必要のある駅(順不同)
+	AvoidStationIDs []uint        // 通過・停車しない駅
+	AvoidTypeIDs    []uint        // 使用しない列車種別
+	AgencyIDs       []uint        // 使用する事業者(空の場合は全事業者)
+	MaxTransfers    int           // 乗換回数の上限
+	MaxTravel       time.Duration // 出発日時からの所要時間の上限
+	MaxWait         time.Duration // 出発・乗換での待ち時間の上限
+}
+
 // 出発基準の経路探索パラメータ
 type TransitSearchParamsByDepart struct {
 	DepartStationID uint
 	DepartDateTime  time.Time
 	ArriveStationID uint
+	SearchConstraints
+}
+
+// 経路探索の結果
+type TransitSearchResult struct {
+	Routes               []Route
+	AvoidedCancellations []models.TrainCancellation // 経路が回避した運休(全経路の和集合)
+	AvoidedSuspensions   []models.SegmentSuspension // 経路が回避した運転見合わせ(全経路の和集合)
+	Complete             bool                       // 探索を最後まで行えたか(期限切れの場合はfalse)
+	searchAvoided        *avoidedDisruptions        // ルートに記録していない、探索中に回避した運休・運転見合わせ
 }
 
 type Route struct {
-	Operations  []models.Operation `json:"operations"`
-	ViaStations map[uint]struct{}  `json:"via_stations"` // 経由した駅の集合
+	Operations      []models.Operation           `json:"operations"`
+	ViaStations     map[uint]struct{}            `json:"via_stations"`     // 経由した駅の集合
+	Transfers       int                          `json:"transfers"`        // 乗換回数(直通・分割・併合で乗ったまま移る場合は数えない)
+	ThroughServices map[int]models.TrainRelation `json:"through_services"` // Operationsの添字から、次の移動へ乗ったまま移る列車の関係への対応
+
+	// 経路上の移動を選ぶ際に、より早い列車の代わりに回避した運休・運転見合わせ
+	AvoidedCancellations []models.TrainCancellation `json:"avoided_cancellations"`
+	AvoidedSuspensions   []models.SegmentSuspension `json:"avoided_suspensions"`
+	avoided              *avoidedDisruptions        // 探索中に記録する集合(親のルートと共有するため変更しない)
+}
+
+// 経路探索中に共有する情報
+type searchContext struct {
+	disruptions models.Disruptions
+	avoided     *avoidedDisruptions // ルートを持たない探索(到達圏・所要時間行列)で回避した運休・運転見合わせ
+	relations   models.TrainRelations
+	transfers   models.PlatformTransfers
+	wheelchair  bool
+	stepFree    map[uint]struct{} // 段差なしでホームまで移動できる駅(車いす利用時のみ使用)
+	via         []uint            // 経由する必要のある駅
+	avoidSta    map[uint]struct{} // 通過・停車しない駅
+	avoidTrains map[uint]struct{} // 使用しない列車(避ける列車種別に属する列車)
+	agencyTrain map[uint]struct{} // 使用する事業者の列車(事業者の指定が無い場合はnil)
+	depart      time.Time         // 出発日時
+	maxTravel   time.Duration
+	maxWait     time.Duration
+}
+
+// 出発日時departと探索条件から、探索中に共有する情報を取得・生成
+func newSearchContext(ctx context.Context, networkID uint, repos models.Repositories, db *sqlx.DB, depart time.Time, constraints SearchConstraints) (*searchContext, error) {
+	// 出発時刻以降に影響しうる運休・運転見合わせ情報を取得
+	disruptions, err := models.GetDisruptions(ctx, db, networkID, depart)
+	if err != nil {
+		return nil, fmt.Errorf("getDisruptions: %w", err)
+	}
+
+	// 直通・分割・併合の関係を取得
+	relations, err := models.GetTrainRelations(ctx, db, networkID)
+	if err != nil {
+		return nil, fmt.Errorf("getTrainRelations: %w", err)
+	}
+
+	// 番線間の乗換時間を取得
+	transfers, err := models.GetPlatformTransfers(ctx, db, networkID)
+	if err != nil {
+		return nil, fmt.Errorf("getPlatformTransfers: %w", err)
+	}
+
+	search := &searchContext{
+		disruptions: disruptions,
+		avoided:     newAvoidedDisruptions(),
+		relations:   relations,
+		transfers:   transfers,
+		wheelchair:  constraints.Wheelchair,
+		via:         constraints.ViaStationIDs,
+		depart:      depart,
+		maxTravel:   constraints.MaxTravel,
+		maxWait:     constraints.MaxWait,
+		avoidSta:    make(map[uint]struct{}, len(constraints.AvoidStationIDs)),
+	}
+	for _, stationID := range constraints.AvoidStationIDs {
+		search.avoidSta[stationID] = struct{}{}
+	}
+
+	// 避ける列車種別に属する列車を取得
+	search.avoidTrains, err = models.GetTrainIDsByTypeIDs(ctx, db, networkID, constraints.AvoidTypeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("getTrainIDsByTypeIDs: %w", err)
+	}
+
+	// 事業者の指定があれば、その事業者の列車に限る
+	if len(constraints.AgencyIDs) > 0 {
+		search.agencyTrain, err = models.GetTrainIDsByAgencyIDs(ctx, db, networkID, constraints.AgencyIDs)
+		if err != nil {
+			return nil, fmt.Errorf("getTrainIDsByAgencyIDs: %w", err)
+		}
+	}
+
+	// 車いす利用時は、乗換可能な駅を段差なしの駅に限る
+	if constraints.Wheelchair {
+		search.stepFree, err = repos.Stations.GetStepFreeStationIDs(ctx, networkID)
+		if err != nil {
+			return nil, fmt.Errorf("getStepFreeStationIDs: %w", err)
+		}
+	}
+
+	return search, nil
+}
+
+// 避ける駅・列車種別に該当せず、指定した事業者の列車で、運休・運転見合わせの影響を受けない移動か(回避したものとしては記録しない)
+func (s *searchContext) isUsable(op models.Operation) bool {
+	return s.isAllowed(op) && !isDisrupted(s.disruptions, op)
+}
+
+// 避ける駅・列車種別に該当せず、指定した事業者の列車の移動か(運休・運転見合わせは判定しない)
+func (s *searchContext) isAllowed(op models.Operation) bool {
+	if _, isAvoided := s.avoidSta[op.ArriveStationID]; isAvoided {
+		return false
+	}
+	if _, isAvoided := s.avoidTrains[op.TrainID]; isAvoided {
+		return false
+	}
+	if _, isAgencyTrain := s.agencyTrain[op.TrainID]; s.agencyTrain != nil && !isAgencyTrain {
+		return false
+	}
+	return true
+}
+
+// 移動lastから移動nextへ、列車に乗ったまま移れるか(同一列車の続行、または直通・分割・併合)
+func (s *searchContext) isStayingAboard(last models.Operation, next models.Operation) bool {
+	if last.TrainID == next.TrainID {
+		return true
+	}
+	_, isRelated := s.relations.Find(last.TrainID, next.TrainID, last.ArriveStationID)
+	return isRelated
+}
+
+// 次停車駅ごとに、運休・運転見合わせの影響を受けない最も早い移動を選択
+// 直前の移動(last)があれば、乗ったまま続けられる移動も候補に残す
+// 選択の際に回避した運休・運転見合わせは、次停車駅ごとに返す(その駅へ向かうルートが回避したものとする)
+// candidatesは、次停車駅ごとに待ち時間の短い順で並んでいる前提
+func (s *searchContext) selectNextOperations(candidates []models.Operation, last *models.Operation) ([]models.Operation, map[uint]*avoidedDisruptions) {
+	selected := make([]models.Operation, 0, len(candidates))
+	selectedArriveStations := make(map[uint]struct{})
+	avoided := make(map[uint]*avoidedDisruptions)
+	for _, op := range candidates {
+		// 所要時間の上限を超える移動は、探索を打ち切る
+		if op.ArriveDatetime.Sub(s.depart) > s.maxTravel {
+			continue
+		}
+
+		isStayingAboard := last != nil && s.isStayingAboard(*last, op)
+		if _, isSelected := selectedArriveStations[op.ArriveStationID]; isSelected && !isStayingAboard {
+			continue
+		}
+
+		// 乗り換える場合は、降車・乗車が可能な停車である必要がある
+		if !isStayingAboard && (op.NoBoarding || (last != nil && last.NoAlighting)) {
+			continue
+		}
+		if !isStayingAboard && last != nil && !s.canTransfer(*last, op) {
+			continue
+		}
+
+		// 出発・乗換時の待ち時間が上限を超える移動は使用しない
+		if !isStayingAboard {
+			waitFrom := s.depart
+			if last != nil {
+				waitFrom = last.ArriveDatetime
+			}
+			if op.DepartDatetime.Sub(waitFrom) > s.maxWait {
+				continue
+			}
+		}
+
+		// 避ける駅へ向かう移動・避ける種別の列車・指定外の事業者の列車・運休・運転見合わせの影響を受ける移動は、候補から除く
+		// NOTE: 回避した運休・運転見合わせを記録するため、他の条件をすべて満たした移動のみ判定する
+		if !s.isAllowed(op) {
+			continue
+		}
+		if isDisrupted(s.disruptions, op) {
+			if avoided[op.ArriveStationID] == nil {
+				avoided[op.ArriveStationID] = newAvoidedDisruptions()
+			}
+			avoided[op.ArriveStationID].check(s.disruptions, op)
+			continue
+		}
+
+		selected = append(selected, op)
+		selectedArriveStations[op.ArriveStationID] = struct{}{}
+	}
+	return selected, avoided
+}
+
+// 移動lastから移動nextへ乗り換えられるか
+// 到着・出発番線の組に応じた乗換時間を確保でき、車いす利用時は駅・乗換経路に段差が無い必要がある
+func (s *searchContext) canTransfer(last models.Operation, next models.Operation) bool {
+	transfer := s.transfers.Find(last.ArriveStationID, last.ArrivePlatform, next.DepartPlatform)
+	transferDuration := transfer.Duration
+	if s.wheelchair {
+		if _, isStepFree := s.stepFree[last.ArriveStationID]; !isStepFree || !transfer.StepFree {
+			return false
+		}
+		transferDuration = transfer.WheelchairDuration
+	}
+	return !next.DepartDatetime.Before(last.ArriveDatetime.Add(transferDuration))
+}
+
+// ルートが経由駅をすべて通っているか
+func (s *searchContext) passesVia(route Route) bool {
+	for _, stationID := range s.via {
+		if _, isPassed := route.ViaStations[stationID]; !isPassed {
+			return false
+		}
+	}
+	return true
+}
+
+// 目的地に到達したルートの乗換回数と、乗ったまま移る列車の関係・回避した運休・運転見合わせを設定
+func (s *searchContext) completeRoute(route Route) Route {
+	route.AvoidedCancellations, route.AvoidedSuspensions = route.avoided.list()
+	route.Transfers = 0
+	route.ThroughServices = make(map[int]models.TrainRelation)
+	for i := 1; i < len(route.Operations); i++ {
+		last, next := route.Operations[i-1], route.Operations[i]
+		if !s.isStayingAboard(last, next) {
+			route.Transfers++
+			continue
+		}
+		if relation, isRelated := s.relations.Find(last.TrainID, next.TrainID, last.ArriveStationID); isRelated {
+			route.ThroughServices[i-1] = relation
+		}
+	}
+	return route
 }
 
 // RouteのQueue構造とMethods
@@ -43,15 +278,35 @@ func (r *RouteQueue) isEmpty() bool {
 	return len(*r) == 0
 }
 
+// 探索の制限時間(ctxの期限)を過ぎたことによるエラーか
+// NOTE: DBドライバによっては、期限切れで中断したクエリのエラーがcontext.DeadlineExceededを含まないため、ctxの状態も確認する
+func isSearchTimeout(ctx context.Context, err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
 // 列車の乗り換え案内を検索(出発時刻基準)
-func SearchTransitByDepart(req TransitSearchParamsByDepart, db *sqlx.DB) ([]Route, error) {
+// NOTE: ctxの期限を過ぎた場合はエラーとせず、それまでに到達したルートをComplete=falseとして返す
+// NOTE: 運休・運転見合わせの影響を受ける列車は使用せず、次に早い列車を探索する
+func SearchTransitByDepart(ctx context.Context, networkID uint, req TransitSearchParamsByDepart, repos models.Repositories, db *sqlx.DB) (TransitSearchResult, error) {
 	reachedRoutes := make([]Route, 0, 10)
 
+	search, err := newSearchContext(ctx, networkID, repos, db, req.DepartDateTime, req.SearchConstraints)
+	if err != nil {
+		if isSearchTimeout(ctx, err) {
+			return TransitSearchResult{Complete: false}, nil
+		}
+		return TransitSearchResult{}, err
+	}
+
 	// 出発駅から発車する直近列車を取得
-	firstOperations, err := models.SearchNextDepartOperations(db, req.DepartStationID, req.DepartDateTime)
+	firstCandidates, err := repos.Operations.SearchNextDepartOperations(ctx, req.DepartStationID, req.DepartDateTime)
 	if err != nil {
-		return []Route{}, fmt.Errorf("searchTransit: %w", err)
+		if isSearchTimeout(ctx, err) {
+			return search.result(reachedRoutes, false), nil
+		}
+		return TransitSearchResult{}, fmt.Errorf("searchTransit: %w", err)
 	}
+	firstOperations, firstAvoided := search.selectNextOperations(firstCandidates, nil)
 
 	// 取得結果からルートを生成
 	searchingRouteQueue := make(RouteQueue, 0, 100)
@@ -62,34 +317,54 @@ func SearchTransitByDepart(req TransitSearchParamsByDepart, db *sqlx.DB) ([]Rout
 				operation.DepartStationID: {},
 				operation.ArriveStationID: {},
 			},
+			avoided: firstAvoided[operation.ArriveStationID],
 		}
 		searchingRouteQueue.enqueue(newRoute)
 	}
 
 	// 続けて幅優先探索で先の経路を取得する
 	// 追加探査すべきルートが無くなるまで続ける(データ取得に問題がなければ、有限時間で終了する)
+	// ctxの期限を過ぎた場合は探索を打ち切り、それまでに到達したルートのみ返す
+	complete := true
 	for !searchingRouteQueue.isEmpty() {
+		if err := ctx.Err(); err != nil {
+			if !errors.Is(err, context.DeadlineExceeded) {
+				return TransitSearchResult{}, err
+			}
+			complete = false
+			break
+		}
+
 		// 先頭の探索ルートを抜き出す
 		lastRoute, err := searchingRouteQueue.dequeue()
 		if err != nil {
-			return []Route{}, fmt.Errorf("dequeueSearchingRouteQueue: %w", err)
+			return TransitSearchResult{}, fmt.Errorf("dequeueSearchingRouteQueue: %w", err)
 		}
 
 		// 生成されたルートオブジェクトが目的地に到達していれば、完成ルートリストに追加
+		// 目的地が乗車専用の停車(降車不可)の場合や、経由駅をすべて通っていない場合は、ルートを破棄する
 		if lastRoute.Operations[len(lastRoute.Operations)-1].ArriveStationID == req.ArriveStationID {
-			reachedRoutes = append(reachedRoutes, lastRoute)
+			if !lastRoute.Operations[len(lastRoute.Operations)-1].NoAlighting && search.passesVia(lastRoute) {
+				reachedRoutes = append(reachedRoutes, search.completeRoute(lastRoute))
+			}
 			continue
 		}
 
 		// 最後に到達した駅・時刻を基準に新たな探索
-		newOperations, err := models.SearchNextDepartOperations(
-			db,
+		newCandidates, err := repos.Operations.SearchNextDepartOperations(
+			ctx,
 			lastRoute.Operations[len(lastRoute.Operations)-1].ArriveStationID,
 			lastRoute.Operations[len(lastRoute.Operations)-1].ArriveDatetime,
 		)
 		if err != nil {
-			return []Route{}, fmt.Errorf("searchNextOperations: %w", err)
+			if isSearchTimeout(ctx, err) {
+				complete = false
+				break
+			}
+			return TransitSearchResult{}, fmt.Errorf("searchNextOperations: %w", err)
 		}
+		lastOperation := lastRoute.Operations[len(lastRoute.Operations)-1]
+		newOperations, newAvoided := search.selectNextOperations(newCandidates, &lastOperation)
 
 		// 発見された移動について、適切なものを探索キューに追加
 		for _, newOperation := range newOperations {
@@ -98,6 +373,15 @@ func SearchTransitByDepart(req TransitSearchParamsByDepart, db *sqlx.DB) ([]Rout
 				continue
 			}
 
+			// 乗換回数の上限を超える移動は除外する
+			transfers := lastRoute.Transfers
+			if !search.isStayingAboard(lastOperation, newOperation) {
+				transfers++
+			}
+			if transfers > req.MaxTransfers {
+				continue
+			}
+
 			// 経由駅集合のDeep Copyをしてから新到達駅IDを追加
 			newViaStations := make(map[uint]struct{})
 			for viaStationID := range lastRoute.ViaStations {
@@ -112,6 +396,8 @@ func SearchTransitByDepart(req TransitSearchParamsByDepart, db *sqlx.DB) ([]Rout
 			extendedRoute := Route{
 				Operations:  append(copiedLastOperations, newOperation),
 				ViaStations: newViaStations,
+				Transfers:   transfers,
+				avoided:     lastRoute.avoided.merged(newAvoided[newOperation.ArriveStationID]),
 			}
 
 			// 目的地に到達していないので、ルートをEnqueue
@@ -120,5 +406,33 @@ func SearchTransitByDepart(req TransitSearchParamsByDepart, db *sqlx.DB) ([]Rout
 	}
 
 	// 目的地に到達したルートのみ返す
-	return reachedRoutes, nil
+	return search.result(reachedRoutes, complete), nil
+}
+
+// 探索したルートと、各ルートが回避した運休・運転見合わせの和集合から探索結果を生成
+func (s *searchContext) result(routes []Route, complete bool) TransitSearchResult {
+	return newTransitSearchResult(routes, s.avoided, complete)
+}
+
+// ルートと、ルートに記録していない回避した運休・運転見合わせ(searchAvoided)から探索結果を生成
+func newTransitSearchResult(routes []Route, searchAvoided *avoidedDisruptions, complete bool) TransitSearchResult {
+	avoided := newAvoidedDisruptions()
+	avoided.add(searchAvoided)
+	for _, route := range routes {
+		avoided.add(route.avoided)
+	}
+	cancellations, suspensions := avoided.list()
+	return TransitSearchResult{
+		Routes:               routes,
+		AvoidedCancellations: cancellations,
+		AvoidedSuspensions:   suspensions,
+		Complete:             complete,
+		searchAvoided:        searchAvoided,
+	}
+}
+
+// ルートをroutesに置き換えた探索結果(回避した運休・運転見合わせは、残したルートのものに限る)
+// NOTE: ルートの並べ替え・件数の制限後に使用する
+func (r TransitSearchResult) WithRoutes(routes []Route) TransitSearchResult {
+	return newTransitSearchResult(routes, r.searchAvoided, r.Complete)
 }
@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"outtech105.com/transit_server/models"
+)
+
+// selectNextOperationsは、isUsableで使用できない移動を選択しない
+func TestSelectNextOperationsAppliesUsability(t *testing.T) {
+	candidates := []models.Operation{
+		testOperation(1, 1, at(8, 0), 2, at(8, 10)),
+		testOperation(2, 1, at(8, 5), 2, at(8, 15)),
+		testOperation(3, 1, at(8, 0), 3, at(8, 20)),
+	}
+
+	tests := []struct {
+		name        string
+		configure   func(s *searchContext)
+		want        []uint          // 選択される列車ID
+		wantAvoided map[uint][]uint // 次停車駅ごとの、回避した運休の列車ID
+	}{
+		{
+			name:      "no constraints",
+			configure: func(s *searchContext) {},
+			want:      []uint{1, 3},
+		},
+		{
+			name:      "avoided station",
+			configure: func(s *searchContext) { s.avoidSta[3] = struct{}{} },
+			want:      []uint{1},
+		},
+		{
+			name:      "avoided train type",
+			configure: func(s *searchContext) { s.avoidTrains = map[uint]struct{}{1: {}} },
+			want:      []uint{2, 3},
+		},
+		{
+			name:      "agency filter",
+			configure: func(s *searchContext) { s.agencyTrain = map[uint]struct{}{2: {}} },
+			want:      []uint{2},
+		},
+		{
+			name: "cancelled train",
+			configure: func(s *searchContext) {
+				s.disruptions.Cancellations = []models.TrainCancellation{{TrainID: 1, ServiceDate: candidates[0].ServiceDate}}
+			},
+			want:        []uint{2, 3},
+			wantAvoided: map[uint][]uint{2: {1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSearchContext(at(7, 55))
+			tt.configure(s)
+
+			selected, avoided := s.selectNextOperations(candidates, nil)
+			got := make([]uint, 0, len(selected))
+			for _, op := range selected {
+				got = append(got, op.TrainID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("selected trains = %v, want %v", got, tt.want)
+			}
+
+			// 回避した運休は、その列車が向かう次停車駅ごとに記録する
+			gotAvoided := make(map[uint][]uint)
+			for stationID, a := range avoided {
+				cancellations, _ := a.list()
+				for _, c := range cancellations {
+					gotAvoided[stationID] = append(gotAvoided[stationID], c.TrainID)
+				}
+			}
+			if fmt.Sprint(gotAvoided) != fmt.Sprint(map[uint][]uint(tt.wantAvoided)) {
+				t.Errorf("avoided cancellations = %v, want %v", gotAvoided, tt.wantAvoided)
+			}
+
+			// 選択された移動は、すべてisUsableを満たす
+			for _, op := range selected {
+				if !s.isUsable(op) {
+					t.Errorf("selected train %d is not usable", op.TrainID)
+				}
+			}
+		})
+	}
+}
+
+// 直通・分割・併合で乗ったまま移る移動は、乗換の条件(乗換時間・降車・乗車の可否・待ち時間)を問わず選択する
+func TestSelectNextOperationsStaysAboard(t *testing.T) {
+	// 駅2に8:10着の列車1から、駅3へ向かう列車2・3と、駅4へ向かう列車4へ(駅2の乗換時間は5分)
+	last := testOperation(1, 1, at(8, 0), 2, at(8, 10))
+	candidates := []models.Operation{
+		testOperation(2, 2, at(8, 10), 3, at(8, 20)),
+		testOperation(3, 2, at(8, 12), 3, at(8, 22)),
+		testOperation(4, 2, at(8, 15), 4, at(8, 25)),
+	}
+	throughService := models.TrainRelations{{1, 2, 2}: {FromTrainID: 1, ToTrainID: 2, StationID: 2, Type: models.RelationThrough}}
+
+	tests := []struct {
+		name      string
+		configure func(s *searchContext, last *models.Operation, candidates []models.Operation)
+		want      []uint // 選択される列車ID
+	}{
+		{
+			name:      "transfer",
+			configure: func(s *searchContext, last *models.Operation, candidates []models.Operation) {},
+			want:      []uint{4},
+		},
+		{
+			name: "through service",
+			configure: func(s *searchContext, last *models.Operation, candidates []models.Operation) {
+				s.relations = throughService
+			},
+			want: []uint{2, 4},
+		},
+		{
+			name: "relation at another station",
+			configure: func(s *searchContext, last *models.Operation, candidates []models.Operation) {
+				s.relations = models.TrainRelations{{1, 2, 5}: {FromTrainID: 1, ToTrainID: 2, StationID: 5, Type: models.RelationThrough}}
+			},
+			want: []uint{4},
+		},
+		{
+			name: "no alighting",
+			configure: func(s *searchContext, last *models.Operation, candidates []models.Operation) {
+				last.NoAlighting = true
+			},
+			want: []uint{},
+		},
+		{
+			name: "through service without alighting",
+			configure: func(s *searchContext, last *models.Operation, candidates []models.Operation) {
+				s.relations = throughService
+				last.NoAlighting = true
+			},
+			want: []uint{2},
+		},
+		{
+			name: "through service without boarding",
+			configure: func(s *searchContext, last *models.Operation, candidates []models.Operation) {
+				s.relations = throughService
+				candidates[0].NoBoarding = true
+			},
+			want: []uint{2, 4},
+		},
+		{
+			name: "through service beyond max wait",
+			configure: func(s *searchContext, last *models.Operation, candidates []models.Operation) {
+				s.relations = throughService
+				s.maxWait = time.Minute
+				candidates[0].DepartDatetime = at(8, 12)
+			},
+			want: []uint{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSearchContext(at(7, 55))
+			s.transfers = models.PlatformTransfers{{StationID: 2}: {Duration: 5 * time.Minute, StepFree: true}}
+			l, c := last, slices.Clone(candidates)
+			tt.configure(s, &l, c)
+
+			selected, _ := s.selectNextOperations(c, &l)
+			got := make([]uint, 0, len(selected))
+			for _, op := range selected {
+				got = append(got, op.TrainID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("selected trains = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 乗換時間は、到着・出発番線の組、到着番線のみ、出発番線のみ、駅全体の順に設定を探す(未設定は0分)
+func TestCanTransfer(t *testing.T) {
+	platform := func(name string) *string { return &name }
+	transfers := models.PlatformTransfers{
+		{StationID: 2, FromPlatform: "1", ToPlatform: "2"}: {Duration: 6 * time.Minute, StepFree: true},
+		{StationID: 2, FromPlatform: "1", ToPlatform: ""}:  {Duration: 4 * time.Minute, StepFree: true},
+		{StationID: 2, FromPlatform: "", ToPlatform: "2"}:  {Duration: 5 * time.Minute, StepFree: true},
+		{StationID: 2, FromPlatform: "", ToPlatform: ""}:   {Duration: 3 * time.Minute, StepFree: true},
+	}
+
+	tests := []struct {
+		name           string
+		station        uint // 乗換駅
+		arrivePlatform *string
+		departPlatform *string
+		wait           time.Duration // 到着から出発までの時間
+		want           bool
+	}{
+		{name: "platform pair", station: 2, arrivePlatform: platform("1"), departPlatform: platform("2"), wait: 6 * time.Minute, want: true},
+		{name: "platform pair too short", station: 2, arrivePlatform: platform("1"), departPlatform: platform("2"), wait: 5 * time.Minute, want: false},
+		{name: "arrive platform", station: 2, arrivePlatform: platform("1"), departPlatform: platform("3"), wait: 4 * time.Minute, want: true},
+		{name: "arrive platform too short", station: 2, arrivePlatform: platform("1"), departPlatform: platform("3"), wait: 3 * time.Minute, want: false},
+		{name: "depart platform", station: 2, arrivePlatform: platform("4"), departPlatform: platform("2"), wait: 5 * time.Minute, want: true},
+		{name: "depart platform too short", station: 2, arrivePlatform: platform("4"), departPlatform: platform("2"), wait: 4 * time.Minute, want: false},
+		{name: "station", station: 2, wait: 3 * time.Minute, want: true},
+		{name: "station too short", station: 2, wait: 2 * time.Minute, want: false},
+		{name: "station without transfers", station: 3, wait: 0, want: true},
+		{name: "departed before arrival", station: 3, wait: -time.Minute, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSearchContext(at(7, 55))
+			s.transfers = transfers
+
+			last := testOperation(1, 1, at(8, 0), tt.station, at(8, 10))
+			last.ArrivePlatform = tt.arrivePlatform
+			next := testOperation(2, tt.station, at(8, 10).Add(tt.wait), 4, at(8, 30))
+			next.DepartPlatform = tt.departPlatform
+
+			if got := s.canTransfer(last, next); got != tt.want {
+				t.Errorf("canTransfer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 車いす利用時は、段差なしの駅・乗換経路に限り、車いす利用時の乗換時間を確保する
+func TestCanTransferWheelchair(t *testing.T) {
+	platform := func(name string) *string { return &name }
+	transfers := models.PlatformTransfers{
+		{StationID: 2, FromPlatform: "1", ToPlatform: "2"}: {Duration: 3 * time.Minute, WheelchairDuration: 8 * time.Minute, StepFree: true},
+		{StationID: 2, FromPlatform: "1", ToPlatform: "3"}: {Duration: 3 * time.Minute, WheelchairDuration: 3 * time.Minute, StepFree: false},
+	}
+
+	tests := []struct {
+		name           string
+		wheelchair     bool
+		station        uint // 乗換駅(駅2・3は段差なし)
+		departPlatform *string
+		wait           time.Duration // 到着から出発までの時間
+		want           bool
+	}{
+		{name: "without wheelchair", station: 2, departPlatform: platform("2"), wait: 3 * time.Minute, want: true},
+		{name: "wheelchair transfer time", wheelchair: true, station: 2, departPlatform: platform("2"), wait: 8 * time.Minute, want: true},
+		{name: "wheelchair transfer time too short", wheelchair: true, station: 2, departPlatform: platform("2"), wait: 7 * time.Minute, want: false},
+		{name: "transfer with steps", wheelchair: true, station: 2, departPlatform: platform("3"), wait: 30 * time.Minute, want: false},
+		{name: "transfer with steps without wheelchair", station: 2, departPlatform: platform("3"), wait: 3 * time.Minute, want: true},
+		{name: "step-free station without transfers", wheelchair: true, station: 3, wait: 0, want: true},
+		{name: "station with steps", wheelchair: true, station: 4, wait: 30 * time.Minute, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSearchContext(at(7, 55))
+			s.transfers = transfers
+			s.wheelchair = tt.wheelchair
+			s.stepFree = map[uint]struct{}{2: {}, 3: {}}
+
+			last := testOperation(1, 1, at(8, 0), tt.station, at(8, 10))
+			last.ArrivePlatform = platform("1")
+			next := testOperation(2, tt.station, at(8, 10).Add(tt.wait), 5, at(8, 30))
+			next.DepartPlatform = tt.departPlatform
+
+			if got := s.canTransfer(last, next); got != tt.want {
+				t.Errorf("canTransfer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"time"
 
@@ -25,12 +26,12 @@ func ConnectDB(maxRetryCount int) (*sqlx.DB, error) {
 		// DB接続が確立されたら離脱
 		err = db.Ping()
 		if err == nil {
-			fmt.Println("DB connection successful")
+			log.Printf("DB connection successful")
 			return db, nil
 		}
 
 		// 接続失敗時は5秒待機後リトライ
-		fmt.Println("DB Connection Error: " + err.Error())
+		log.Printf("DB Connection Error: %v", err)
 		time.Sleep(5 * time.Second)
 	}
 	return nil, fmt.Errorf("DB connection error occured %d times", maxRetryCount)
@@ -0,0 +1,434 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DBの種類ごとのマイグレーション(migrations/<DBの種類>/<バージョン>_<名前>.up.sql・.down.sql)
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// マイグレーション導入前にinit.sql等で作成したスキーマの判定(バージョンの新しい順)
+// 各バージョンで追加したテーブル(columnが空でなければ列)のうち、存在する最新のものまでを適用済みとして登録する
+// NOTE: init.sqlは各バージョンの変更を順に取り込んでいたため、途中のバージョンの追加分が欠けているスキーマは判定できない
+var legacySchemas = []struct {
+	version uint
+	table   string
+	column  string
+}{
+	{6, "platform_transfers", ""}, // マイグレーション導入直前のinit.sql(SQLite・PostgreSQLはschema.sql)
+	{5, "agencies", ""},
+	{4, "stations", "step_free"},
+	{3, "stop_times", ""},
+	{2, "networks", ""},
+	{1, "stations", ""}, // 最初のinit.sql
+}
+
+// スキーマの1バージョン分の変更
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string // 適用するSQL
+	Down    string // 取り消すSQL
+}
+
+// 適用済みのマイグレーション(schema_migrationsテーブルの1行)
+type AppliedMigration struct {
+	Version   uint
+	Name      string
+	AppliedAt time.Time
+}
+
+// DBの種類ごとの、マイグレーション管理用のSQL
+type migrationDialect struct {
+	dir              string // マイグレーションのディレクトリ名
+	createTable      string // schema_migrationsテーブルの作成
+	tableExists      string // 引数: テーブル名
+	columnExists     string // 引数: テーブル名, 列名
+	transactionalDDL bool   // DDLをトランザクション内で実行できるか(MySQLはDDLで暗黙的にコミットする)
+
+	// マイグレーション中の外部キー制約の無効化・再有効化と、コミット前の違反件数の確認
+	// NOTE: SQLiteはテーブルを作り直して列・制約を変更するため、作り直しの間は外部キー制約を無効にする
+	foreignKeysOff  string
+	foreignKeysOn   string
+	foreignKeyCheck string
+}
+
+var migrationDialects = map[string]migrationDialect{
+	"mysql": {
+		dir:          "mysql",
+		createTable:  "CREATE TABLE schema_migrations (version int unsigned NOT NULL, name varchar(255) NOT NULL, applied_at datetime NOT NULL, PRIMARY KEY (version))",
+		tableExists:  "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?",
+		columnExists: "SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?",
+	},
+	"sqlite3": {
+		dir:              "sqlite",
+		createTable:      "CREATE TABLE schema_migrations (version INTEGER NOT NULL PRIMARY KEY, name TEXT NOT NULL, applied_at datetime NOT NULL)",
+		tableExists:      "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?",
+		columnExists:     "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?",
+		transactionalDDL: true,
+		foreignKeysOff:   "PRAGMA foreign_keys = OFF",
+		foreignKeysOn:    "PRAGMA foreign_keys = ON",
+		foreignKeyCheck:  "SELECT COUNT(*) FROM pragma_foreign_key_check",
+	},
+	"postgres": {
+		dir:              "postgres",
+		createTable:      "CREATE TABLE schema_migrations (version INTEGER NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMPTZ NOT NULL)",
+		tableExists:      "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?",
+		columnExists:     "SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?",
+		transactionalDDL: true,
+	},
+}
+
+// DBのスキーマを、バージョン付きのマイグレーションで更新する
+type Migrator struct {
+	db         *sqlx.DB
+	dialect    migrationDialect
+	migrations []Migration // バージョン順
+}
+
+// DBの種類に対応するマイグレーションを読み込む
+func NewMigrator(db *sqlx.DB) (*Migrator, error) {
+	dialect, ok := migrationDialects[db.DriverName()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported driver: %s", db.DriverName())
+	}
+	migrations, err := loadMigrations(dialect.dir)
+	if err != nil {
+		return nil, fmt.Errorf("loadMigrations: %w", err)
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// migrations/<dir>以下のマイグレーションを、バージョン順に読み込む
+// バージョンは1からの連番とし、各バージョンにup・downの両方が必要
+func loadMigrations(dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, path.Join("migrations", dir))
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration, len(entries)/2)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid file name: %s", entry.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("parse version %s: %w", entry.Name(), err)
+		}
+		content, err := migrationFiles.ReadFile(path.Join("migrations", dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("duplicate version %d: %s, %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("version %d (%s): both up and down are required", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != uint(i+1) {
+			return nil, fmt.Errorf("version %d (%s): versions must be sequential from 1", m.Version, m.Name)
+		}
+	}
+	return migrations, nil
+}
+
+// 未適用のマイグレーションを、バージョン順にすべて適用する
+// 適用したマイグレーションを返す(最新の場合は空)
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	current, err := m.currentVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if current > uint(len(m.migrations)) {
+		return nil, fmt.Errorf("schema version %d is newer than this server (latest: %d)", current, len(m.migrations))
+	}
+
+	applied := make([]Migration, 0, len(m.migrations))
+	for _, migration := range m.migrations[current:] {
+		err := m.run(ctx, migration.Up, func(tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(
+				ctx,
+				tx.Rebind(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`),
+				migration.Version,
+				migration.Name,
+				time.Now().UTC(),
+			)
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("up %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// 適用済みのマイグレーションを、新しい順にsteps件取り消す
+// 取り消したマイグレーションを返す
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	current, err := m.currentVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if current > uint(len(m.migrations)) {
+		return nil, fmt.Errorf("schema version %d is newer than this server (latest: %d)", current, len(m.migrations))
+	}
+
+	reverted := make([]Migration, 0, steps)
+	for version := current; version > 0 && len(reverted) < steps; version-- {
+		migration := m.migrations[version-1]
+		err := m.run(ctx, migration.Down, func(tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(ctx, tx.Rebind(`DELETE FROM schema_migrations WHERE version = ?`), migration.Version)
+			return err
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("down %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// 適用済みのマイグレーションと、未適用のマイグレーションを返す
+func (m *Migrator) Status(ctx context.Context) ([]AppliedMigration, []Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	applied := make([]AppliedMigration, 0, len(m.migrations))
+	rows, err := m.db.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("selectMigrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.AppliedAt); err != nil {
+			return nil, nil, fmt.Errorf("scanRecord: %w", err)
+		}
+		applied = append(applied, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("scanRecord: %w", err)
+	}
+
+	pending := make([]Migration, 0, len(m.migrations))
+	if len(applied) < len(m.migrations) {
+		pending = append(pending, m.migrations[len(applied):]...)
+	}
+	return applied, pending, nil
+}
+
+// schema_migrationsテーブルが無ければ作成する
+// NOTE: マイグレーション導入前にinit.sql等でスキーマを作成済みのDBは、データを残したまま該当するバージョンまでを適用済みとして登録する
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	exists, err := m.tableExists(ctx, "schema_migrations")
+	if err != nil || exists {
+		return err
+	}
+	legacyVersion, err := m.legacyVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.dialect.createTable); err != nil {
+		return fmt.Errorf("createMigrationsTable: %w", err)
+	}
+	for _, migration := range m.migrations[:legacyVersion] {
+		_, err := tx.ExecContext(
+			ctx,
+			tx.Rebind(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`),
+			migration.Version,
+			migration.Name,
+			time.Now().UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("insertLegacyVersion: %w", err)
+		}
+	}
+	if legacyVersion > 0 {
+		log.Printf("Existing schema found. Marked up to %04d_%s as applied", legacyVersion, m.migrations[legacyVersion-1].Name)
+	}
+	return tx.Commit()
+}
+
+// マイグレーション導入前のスキーマのバージョン(スキーマが無い場合は0)
+// 判定したバージョンより前の追加分が欠けている場合は、既知のどのバージョンとも一致しないためエラーとする
+func (m *Migrator) legacyVersion(ctx context.Context) (uint, error) {
+	var version uint
+	for _, legacy := range legacySchemas {
+		found, err := m.legacySchemaExists(ctx, legacy.table, legacy.column)
+		if err != nil {
+			return 0, err
+		}
+		if version == 0 && found {
+			version = legacy.version
+		}
+		if version > 0 && !found {
+			return 0, fmt.Errorf("existing schema matches no known version: version %d found but %s is missing for version %d", version, legacySchemaName(legacy.table, legacy.column), legacy.version)
+		}
+	}
+	return min(version, uint(len(m.migrations))), nil
+}
+
+// 判定に使うテーブル(columnが空でなければ列)が存在するか
+func (m *Migrator) legacySchemaExists(ctx context.Context, table, column string) (bool, error) {
+	if column == "" {
+		return m.tableExists(ctx, table)
+	}
+	var count int
+	if err := m.db.QueryRowContext(ctx, m.db.Rebind(m.dialect.columnExists), table, column).Scan(&count); err != nil {
+		return false, fmt.Errorf("columnExists %s: %w", legacySchemaName(table, column), err)
+	}
+	return count > 0, nil
+}
+
+func legacySchemaName(table, column string) string {
+	if column == "" {
+		return table
+	}
+	return table + "." + column
+}
+
+// テーブルが存在するか
+func (m *Migrator) tableExists(ctx context.Context, table string) (bool, error) {
+	var count int
+	if err := m.db.QueryRowContext(ctx, m.db.Rebind(m.dialect.tableExists), table).Scan(&count); err != nil {
+		return false, fmt.Errorf("tableExists %s: %w", table, err)
+	}
+	return count > 0, nil
+}
+
+// 適用済みの最新バージョン(未適用の場合は0)
+// NOTE: バージョンは1からの連番で順に適用するため、最大値までがすべて適用済みとなる
+func (m *Migrator) currentVersion(ctx context.Context) (uint, error) {
+	var version *uint
+	if err := m.db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("selectVersion: %w", err)
+	}
+	if version == nil {
+		return 0, nil
+	}
+	return *version, nil
+}
+
+// マイグレーションのSQLを1文ずつ実行し、record(schema_migrationsの更新)とともにコミットする
+// NOTE: MySQLはDDLの実行時に暗黙的にコミットするため、途中で失敗した場合は実行済みの文を手動で戻す必要がある
+// NOTE: 外部キー制約の無効化は接続ごとの設定のため、1つの接続上で実行する
+func (m *Migrator) run(ctx context.Context, script string, record func(tx *sqlx.Tx) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dialect.foreignKeysOff != "" {
+		if _, err := conn.ExecContext(ctx, m.dialect.foreignKeysOff); err != nil {
+			return fmt.Errorf("disableForeignKeys: %w", err)
+		}
+		defer func() {
+			if _, err := conn.ExecContext(context.Background(), m.dialect.foreignKeysOn); err != nil {
+				log.Printf("enableForeignKeys: %v", err)
+			}
+		}()
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, statement := range splitStatements(script) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			if !m.dialect.transactionalDDL {
+				return fmt.Errorf("statement %d (statements before it are already committed): %w", i+1, err)
+			}
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+	}
+	if m.dialect.foreignKeyCheck != "" {
+		var violations int
+		if err := tx.QueryRowContext(ctx, m.dialect.foreignKeyCheck).Scan(&violations); err != nil {
+			return fmt.Errorf("foreignKeyCheck: %w", err)
+		}
+		if violations > 0 {
+			return fmt.Errorf("foreign key constraint violated (%d rows)", violations)
+		}
+	}
+	if err := record(tx); err != nil {
+		return fmt.Errorf("recordMigration: %w", err)
+	}
+	return tx.Commit()
+}
+
+// SQLを文ごとに分割する(行末の;を文の終わりとし、--で始まる行は除く)
+// NOTE: MySQLのドライバは1回の実行で複数の文を受け付けないため、1文ずつ実行する
+func splitStatements(script string) []string {
+	statements := make([]string, 0, 20)
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,428 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 一時ディレクトリにSQLiteのDBを作成する
+func newTestSQLite(t *testing.T) *sqlx.DB {
+	t.Helper()
+	db, err := ConnectSQLite(filepath.Join(t.TempDir(), "test.db"), true)
+	if err != nil {
+		t.Fatalf("ConnectSQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newTestMigrator(t *testing.T, db *sqlx.DB) *Migrator {
+	t.Helper()
+	m, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	return m
+}
+
+func execAll(t *testing.T, db *sqlx.DB, statements ...string) {
+	t.Helper()
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("exec %q: %v", statement, err)
+		}
+	}
+}
+
+// クエリ結果を、1行を文字列のスライスとして読み込む(NULLは"NULL")
+func queryStrings(t *testing.T, db *sqlx.DB, query string) [][]string {
+	t.Helper()
+	rows, err := db.Queryx(query)
+	if err != nil {
+		t.Fatalf("query %q: %v", query, err)
+	}
+	defer rows.Close()
+
+	result := make([][]string, 0)
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			t.Fatalf("scan %q: %v", query, err)
+		}
+		row := make([]string, len(values))
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+				row[i] = "NULL"
+			case []byte:
+				row[i] = string(v)
+			default:
+				row[i] = fmt.Sprint(v)
+			}
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows %q: %v", query, err)
+	}
+	return result
+}
+
+// スキーマ(テーブル・ビュー・インデックスの定義)
+const schemaQuery = `SELECT type, name, COALESCE(sql, '') FROM sqlite_master WHERE name NOT IN ('sqlite_sequence', 'schema_migrations') ORDER BY type, name`
+
+func TestMigrationsHaveSameVersionsForAllDialects(t *testing.T) {
+	names := make(map[string][]string, len(migrationDialects))
+	for driver, dialect := range migrationDialects {
+		migrations, err := loadMigrations(dialect.dir)
+		if err != nil {
+			t.Fatalf("loadMigrations(%s): %v", dialect.dir, err)
+		}
+		for _, m := range migrations {
+			names[driver] = append(names[driver], m.Name)
+		}
+	}
+	for driver, got := range names {
+		if !reflect.DeepEqual(got, names["mysql"]) {
+			t.Errorf("migrations of %s = %v, want %v", driver, got, names["mysql"])
+		}
+	}
+}
+
+// 最初のinit.sqlで作成したDB(schema_migrationsなし)を最新まで更新しても、データが残る
+func TestMigratorUpgradesBaselineDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := newTestSQLite(t)
+	m := newTestMigrator(t, db)
+
+	execAll(t, db, splitStatements(m.migrations[0].Up)...)
+	execAll(t, db,
+		`INSERT INTO stations (id, name, name_en) VALUES (1, '甲', 'Ko'), (2, '乙', 'Otsu'), (3, '丙', 'Hei')`,
+		`INSERT INTO trains (id, name) VALUES (1, '普通1'), (2, '普通2')`,
+		`INSERT INTO operations (train_id, op_order, dep_sta_id, dep_time, arr_sta_id, arr_time) VALUES
+			(1, 1, 1, '08:00:00', 2, '08:10:00'),
+			(1, 2, 2, '08:12:00', 3, '08:20:00'),
+			(2, 1, 3, '23:50:00', 1, '24:30:00')`,
+	)
+	before := queryStrings(t, db, `SELECT train_id, op_order, dep_sta_id, dep_time, arr_sta_id, arr_time FROM operations ORDER BY train_id, op_order`)
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != len(m.migrations)-1 || applied[0].Version != 2 {
+		t.Fatalf("applied %d migrations from %v, want versions 2..%d", len(applied), applied, len(m.migrations))
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  [][]string
+	}{
+		{
+			name:  "stations",
+			query: `SELECT id, network_id, name, name_en FROM stations ORDER BY id`,
+			want:  [][]string{{"1", "1", "甲", "Ko"}, {"2", "1", "乙", "Otsu"}, {"3", "1", "丙", "Hei"}},
+		},
+		{
+			name:  "trains",
+			query: `SELECT id, network_id, name, COALESCE(type_id, 'NULL') FROM trains ORDER BY id`,
+			want:  [][]string{{"1", "1", "普通1", "NULL"}, {"2", "1", "普通2", "NULL"}},
+		},
+		{
+			name:  "stop_times",
+			query: `SELECT train_id, stop_sequence, station_id, arr_time, dep_time FROM stop_times ORDER BY train_id, stop_sequence`,
+			want: [][]string{
+				{"1", "1", "1", "NULL", "08:00:00"},
+				{"1", "2", "2", "08:10:00", "08:12:00"},
+				{"1", "3", "3", "08:20:00", "NULL"},
+				{"2", "1", "3", "NULL", "23:50:00"},
+				{"2", "2", "1", "24:30:00", "NULL"},
+			},
+		},
+		{
+			name:  "operations",
+			query: `SELECT train_id, op_order, dep_sta_id, dep_time, arr_sta_id, arr_time FROM operations ORDER BY train_id, op_order`,
+			want:  before,
+		},
+		{
+			name:  "networks",
+			query: `SELECT id, code FROM networks`,
+			want:  [][]string{{"1", "default"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryStrings(t, db, tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	_, pending, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("pending = %v, want none", pending)
+	}
+}
+
+// down N件の後にupすると、スキーマと(取り消したバージョンで失われない)データが元に戻る
+func TestMigratorDownUpRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	total := len(newTestMigrator(t, newTestSQLite(t)).migrations)
+
+	for steps := 1; steps < total; steps++ {
+		t.Run(strconv.Itoa(steps), func(t *testing.T) {
+			db := newTestSQLite(t)
+			m := newTestMigrator(t, db)
+			if _, err := m.Up(ctx); err != nil {
+				t.Fatalf("Up: %v", err)
+			}
+			execAll(t, db,
+				`INSERT INTO stations (id, network_id, name, name_en) VALUES (1, 1, '甲', 'Ko'), (2, 1, '乙', 'Otsu'), (3, 1, '丙', 'Hei')`,
+				`INSERT INTO trains (id, network_id, name) VALUES (1, 1, '普通1')`,
+				`INSERT INTO stop_times (train_id, stop_sequence, station_id, arr_time, dep_time) VALUES
+					(1, 1, 1, NULL, '08:00:00'),
+					(1, 2, 2, '08:10:00', '08:12:00'),
+					(1, 3, 3, '08:20:00', NULL)`,
+			)
+			queries := []string{
+				schemaQuery,
+				`SELECT id, network_id, name, name_en FROM stations ORDER BY id`,
+				`SELECT id, network_id, name FROM trains ORDER BY id`,
+				`SELECT train_id, op_order, dep_sta_id, dep_time, arr_sta_id, arr_time FROM operations ORDER BY train_id, op_order`,
+			}
+			before := make([][][]string, len(queries))
+			for i, q := range queries {
+				before[i] = queryStrings(t, db, q)
+			}
+
+			reverted, err := m.Down(ctx, steps)
+			if err != nil {
+				t.Fatalf("Down(%d): %v", steps, err)
+			}
+			if len(reverted) != steps {
+				t.Fatalf("reverted %d migrations, want %d", len(reverted), steps)
+			}
+			applied, err := m.Up(ctx)
+			if err != nil {
+				t.Fatalf("Up: %v", err)
+			}
+			if len(applied) != steps {
+				t.Fatalf("applied %d migrations, want %d", len(applied), steps)
+			}
+
+			for i, q := range queries {
+				if got := queryStrings(t, db, q); !reflect.DeepEqual(got, before[i]) {
+					t.Errorf("%s:\ngot  %v\nwant %v", q, got, before[i])
+				}
+			}
+		})
+	}
+}
+
+// すべて取り消すとテーブルが残らず、再度適用すると同じスキーマになる
+func TestMigratorDownAll(t *testing.T) {
+	ctx := context.Background()
+	db := newTestSQLite(t)
+	m := newTestMigrator(t, db)
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	schema := queryStrings(t, db, schemaQuery)
+
+	if _, err := m.Down(ctx, len(m.migrations)); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if got := queryStrings(t, db, schemaQuery); len(got) != 0 {
+		t.Errorf("schema after down = %v, want empty", got)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if got := queryStrings(t, db, schemaQuery); !reflect.DeepEqual(got, schema) {
+		t.Errorf("schema after up:\ngot  %v\nwant %v", got, schema)
+	}
+}
+
+// お知らせの日本語・英語の文言を、ロケールごとの文言に移し、downで元の列に戻す
+func TestMigratorMovesAlertTexts(t *testing.T) {
+	ctx := context.Background()
+	db := newTestSQLite(t)
+	m := newTestMigrator(t, db)
+	// 0007_alert_texts以降を取り消した状態で、元の列に文言を登録する
+	afterAlertTexts := len(m.migrations) - 6
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if _, err := m.Down(ctx, afterAlertTexts); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	execAll(t, db,
+		`INSERT INTO service_alerts (id, network_id, severity, header, header_en, description, description_en) VALUES
+			(1, 1, 'warning', '工事', 'Construction', '番線変更', 'Track change'),
+			(2, 1, 'info', 'ダイヤ改正', '', '', '')`,
+		`INSERT INTO service_alert_entities (alert_id, entity_type, entity_id) VALUES (1, 'station', 1)`,
+	)
+	before := queryStrings(t, db, `SELECT id, severity, header, header_en, description, description_en FROM service_alerts ORDER BY id`)
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	texts := queryStrings(t, db, `SELECT alert_id, locale, header, description FROM service_alert_texts ORDER BY alert_id, locale`)
+	wantTexts := [][]string{
+		{"1", "en", "Construction", "Track change"},
+		{"1", "ja", "工事", "番線変更"},
+		{"2", "ja", "ダイヤ改正", ""},
+	}
+	if !reflect.DeepEqual(texts, wantTexts) {
+		t.Errorf("texts = %v, want %v", texts, wantTexts)
+	}
+	if got := queryStrings(t, db, `SELECT alert_id, entity_type, entity_id FROM service_alert_entities`); !reflect.DeepEqual(got, [][]string{{"1", "station", "1"}}) {
+		t.Errorf("entities = %v, want the entity of alert 1", got)
+	}
+
+	if _, err := m.Down(ctx, afterAlertTexts); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if got := queryStrings(t, db, `SELECT id, severity, header, header_en, description, description_en FROM service_alerts ORDER BY id`); !reflect.DeepEqual(got, before) {
+		t.Errorf("alerts after down = %v, want %v", got, before)
+	}
+}
+
+// マイグレーション導入前のスキーマを、各バージョンで追加したテーブル・列の有無で判定する
+func TestMigratorDetectsLegacySchema(t *testing.T) {
+	ctx := context.Background()
+
+	// versionまでを適用したスキーマを、schema_migrationsテーブル無しで作成する
+	legacySchema := func(version uint) func(t *testing.T, db *sqlx.DB, m *Migrator) {
+		return func(t *testing.T, db *sqlx.DB, m *Migrator) {
+			if _, err := m.Up(ctx); err != nil {
+				t.Fatalf("Up: %v", err)
+			}
+			if _, err := m.Down(ctx, len(m.migrations)-int(version)); err != nil {
+				t.Fatalf("Down: %v", err)
+			}
+			execAll(t, db, `DROP TABLE schema_migrations`)
+		}
+	}
+
+	type legacyTest struct {
+		name        string
+		setup       func(t *testing.T, db *sqlx.DB, m *Migrator)
+		wantApplied uint // 適用済みとして登録されるバージョン
+		wantErr     bool // 既知のバージョンと一致しない
+	}
+	tests := []legacyTest{
+		{
+			name:        "empty database",
+			setup:       func(t *testing.T, db *sqlx.DB, m *Migrator) {},
+			wantApplied: 0,
+		},
+		{
+			name: "first init.sql",
+			setup: func(t *testing.T, db *sqlx.DB, m *Migrator) {
+				execAll(t, db, splitStatements(m.migrations[0].Up)...)
+			},
+			wantApplied: 1,
+		},
+		{
+			name: "unknown schema",
+			setup: func(t *testing.T, db *sqlx.DB, m *Migrator) {
+				// 駅設備の列が無いまま、事業者のテーブルだけが追加されている
+				legacySchema(3)(t, db, m)
+				execAll(t, db, `CREATE TABLE agencies (id INTEGER PRIMARY KEY)`)
+			},
+			wantErr: true,
+		},
+	}
+	for _, legacy := range legacySchemas {
+		tests = append(tests, legacyTest{
+			name:        fmt.Sprintf("init.sql of version %d", legacy.version),
+			setup:       legacySchema(legacy.version),
+			wantApplied: legacy.version,
+		})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestSQLite(t)
+			m := newTestMigrator(t, db)
+			tt.setup(t, db, m)
+
+			applied, pending, err := m.Status(ctx)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Status succeeded with applied %d, want error", len(applied))
+				}
+				if _, err := m.Up(ctx); err == nil {
+					t.Error("Up succeeded on an unknown schema, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Status: %v", err)
+			}
+			if uint(len(applied)) != tt.wantApplied || len(pending) != len(m.migrations)-int(tt.wantApplied) {
+				t.Errorf("applied %d, pending %d; want applied %d", len(applied), len(pending), tt.wantApplied)
+			}
+			if _, err := m.Up(ctx); err != nil {
+				t.Fatalf("Up: %v", err)
+			}
+		})
+	}
+}
+
+// サーバの知らない新しいバージョンのDBには適用しない
+func TestMigratorRejectsNewerSchema(t *testing.T) {
+	ctx := context.Background()
+	db := newTestSQLite(t)
+	m := newTestMigrator(t, db)
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	execAll(t, db, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (9999, 'future', '2000-01-01 00:00:00')`)
+
+	if _, err := m.Up(ctx); err == nil {
+		t.Error("Up succeeded on a newer schema, want error")
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "comments and blank lines",
+			script: "-- comment\n\nCREATE TABLE a (id INTEGER);\n-- another\nDROP TABLE b;\n",
+			want:   []string{"CREATE TABLE a (id INTEGER)", "DROP TABLE b"},
+		},
+		{
+			name:   "multi-line statement",
+			script: "CREATE TABLE a (\n  id INTEGER\n);\n",
+			want:   []string{"CREATE TABLE a (\n  id INTEGER\n)"},
+		},
+		{
+			name:   "missing final semicolon",
+			script: "DROP TABLE a;\nDROP TABLE b\n",
+			want:   []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+-- 鉄道網(networks)の削除
+-- NOTE: 複数の鉄道網に同名の列車がある場合は、列車名の一意制約に違反するため取り消せない
+
+-- trainsテーブル
+ALTER TABLE `trains` DROP FOREIGN KEY `trains_networks_FK`;
+ALTER TABLE `trains`
+  DROP KEY `trains_unique`,
+  ADD UNIQUE KEY `trains_unique` (`name`),
+  DROP COLUMN `network_id`;
+
+-- stationsテーブル
+ALTER TABLE `stations` DROP FOREIGN KEY `stations_networks_FK`;
+ALTER TABLE `stations`
+  DROP KEY `stations_networks_FK`,
+  DROP COLUMN `network_id`;
+
+-- networksテーブル
+DROP TABLE `networks`;
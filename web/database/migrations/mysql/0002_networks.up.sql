@@ -0,0 +1,35 @@
+-- 鉄道網(networks)の追加
+-- 既存の駅・列車は、既定の鉄道網(id=1)に所属させる
+
+-- networksテーブル
+-- 鉄道網(路線網)ごとの情報。time_zoneはIANAタイムゾーン名で、stop_timesの時刻はこのタイムゾーンの時刻として扱う
+-- codeはURL(/api/v2/traffic/:network/...)で鉄道網を指定する識別子
+-- admin_user/admin_password_hashは管理用エンドポイントの認証情報(パスワードはbcryptのハッシュ)。NULLの場合、管理用エンドポイントは無効
+CREATE TABLE `networks` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `code` varchar(32) NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `time_zone` varchar(64) NOT NULL DEFAULT 'Asia/Tokyo',
+  `admin_user` varchar(100) DEFAULT NULL,
+  `admin_password_hash` varchar(255) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `networks_unique` (`code`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+INSERT INTO `networks` (`id`, `code`, `name`, `time_zone`) VALUES (1, 'default', 'default', 'Asia/Tokyo');
+
+-- stationsテーブル
+ALTER TABLE `stations`
+  ADD COLUMN `network_id` int unsigned NOT NULL DEFAULT '1' AFTER `id`,
+  ADD KEY `stations_networks_FK` (`network_id`),
+  ADD CONSTRAINT `stations_networks_FK` FOREIGN KEY (`network_id`) REFERENCES `networks` (`id`);
+ALTER TABLE `stations` ALTER COLUMN `network_id` DROP DEFAULT;
+
+-- trainsテーブル
+-- 列車名は鉄道網ごとに一意
+ALTER TABLE `trains`
+  ADD COLUMN `network_id` int unsigned NOT NULL DEFAULT '1' AFTER `id`,
+  DROP KEY `trains_unique`,
+  ADD UNIQUE KEY `trains_unique` (`network_id`,`name`),
+  ADD CONSTRAINT `trains_networks_FK` FOREIGN KEY (`network_id`) REFERENCES `networks` (`id`);
+ALTER TABLE `trains` ALTER COLUMN `network_id` DROP DEFAULT;
@@ -0,0 +1,25 @@
+-- operationsビューをテーブルに戻す
+-- NOTE: 停車駅ごとの乗降制限・番線(pickup_only・drop_off_only・platform)は失われる
+
+CREATE TABLE `operations_table` (
+  `train_id` int unsigned NOT NULL,
+  `op_order` int unsigned NOT NULL,
+  `dep_sta_id` int unsigned NOT NULL,
+  `dep_time` time NOT NULL,
+  `arr_sta_id` int unsigned NOT NULL,
+  `arr_time` time NOT NULL,
+  PRIMARY KEY (`train_id`,`op_order`),
+  KEY `operations_stations_FK` (`dep_sta_id`),
+  KEY `operations_stations_FK_1` (`arr_sta_id`),
+  CONSTRAINT `operations_stations_FK` FOREIGN KEY (`dep_sta_id`) REFERENCES `stations` (`id`),
+  CONSTRAINT `operations_stations_FK_1` FOREIGN KEY (`arr_sta_id`) REFERENCES `stations` (`id`),
+  CONSTRAINT `operations_trains_FK` FOREIGN KEY (`train_id`) REFERENCES `trains` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+INSERT INTO `operations_table` (`train_id`, `op_order`, `dep_sta_id`, `dep_time`, `arr_sta_id`, `arr_time`)
+SELECT `train_id`, `op_order`, `dep_sta_id`, `dep_time`, `arr_sta_id`, `arr_time`
+FROM `operations`;
+
+DROP VIEW `operations`;
+RENAME TABLE `operations_table` TO `operations`;
+DROP TABLE `stop_times`;
@@ -0,0 +1,65 @@
+-- 時刻表を停車駅ごとの時刻(stop_times)で管理する
+-- 既存のoperationsテーブル(区間ごとの時刻)の内容をstop_timesに移し、operationsは同じ列を持つビューに置き換える
+
+-- stop_timesテーブル
+-- 列車の停車駅ごとの到着・発車時刻。始発駅のarr_time、終着駅のdep_timeはNULL
+-- 時刻は列車の運行日の0:00からの経過時間とし、日付を跨ぐ列車は24:00以降の時刻(例: 25:30:00)で表す
+-- NOTE: 24:00未満で運行日の開始時刻(SERVICE_DAY_START)より前の時刻は、前日の運行日の時刻として扱う
+-- pickup_onlyは乗車のみ(降車不可)、drop_off_onlyは降車のみ(乗車不可)の停車
+-- platformは発着番線(未定の場合はNULL)
+CREATE TABLE `stop_times` (
+  `train_id` int unsigned NOT NULL,
+  `stop_sequence` int unsigned NOT NULL,
+  `station_id` int unsigned NOT NULL,
+  `arr_time` time DEFAULT NULL,
+  `dep_time` time DEFAULT NULL,
+  `pickup_only` tinyint(1) NOT NULL DEFAULT '0',
+  `drop_off_only` tinyint(1) NOT NULL DEFAULT '0',
+  `platform` varchar(8) DEFAULT NULL,
+  PRIMARY KEY (`train_id`,`stop_sequence`),
+  KEY `stop_times_stations_FK` (`station_id`),
+  CONSTRAINT `stop_times_stations_FK` FOREIGN KEY (`station_id`) REFERENCES `stations` (`id`),
+  CONSTRAINT `stop_times_trains_FK` FOREIGN KEY (`train_id`) REFERENCES `trains` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- 各区間の発駅を、区間の順序(op_order)を停車順として登録する(到着時刻は前の区間の到着時刻)
+INSERT INTO `stop_times` (`train_id`, `stop_sequence`, `station_id`, `arr_time`, `dep_time`)
+SELECT o.`train_id`, o.`op_order`, o.`dep_sta_id`, prev.`arr_time`, o.`dep_time`
+FROM `operations` o
+LEFT JOIN `operations` prev ON prev.`train_id` = o.`train_id` AND prev.`op_order` + 1 = o.`op_order`;
+
+-- 最後の区間の着駅を、終着駅として登録する
+INSERT INTO `stop_times` (`train_id`, `stop_sequence`, `station_id`, `arr_time`, `dep_time`)
+SELECT o.`train_id`, o.`op_order` + 1, o.`arr_sta_id`, o.`arr_time`, NULL
+FROM `operations` o
+WHERE NOT EXISTS (
+  SELECT * FROM `operations` n WHERE n.`train_id` = o.`train_id` AND n.`op_order` = o.`op_order` + 1
+);
+
+DROP TABLE `operations`;
+
+-- operationsビュー
+-- stop_timesの隣り合う停車駅の組から、列車での1区間移動を導出する(op_orderは1からの連番)
+-- network_idは列車の所属する鉄道網
+CREATE VIEW `operations` AS
+SELECT network_id, train_id, op_order, dep_sta_id, dep_time, arr_sta_id, arr_time, dep_drop_off_only, arr_pickup_only, dep_platform, arr_platform
+FROM (
+  SELECT
+    t.network_id,
+    train_id,
+    ROW_NUMBER() OVER (PARTITION BY train_id ORDER BY stop_sequence) AS op_order,
+    station_id AS dep_sta_id,
+    COALESCE(dep_time, arr_time) AS dep_time,
+    LEAD(station_id) OVER (PARTITION BY train_id ORDER BY stop_sequence) AS arr_sta_id,
+    COALESCE(
+      LEAD(arr_time) OVER (PARTITION BY train_id ORDER BY stop_sequence),
+      LEAD(dep_time) OVER (PARTITION BY train_id ORDER BY stop_sequence)
+    ) AS arr_time,
+    drop_off_only AS dep_drop_off_only,
+    LEAD(pickup_only) OVER (PARTITION BY train_id ORDER BY stop_sequence) AS arr_pickup_only,
+    platform AS dep_platform,
+    LEAD(platform) OVER (PARTITION BY train_id ORDER BY stop_sequence) AS arr_platform
+  FROM stop_times
+  INNER JOIN trains t ON t.id = stop_times.train_id
+) o
+WHERE arr_sta_id IS NOT NULL;
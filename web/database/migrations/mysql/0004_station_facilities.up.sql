@@ -0,0 +1,11 @@
+-- 駅の位置・設備の追加
+
+-- stationsテーブル
+-- lat/lonは駅の緯度・経度(未設定の場合はNULL)
+-- elevatorはエレベーター、step_freeは段差なしでのホームへの移動、accessible_toiletは多機能トイレの有無
+ALTER TABLE `stations`
+  ADD COLUMN `lat` decimal(9,6) DEFAULT NULL AFTER `name_en`,
+  ADD COLUMN `lon` decimal(9,6) DEFAULT NULL AFTER `lat`,
+  ADD COLUMN `elevator` tinyint(1) NOT NULL DEFAULT '0' AFTER `lon`,
+  ADD COLUMN `step_free` tinyint(1) NOT NULL DEFAULT '0' AFTER `elevator`,
+  ADD COLUMN `accessible_toilet` tinyint(1) NOT NULL DEFAULT '0' AFTER `step_free`;
@@ -0,0 +1,25 @@
+-- 列車種別・事業者・路線・運行パターンの削除
+
+-- trainsテーブル
+ALTER TABLE `trains`
+  DROP FOREIGN KEY `trains_train_types_FK`,
+  DROP FOREIGN KEY `trains_lines_FK`,
+  DROP FOREIGN KEY `trains_service_patterns_FK`,
+  DROP FOREIGN KEY `trains_agencies_FK`;
+ALTER TABLE `trains`
+  DROP KEY `trains_train_types_FK`,
+  DROP KEY `trains_lines_FK`,
+  DROP KEY `trains_service_patterns_FK`,
+  DROP KEY `trains_agencies_FK`,
+  DROP COLUMN `agency_id`,
+  DROP COLUMN `pattern_id`,
+  DROP COLUMN `line_id`,
+  DROP COLUMN `type_id`;
+
+DROP TABLE `line_stations`;
+DROP TABLE `service_frequencies`;
+DROP TABLE `service_pattern_stops`;
+DROP TABLE `service_patterns`;
+DROP TABLE `lines`;
+DROP TABLE `agencies`;
+DROP TABLE `train_types`;
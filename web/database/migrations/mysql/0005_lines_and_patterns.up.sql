@@ -0,0 +1,122 @@
+-- 列車種別・事業者・路線・運行パターンの追加
+-- 既存の列車は、種別・路線・運行パターン・事業者が未設定(NULL)となる
+
+-- train_typesテーブル
+-- 列車種別(普通・快速など)。colorは運行図表の描画色(#RRGGBB)
+CREATE TABLE `train_types` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `network_id` int unsigned NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `name_en` varchar(100) NOT NULL,
+  `color` char(7) NOT NULL DEFAULT '#333333',
+  PRIMARY KEY (`id`),
+  KEY `train_types_networks_FK` (`network_id`),
+  CONSTRAINT `train_types_networks_FK` FOREIGN KEY (`network_id`) REFERENCES `networks` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- agenciesテーブル
+-- 鉄道事業者(運行会社)。fare_systemは運賃体系の識別子(同じ値の事業者間は運賃を通算する想定)、colorは表示色(#RRGGBB)
+CREATE TABLE `agencies` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `network_id` int unsigned NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `name_en` varchar(100) NOT NULL,
+  `url` varchar(255) NOT NULL DEFAULT '',
+  `fare_system` varchar(100) NOT NULL DEFAULT '',
+  `color` char(7) NOT NULL DEFAULT '#333333',
+  PRIMARY KEY (`id`),
+  KEY `agencies_networks_FK` (`network_id`),
+  CONSTRAINT `agencies_networks_FK` FOREIGN KEY (`network_id`) REFERENCES `networks` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- linesテーブル
+-- agency_idは路線を運行する事業者(列車の事業者が未設定の場合に用いる)
+CREATE TABLE `lines` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `network_id` int unsigned NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `name_en` varchar(100) NOT NULL,
+  `agency_id` int unsigned DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `lines_networks_FK` (`network_id`),
+  KEY `lines_agencies_FK` (`agency_id`),
+  CONSTRAINT `lines_networks_FK` FOREIGN KEY (`network_id`) REFERENCES `networks` (`id`),
+  CONSTRAINT `lines_agencies_FK` FOREIGN KEY (`agency_id`) REFERENCES `agencies` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- service_patternsテーブル
+-- 運行パターン(停車駅・駅間所要時間・運転間隔)。生成した列車の名前は train_name_prefix + 始発時刻(HHMM)
+CREATE TABLE `service_patterns` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `network_id` int unsigned NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `train_name_prefix` varchar(50) NOT NULL DEFAULT '',
+  `type_id` int unsigned DEFAULT NULL,
+  `line_id` int unsigned DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `service_patterns_networks_FK` (`network_id`),
+  KEY `service_patterns_train_types_FK` (`type_id`),
+  KEY `service_patterns_lines_FK` (`line_id`),
+  CONSTRAINT `service_patterns_networks_FK` FOREIGN KEY (`network_id`) REFERENCES `networks` (`id`),
+  CONSTRAINT `service_patterns_train_types_FK` FOREIGN KEY (`type_id`) REFERENCES `train_types` (`id`),
+  CONSTRAINT `service_patterns_lines_FK` FOREIGN KEY (`line_id`) REFERENCES `lines` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- service_pattern_stopsテーブル
+-- run_secondsは前停車駅の発車から当駅到着までの所要時間(始発駅は0)、dwell_secondsは当駅の停車時間
+-- platformは生成する列車の発着番線(未定の場合はNULL)
+CREATE TABLE `service_pattern_stops` (
+  `pattern_id` int unsigned NOT NULL,
+  `sequence` int unsigned NOT NULL,
+  `station_id` int unsigned NOT NULL,
+  `run_seconds` int unsigned NOT NULL DEFAULT '0',
+  `dwell_seconds` int unsigned NOT NULL DEFAULT '0',
+  `platform` varchar(8) DEFAULT NULL,
+  PRIMARY KEY (`pattern_id`,`sequence`),
+  KEY `service_pattern_stops_stations_FK` (`station_id`),
+  CONSTRAINT `service_pattern_stops_service_patterns_FK` FOREIGN KEY (`pattern_id`) REFERENCES `service_patterns` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `service_pattern_stops_stations_FK` FOREIGN KEY (`station_id`) REFERENCES `stations` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- service_frequenciesテーブル
+-- start_time以降、end_time未満の間、headway_seconds間隔で始発駅を発車する(GTFSのfrequencies.txtに相当)
+CREATE TABLE `service_frequencies` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `pattern_id` int unsigned NOT NULL,
+  `start_time` time NOT NULL,
+  `end_time` time NOT NULL,
+  `headway_seconds` int unsigned NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `service_frequencies_service_patterns_FK` (`pattern_id`),
+  CONSTRAINT `service_frequencies_service_patterns_FK` FOREIGN KEY (`pattern_id`) REFERENCES `service_patterns` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- line_stationsテーブル
+-- 路線を構成する駅と、その順序(sequence)・起点からの距離(km)
+CREATE TABLE `line_stations` (
+  `line_id` int unsigned NOT NULL,
+  `station_id` int unsigned NOT NULL,
+  `sequence` int unsigned NOT NULL,
+  `km` decimal(7,2) NOT NULL DEFAULT '0.00',
+  PRIMARY KEY (`line_id`,`sequence`),
+  UNIQUE KEY `line_stations_unique` (`line_id`,`station_id`),
+  KEY `line_stations_stations_FK` (`station_id`),
+  CONSTRAINT `line_stations_lines_FK` FOREIGN KEY (`line_id`) REFERENCES `lines` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `line_stations_stations_FK` FOREIGN KEY (`station_id`) REFERENCES `stations` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- trainsテーブル
+-- agency_idは列車を運行する事業者(NULLの場合は所属路線の事業者)
+ALTER TABLE `trains`
+  ADD COLUMN `type_id` int unsigned DEFAULT NULL AFTER `name`,
+  ADD COLUMN `line_id` int unsigned DEFAULT NULL AFTER `type_id`,
+  ADD COLUMN `pattern_id` int unsigned DEFAULT NULL AFTER `line_id`,
+  ADD COLUMN `agency_id` int unsigned DEFAULT NULL AFTER `pattern_id`,
+  ADD KEY `trains_train_types_FK` (`type_id`),
+  ADD KEY `trains_lines_FK` (`line_id`),
+  ADD KEY `trains_service_patterns_FK` (`pattern_id`),
+  ADD KEY `trains_agencies_FK` (`agency_id`),
+  ADD CONSTRAINT `trains_train_types_FK` FOREIGN KEY (`type_id`) REFERENCES `train_types` (`id`),
+  ADD CONSTRAINT `trains_lines_FK` FOREIGN KEY (`line_id`) REFERENCES `lines` (`id`) ON DELETE SET NULL,
+  ADD CONSTRAINT `trains_service_patterns_FK` FOREIGN KEY (`pattern_id`) REFERENCES `service_patterns` (`id`),
+  ADD CONSTRAINT `trains_agencies_FK` FOREIGN KEY (`agency_id`) REFERENCES `agencies` (`id`) ON DELETE SET NULL;
@@ -0,0 +1,8 @@
+-- 運休・運転見合わせ・お知らせ・線路設備・番線間の乗換・列車の直通の削除
+DROP TABLE `train_relations`;
+DROP TABLE `track_segments`;
+DROP TABLE `service_alert_entities`;
+DROP TABLE `service_alerts`;
+DROP TABLE `segment_suspensions`;
+DROP TABLE `train_cancellations`;
+DROP TABLE `platform_transfers`;
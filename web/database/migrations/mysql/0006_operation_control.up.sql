@@ -0,0 +1,95 @@
+-- 運休・運転見合わせ・お知らせ・線路設備・番線間の乗換・列車の直通の追加
+
+-- platform_transfersテーブル
+-- 駅構内での番線間(from_platform -> to_platform)の乗換に必要な時間
+-- 番線が空文字のものは任意の番線(番線未定を含む)に適用し、番線が一致するものを優先する。登録の無い乗換は0秒とする
+-- step_freeは段差なしで乗り換えられるか、wheelchair_transfer_secondsは車いす利用時の乗換時間(NULLの場合はtransfer_secondsと同じ)
+CREATE TABLE `platform_transfers` (
+  `station_id` int unsigned NOT NULL,
+  `from_platform` varchar(8) NOT NULL DEFAULT '',
+  `to_platform` varchar(8) NOT NULL DEFAULT '',
+  `transfer_seconds` int unsigned NOT NULL,
+  `step_free` tinyint(1) NOT NULL DEFAULT '1',
+  `wheelchair_transfer_seconds` int unsigned DEFAULT NULL,
+  PRIMARY KEY (`station_id`,`from_platform`,`to_platform`),
+  CONSTRAINT `platform_transfers_stations_FK` FOREIGN KEY (`station_id`) REFERENCES `stations` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- train_cancellationsテーブル
+CREATE TABLE `train_cancellations` (
+  `train_id` int unsigned NOT NULL,
+  `service_date` date NOT NULL,
+  `reason` varchar(255) NOT NULL DEFAULT '',
+  PRIMARY KEY (`train_id`,`service_date`),
+  CONSTRAINT `train_cancellations_trains_FK` FOREIGN KEY (`train_id`) REFERENCES `trains` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- segment_suspensionsテーブル
+-- 駅間(sta_id_a - sta_id_b)の運転見合わせ。上下線の両方に適用する
+CREATE TABLE `segment_suspensions` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `sta_id_a` int unsigned NOT NULL,
+  `sta_id_b` int unsigned NOT NULL,
+  `start_datetime` datetime NOT NULL,
+  `end_datetime` datetime NOT NULL,
+  `reason` varchar(255) NOT NULL DEFAULT '',
+  PRIMARY KEY (`id`),
+  KEY `segment_suspensions_stations_FK` (`sta_id_a`),
+  KEY `segment_suspensions_stations_FK_1` (`sta_id_b`),
+  CONSTRAINT `segment_suspensions_stations_FK` FOREIGN KEY (`sta_id_a`) REFERENCES `stations` (`id`),
+  CONSTRAINT `segment_suspensions_stations_FK_1` FOREIGN KEY (`sta_id_b`) REFERENCES `stations` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- service_alertsテーブル
+-- active_from/active_untilがNULLの場合は、期間の制限なし
+CREATE TABLE `service_alerts` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `network_id` int unsigned NOT NULL,
+  `severity` enum('info','warning','severe') NOT NULL DEFAULT 'info',
+  `header` varchar(255) NOT NULL,
+  `header_en` varchar(255) NOT NULL DEFAULT '',
+  `description` text NOT NULL,
+  `description_en` text NOT NULL,
+  `active_from` datetime DEFAULT NULL,
+  `active_until` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `service_alerts_networks_FK` (`network_id`),
+  CONSTRAINT `service_alerts_networks_FK` FOREIGN KEY (`network_id`) REFERENCES `networks` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- service_alert_entitiesテーブル
+-- お知らせの影響対象(駅・列車・路線)
+CREATE TABLE `service_alert_entities` (
+  `alert_id` int unsigned NOT NULL,
+  `entity_type` enum('station','train','line') NOT NULL,
+  `entity_id` int unsigned NOT NULL,
+  PRIMARY KEY (`alert_id`,`entity_type`,`entity_id`),
+  CONSTRAINT `service_alert_entities_service_alerts_FK` FOREIGN KEY (`alert_id`) REFERENCES `service_alerts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- track_segmentsテーブル
+-- 駅間(sta_id_a - sta_id_b)の線路設備。登録の無い駅間は複線として扱う
+CREATE TABLE `track_segments` (
+  `sta_id_a` int unsigned NOT NULL,
+  `sta_id_b` int unsigned NOT NULL,
+  `single_track` tinyint(1) NOT NULL DEFAULT '0',
+  PRIMARY KEY (`sta_id_a`,`sta_id_b`),
+  KEY `track_segments_stations_FK_1` (`sta_id_b`),
+  CONSTRAINT `track_segments_stations_FK` FOREIGN KEY (`sta_id_a`) REFERENCES `stations` (`id`),
+  CONSTRAINT `track_segments_stations_FK_1` FOREIGN KEY (`sta_id_b`) REFERENCES `stations` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- train_relationsテーブル
+-- 直通(through)・分割(split)・併合(join)により、from_train_idの車両がstation_idからto_train_idとして運行を続ける
+CREATE TABLE `train_relations` (
+  `from_train_id` int unsigned NOT NULL,
+  `to_train_id` int unsigned NOT NULL,
+  `station_id` int unsigned NOT NULL,
+  `relation_type` enum('through','split','join') NOT NULL,
+  PRIMARY KEY (`from_train_id`,`to_train_id`,`station_id`),
+  KEY `train_relations_trains_FK_1` (`to_train_id`),
+  KEY `train_relations_stations_FK` (`station_id`),
+  CONSTRAINT `train_relations_trains_FK` FOREIGN KEY (`from_train_id`) REFERENCES `trains` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `train_relations_trains_FK_1` FOREIGN KEY (`to_train_id`) REFERENCES `trains` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `train_relations_stations_FK` FOREIGN KEY (`station_id`) REFERENCES `stations` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,20 @@
+-- お知らせの文言を、service_alertsの日本語・英語の列に戻す(日本語・英語以外の文言は削除される)
+-- NOTE: TEXT型の列は既定値を持てないため、NULL可で追加して値を移した後にNOT NULLへ変更する
+
+-- service_alertsテーブル
+ALTER TABLE `service_alerts`
+  ADD COLUMN `header` varchar(255) NOT NULL DEFAULT '' AFTER `severity`,
+  ADD COLUMN `header_en` varchar(255) NOT NULL DEFAULT '' AFTER `header`,
+  ADD COLUMN `description` text AFTER `header_en`,
+  ADD COLUMN `description_en` text AFTER `description`;
+UPDATE `service_alerts` a
+LEFT JOIN `service_alert_texts` ja ON ja.`alert_id` = a.`id` AND ja.`locale` = 'ja'
+LEFT JOIN `service_alert_texts` en ON en.`alert_id` = a.`id` AND en.`locale` = 'en'
+SET a.`header` = COALESCE(ja.`header`, ''), a.`header_en` = COALESCE(en.`header`, ''),
+  a.`description` = COALESCE(ja.`description`, ''), a.`description_en` = COALESCE(en.`description`, '');
+ALTER TABLE `service_alerts`
+  MODIFY `header` varchar(255) NOT NULL,
+  MODIFY `description` text NOT NULL,
+  MODIFY `description_en` text NOT NULL;
+
+DROP TABLE `service_alert_texts`;
@@ -0,0 +1,23 @@
+-- お知らせの文言を、言語(ロケール)ごとにservice_alert_textsへ保持する
+-- 既存の文言は、日本語(ja)と英語(en、空の場合は除く)として移行する
+
+-- service_alert_textsテーブル
+CREATE TABLE `service_alert_texts` (
+  `alert_id` int unsigned NOT NULL,
+  `locale` varchar(16) NOT NULL,
+  `header` varchar(255) NOT NULL,
+  `description` text NOT NULL,
+  PRIMARY KEY (`alert_id`,`locale`),
+  CONSTRAINT `service_alert_texts_service_alerts_FK` FOREIGN KEY (`alert_id`) REFERENCES `service_alerts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+INSERT INTO `service_alert_texts` (`alert_id`, `locale`, `header`, `description`)
+SELECT `id`, 'ja', `header`, `description` FROM `service_alerts`;
+INSERT INTO `service_alert_texts` (`alert_id`, `locale`, `header`, `description`)
+SELECT `id`, 'en', `header_en`, `description_en` FROM `service_alerts` WHERE `header_en` <> '' OR `description_en` <> '';
+
+-- service_alertsテーブル
+ALTER TABLE `service_alerts`
+  DROP COLUMN `header`,
+  DROP COLUMN `header_en`,
+  DROP COLUMN `description`,
+  DROP COLUMN `description_en`;
@@ -0,0 +1,36 @@
+-- 初期スキーマ(PostgreSQL)
+-- NOTE: 時刻は、0:00からの経過時間としてinterval型(service_timeドメイン)で保存する(24:00以降も可)
+-- NOTE: idは自動採番のため、明示的にidを指定して登録した場合はsetvalで採番を進めること
+-- NOTE: 最初のdb/initdb.d/init.sql(MySQL)と同じ構成
+
+-- 0:00からの時刻('HH:MM:SS'形式で入出力できるよう、日・月の単位と秒未満を含まない値に限る)
+CREATE DOMAIN service_time AS interval
+  CHECK (
+    VALUE >= INTERVAL '0'
+    AND EXTRACT(YEAR FROM VALUE) = 0 AND EXTRACT(MONTH FROM VALUE) = 0 AND EXTRACT(DAY FROM VALUE) = 0
+    AND date_trunc('second', VALUE) = VALUE
+  );
+
+-- stationsテーブル
+CREATE TABLE stations (
+  id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  name VARCHAR(100) NOT NULL,
+  name_en VARCHAR(100) NOT NULL
+);
+
+-- trainsテーブル
+CREATE TABLE trains (
+  id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  name VARCHAR(100) DEFAULT NULL UNIQUE
+);
+
+-- operationsテーブル
+CREATE TABLE operations (
+  train_id INTEGER NOT NULL REFERENCES trains (id),
+  op_order INTEGER NOT NULL,
+  dep_sta_id INTEGER NOT NULL REFERENCES stations (id),
+  dep_time service_time NOT NULL,
+  arr_sta_id INTEGER NOT NULL REFERENCES stations (id),
+  arr_time service_time NOT NULL,
+  PRIMARY KEY (train_id, op_order)
+);
@@ -0,0 +1,12 @@
+-- 鉄道網(networks)の削除
+-- NOTE: 複数の鉄道網に同名の列車がある場合は、列車名の一意制約に違反するため取り消せない
+
+-- trainsテーブル(network_idを含む一意制約・外部キーも削除される)
+ALTER TABLE trains DROP COLUMN network_id;
+ALTER TABLE trains ADD UNIQUE (name);
+
+-- stationsテーブル
+ALTER TABLE stations DROP COLUMN network_id;
+
+-- networksテーブル
+DROP TABLE networks;
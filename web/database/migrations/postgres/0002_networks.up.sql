@@ -0,0 +1,26 @@
+-- 鉄道網(networks)の追加
+-- 既存の駅・列車は、既定の鉄道網(id=1)に所属させる
+
+-- networksテーブル
+CREATE TABLE networks (
+  id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  code VARCHAR(32) NOT NULL UNIQUE,
+  name VARCHAR(100) NOT NULL,
+  time_zone VARCHAR(64) NOT NULL DEFAULT 'Asia/Tokyo',
+  admin_user VARCHAR(100) DEFAULT NULL,
+  admin_password_hash VARCHAR(255) DEFAULT NULL
+);
+
+INSERT INTO networks (code, name, time_zone) VALUES ('default', 'default', 'Asia/Tokyo');
+
+-- stationsテーブル
+ALTER TABLE stations ADD COLUMN network_id INTEGER NOT NULL DEFAULT 1 REFERENCES networks (id);
+ALTER TABLE stations ALTER COLUMN network_id DROP DEFAULT;
+CREATE INDEX stations_networks_FK ON stations (network_id);
+
+-- trainsテーブル
+-- 列車名は鉄道網ごとに一意
+ALTER TABLE trains ADD COLUMN network_id INTEGER NOT NULL DEFAULT 1 REFERENCES networks (id);
+ALTER TABLE trains ALTER COLUMN network_id DROP DEFAULT;
+ALTER TABLE trains DROP CONSTRAINT trains_name_key;
+ALTER TABLE trains ADD UNIQUE (network_id, name);
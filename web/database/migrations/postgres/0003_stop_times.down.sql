@@ -0,0 +1,20 @@
+-- operationsビューをテーブルに戻す
+-- NOTE: 停車駅ごとの乗降制限・番線(pickup_only・drop_off_only・platform)は失われる
+
+CREATE TABLE operations_table (
+  train_id INTEGER NOT NULL REFERENCES trains (id),
+  op_order INTEGER NOT NULL,
+  dep_sta_id INTEGER NOT NULL REFERENCES stations (id),
+  dep_time service_time NOT NULL,
+  arr_sta_id INTEGER NOT NULL REFERENCES stations (id),
+  arr_time service_time NOT NULL,
+  PRIMARY KEY (train_id, op_order)
+);
+
+INSERT INTO operations_table (train_id, op_order, dep_sta_id, dep_time, arr_sta_id, arr_time)
+SELECT train_id, op_order, dep_sta_id, dep_time, arr_sta_id, arr_time
+FROM operations;
+
+DROP VIEW operations;
+ALTER TABLE operations_table RENAME TO operations;
+DROP TABLE stop_times;
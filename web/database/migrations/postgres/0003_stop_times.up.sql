@@ -0,0 +1,56 @@
+-- 時刻表を停車駅ごとの時刻(stop_times)で管理する
+-- 既存のoperationsテーブル(区間ごとの時刻)の内容をstop_timesに移し、operationsは同じ列を持つビューに置き換える
+
+-- stop_timesテーブル
+CREATE TABLE stop_times (
+  train_id INTEGER NOT NULL REFERENCES trains (id),
+  stop_sequence INTEGER NOT NULL,
+  station_id INTEGER NOT NULL REFERENCES stations (id),
+  arr_time service_time DEFAULT NULL,
+  dep_time service_time DEFAULT NULL,
+  pickup_only SMALLINT NOT NULL DEFAULT 0,
+  drop_off_only SMALLINT NOT NULL DEFAULT 0,
+  platform VARCHAR(8) DEFAULT NULL,
+  PRIMARY KEY (train_id, stop_sequence)
+);
+CREATE INDEX stop_times_stations_FK ON stop_times (station_id);
+
+-- 各区間の発駅を、区間の順序(op_order)を停車順として登録する(到着時刻は前の区間の到着時刻)
+INSERT INTO stop_times (train_id, stop_sequence, station_id, arr_time, dep_time)
+SELECT o.train_id, o.op_order, o.dep_sta_id, prev.arr_time, o.dep_time
+FROM operations o
+LEFT JOIN operations prev ON prev.train_id = o.train_id AND prev.op_order + 1 = o.op_order;
+
+-- 最後の区間の着駅を、終着駅として登録する
+INSERT INTO stop_times (train_id, stop_sequence, station_id, arr_time, dep_time)
+SELECT o.train_id, o.op_order + 1, o.arr_sta_id, o.arr_time, NULL
+FROM operations o
+WHERE NOT EXISTS (
+  SELECT * FROM operations n WHERE n.train_id = o.train_id AND n.op_order = o.op_order + 1
+);
+
+DROP TABLE operations;
+
+-- operationsビュー
+CREATE VIEW operations AS
+SELECT network_id, train_id, op_order, dep_sta_id, dep_time, arr_sta_id, arr_time, dep_drop_off_only, arr_pickup_only, dep_platform, arr_platform
+FROM (
+  SELECT
+    t.network_id,
+    train_id,
+    ROW_NUMBER() OVER (PARTITION BY train_id ORDER BY stop_sequence) AS op_order,
+    station_id AS dep_sta_id,
+    COALESCE(dep_time, arr_time) AS dep_time,
+    LEAD(station_id) OVER (PARTITION BY train_id ORDER BY stop_sequence) AS arr_sta_id,
+    COALESCE(
+      LEAD(arr_time) OVER (PARTITION BY train_id ORDER BY stop_sequence),
+      LEAD(dep_time) OVER (PARTITION BY train_id ORDER BY stop_sequence)
+    ) AS arr_time,
+    drop_off_only AS dep_drop_off_only,
+    LEAD(pickup_only) OVER (PARTITION BY train_id ORDER BY stop_sequence) AS arr_pickup_only,
+    platform AS dep_platform,
+    LEAD(platform) OVER (PARTITION BY train_id ORDER BY stop_sequence) AS arr_platform
+  FROM stop_times
+  INNER JOIN trains t ON t.id = stop_times.train_id
+) o
+WHERE arr_sta_id IS NOT NULL;
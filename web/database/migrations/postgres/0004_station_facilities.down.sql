@@ -0,0 +1,9 @@
+-- 駅の位置・設備の削除
+
+-- stationsテーブル
+ALTER TABLE stations
+  DROP COLUMN accessible_toilet,
+  DROP COLUMN step_free,
+  DROP COLUMN elevator,
+  DROP COLUMN lon,
+  DROP COLUMN lat;
@@ -0,0 +1,9 @@
+-- 駅の位置・設備の追加
+
+-- stationsテーブル
+ALTER TABLE stations
+  ADD COLUMN lat NUMERIC(9, 6) DEFAULT NULL,
+  ADD COLUMN lon NUMERIC(9, 6) DEFAULT NULL,
+  ADD COLUMN elevator SMALLINT NOT NULL DEFAULT 0,
+  ADD COLUMN step_free SMALLINT NOT NULL DEFAULT 0,
+  ADD COLUMN accessible_toilet SMALLINT NOT NULL DEFAULT 0;
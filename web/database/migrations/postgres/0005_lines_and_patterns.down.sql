@@ -0,0 +1,16 @@
+-- 列車種別・事業者・路線・運行パターンの削除
+
+-- trainsテーブル(外部キーも削除される)
+ALTER TABLE trains
+  DROP COLUMN agency_id,
+  DROP COLUMN pattern_id,
+  DROP COLUMN line_id,
+  DROP COLUMN type_id;
+
+DROP TABLE line_stations;
+DROP TABLE service_frequencies;
+DROP TABLE service_pattern_stops;
+DROP TABLE service_patterns;
+DROP TABLE lines;
+DROP TABLE agencies;
+DROP TABLE train_types;
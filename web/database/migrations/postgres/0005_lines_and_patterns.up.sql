@@ -0,0 +1,78 @@
+-- 列車種別・事業者・路線・運行パターンの追加
+-- 既存の列車は、種別・路線・運行パターン・事業者が未設定(NULL)となる
+
+-- train_typesテーブル
+CREATE TABLE train_types (
+  id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  name VARCHAR(100) NOT NULL,
+  name_en VARCHAR(100) NOT NULL,
+  color CHAR(7) NOT NULL DEFAULT '#333333'
+);
+
+-- agenciesテーブル
+CREATE TABLE agencies (
+  id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  name VARCHAR(100) NOT NULL,
+  name_en VARCHAR(100) NOT NULL,
+  url VARCHAR(255) NOT NULL DEFAULT '',
+  fare_system VARCHAR(100) NOT NULL DEFAULT '',
+  color CHAR(7) NOT NULL DEFAULT '#333333'
+);
+
+-- linesテーブル
+CREATE TABLE lines (
+  id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  name VARCHAR(100) NOT NULL,
+  name_en VARCHAR(100) NOT NULL,
+  agency_id INTEGER DEFAULT NULL REFERENCES agencies (id) ON DELETE SET NULL
+);
+
+-- service_patternsテーブル
+CREATE TABLE service_patterns (
+  id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  name VARCHAR(100) NOT NULL,
+  train_name_prefix VARCHAR(50) NOT NULL DEFAULT '',
+  type_id INTEGER DEFAULT NULL REFERENCES train_types (id),
+  line_id INTEGER DEFAULT NULL REFERENCES lines (id) ON DELETE SET NULL
+);
+
+-- service_pattern_stopsテーブル
+CREATE TABLE service_pattern_stops (
+  pattern_id INTEGER NOT NULL REFERENCES service_patterns (id) ON DELETE CASCADE,
+  sequence INTEGER NOT NULL,
+  station_id INTEGER NOT NULL REFERENCES stations (id),
+  run_seconds INTEGER NOT NULL DEFAULT 0,
+  dwell_seconds INTEGER NOT NULL DEFAULT 0,
+  platform VARCHAR(8) DEFAULT NULL,
+  PRIMARY KEY (pattern_id, sequence)
+);
+
+-- service_frequenciesテーブル
+CREATE TABLE service_frequencies (
+  id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  pattern_id INTEGER NOT NULL REFERENCES service_patterns (id) ON DELETE CASCADE,
+  start_time service_time NOT NULL,
+  end_time service_time NOT NULL,
+  headway_seconds INTEGER NOT NULL
+);
+
+-- line_stationsテーブル
+CREATE TABLE line_stations (
+  line_id INTEGER NOT NULL REFERENCES lines (id) ON DELETE CASCADE,
+  station_id INTEGER NOT NULL REFERENCES stations (id),
+  sequence INTEGER NOT NULL,
+  km NUMERIC(7, 2) NOT NULL DEFAULT 0,
+  PRIMARY KEY (line_id, sequence),
+  UNIQUE (line_id, station_id)
+);
+
+-- trainsテーブル
+ALTER TABLE trains
+  ADD COLUMN type_id INTEGER DEFAULT NULL REFERENCES train_types (id),
+  ADD COLUMN line_id INTEGER DEFAULT NULL REFERENCES lines (id) ON DELETE SET NULL,
+  ADD COLUMN pattern_id INTEGER DEFAULT NULL REFERENCES service_patterns (id),
+  ADD COLUMN agency_id INTEGER DEFAULT NULL REFERENCES agencies (id) ON DELETE SET NULL;
@@ -0,0 +1,18 @@
+-- お知らせの文言を、service_alertsの日本語・英語の列に戻す(日本語・英語以外の文言は削除される)
+
+-- service_alertsテーブル
+ALTER TABLE service_alerts
+  ADD COLUMN header VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN header_en VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN description TEXT NOT NULL DEFAULT '',
+  ADD COLUMN description_en TEXT NOT NULL DEFAULT '';
+UPDATE service_alerts a SET header = t.header, description = t.description
+FROM service_alert_texts t WHERE t.alert_id = a.id AND t.locale = 'ja';
+UPDATE service_alerts a SET header_en = t.header, description_en = t.description
+FROM service_alert_texts t WHERE t.alert_id = a.id AND t.locale = 'en';
+ALTER TABLE service_alerts
+  ALTER COLUMN header DROP DEFAULT,
+  ALTER COLUMN description DROP DEFAULT,
+  ALTER COLUMN description_en DROP DEFAULT;
+
+DROP TABLE service_alert_texts;
@@ -0,0 +1,22 @@
+-- お知らせの文言を、言語(ロケール)ごとにservice_alert_textsへ保持する
+-- 既存の文言は、日本語(ja)と英語(en、空の場合は除く)として移行する
+
+-- service_alert_textsテーブル
+CREATE TABLE service_alert_texts (
+  alert_id INTEGER NOT NULL REFERENCES service_alerts (id) ON DELETE CASCADE,
+  locale VARCHAR(16) NOT NULL,
+  header VARCHAR(255) NOT NULL,
+  description TEXT NOT NULL,
+  PRIMARY KEY (alert_id, locale)
+);
+INSERT INTO service_alert_texts (alert_id, locale, header, description)
+SELECT id, 'ja', header, description FROM service_alerts;
+INSERT INTO service_alert_texts (alert_id, locale, header, description)
+SELECT id, 'en', header_en, description_en FROM service_alerts WHERE header_en <> '' OR description_en <> '';
+
+-- service_alertsテーブル
+ALTER TABLE service_alerts
+  DROP COLUMN header,
+  DROP COLUMN header_en,
+  DROP COLUMN description,
+  DROP COLUMN description_en;
@@ -0,0 +1,4 @@
+-- 駅ごとの発車の検索用インデックスの削除
+
+-- stop_timesテーブル
+DROP INDEX stop_times_stations_dep_time;
@@ -0,0 +1,5 @@
+-- 駅ごとの発車の検索用インデックスの追加
+-- NOTE: 経路探索・走行位置では、operationsビュー(全列車の停車駅から区間を導出する)を介さずstop_timesを直接参照する
+
+-- stop_timesテーブル
+CREATE INDEX stop_times_stations_dep_time ON stop_times (station_id, dep_time);
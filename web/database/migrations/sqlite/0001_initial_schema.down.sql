@@ -0,0 +1,4 @@
+-- 初期スキーマの削除(全データが失われる)
+DROP TABLE operations;
+DROP TABLE trains;
+DROP TABLE stations;
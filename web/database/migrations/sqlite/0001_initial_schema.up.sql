@@ -0,0 +1,28 @@
+-- 初期スキーマ(SQLite)
+-- NOTE: SQLiteには時刻型が無いため、時刻は'HH:MM:SS'形式の文字列(24:00以降も可)で保存する
+-- NOTE: 真偽値は0/1の整数、列挙型はCHECK制約で表す
+-- NOTE: 最初のdb/initdb.d/init.sql(MySQL)と同じ構成
+
+-- stationsテーブル
+CREATE TABLE stations (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL,
+  name_en TEXT NOT NULL
+);
+
+-- trainsテーブル
+CREATE TABLE trains (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT DEFAULT NULL UNIQUE
+);
+
+-- operationsテーブル
+CREATE TABLE operations (
+  train_id INTEGER NOT NULL REFERENCES trains (id),
+  op_order INTEGER NOT NULL,
+  dep_sta_id INTEGER NOT NULL REFERENCES stations (id),
+  dep_time TEXT NOT NULL CHECK (dep_time GLOB '[0-9][0-9]:[0-5][0-9]:[0-5][0-9]'),
+  arr_sta_id INTEGER NOT NULL REFERENCES stations (id),
+  arr_time TEXT NOT NULL CHECK (arr_time GLOB '[0-9][0-9]:[0-5][0-9]:[0-5][0-9]'),
+  PRIMARY KEY (train_id, op_order)
+);
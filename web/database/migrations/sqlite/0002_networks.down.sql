@@ -0,0 +1,24 @@
+-- 鉄道網(networks)の削除
+-- NOTE: 複数の鉄道網に同名の列車がある場合は、列車名の一意制約に違反するため取り消せない
+
+-- trainsテーブル
+CREATE TABLE trains_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT DEFAULT NULL UNIQUE
+);
+INSERT INTO trains_old (id, name) SELECT id, name FROM trains;
+DROP TABLE trains;
+ALTER TABLE trains_old RENAME TO trains;
+
+-- stationsテーブル
+CREATE TABLE stations_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL,
+  name_en TEXT NOT NULL
+);
+INSERT INTO stations_old (id, name, name_en) SELECT id, name, name_en FROM stations;
+DROP TABLE stations;
+ALTER TABLE stations_old RENAME TO stations;
+
+-- networksテーブル
+DROP TABLE networks;
@@ -0,0 +1,39 @@
+-- 鉄道網(networks)の追加
+-- 既存の駅・列車は、既定の鉄道網(id=1)に所属させる
+-- NOTE: SQLiteは外部キー付きのNOT NULL列・一意制約を追加できないため、テーブルを作り直す(外部キー制約は無効にして実行する)
+
+-- networksテーブル
+CREATE TABLE networks (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  code TEXT NOT NULL UNIQUE,
+  name TEXT NOT NULL,
+  time_zone TEXT NOT NULL DEFAULT 'Asia/Tokyo',
+  admin_user TEXT DEFAULT NULL,
+  admin_password_hash TEXT DEFAULT NULL
+);
+
+INSERT INTO networks (id, code, name, time_zone) VALUES (1, 'default', 'default', 'Asia/Tokyo');
+
+-- stationsテーブル
+CREATE TABLE stations_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  name TEXT NOT NULL,
+  name_en TEXT NOT NULL
+);
+INSERT INTO stations_new (id, network_id, name, name_en) SELECT id, 1, name, name_en FROM stations;
+DROP TABLE stations;
+ALTER TABLE stations_new RENAME TO stations;
+CREATE INDEX stations_networks_FK ON stations (network_id);
+
+-- trainsテーブル
+-- 列車名は鉄道網ごとに一意
+CREATE TABLE trains_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  name TEXT DEFAULT NULL,
+  UNIQUE (network_id, name)
+);
+INSERT INTO trains_new (id, network_id, name) SELECT id, 1, name FROM trains;
+DROP TABLE trains;
+ALTER TABLE trains_new RENAME TO trains;
@@ -0,0 +1,8 @@
+-- 駅の位置・設備の削除
+
+-- stationsテーブル
+ALTER TABLE stations DROP COLUMN accessible_toilet;
+ALTER TABLE stations DROP COLUMN step_free;
+ALTER TABLE stations DROP COLUMN elevator;
+ALTER TABLE stations DROP COLUMN lon;
+ALTER TABLE stations DROP COLUMN lat;
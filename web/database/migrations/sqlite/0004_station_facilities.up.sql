@@ -0,0 +1,8 @@
+-- 駅の位置・設備の追加
+
+-- stationsテーブル
+ALTER TABLE stations ADD COLUMN lat REAL DEFAULT NULL;
+ALTER TABLE stations ADD COLUMN lon REAL DEFAULT NULL;
+ALTER TABLE stations ADD COLUMN elevator INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE stations ADD COLUMN step_free INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE stations ADD COLUMN accessible_toilet INTEGER NOT NULL DEFAULT 0;
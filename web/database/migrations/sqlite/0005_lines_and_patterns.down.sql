@@ -0,0 +1,48 @@
+-- 列車種別・事業者・路線・運行パターンの削除
+-- NOTE: SQLiteは外部キー付きの列を削除できないため、trainsテーブルを作り直す(外部キー制約は無効にして実行する)
+-- NOTE: trainsを参照するoperationsビューは、作り直しの間だけ削除する
+
+DROP VIEW operations;
+
+-- trainsテーブル
+CREATE TABLE trains_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  name TEXT DEFAULT NULL,
+  UNIQUE (network_id, name)
+);
+INSERT INTO trains_old (id, network_id, name) SELECT id, network_id, name FROM trains;
+DROP TABLE trains;
+ALTER TABLE trains_old RENAME TO trains;
+
+-- operationsビュー
+CREATE VIEW operations AS
+SELECT network_id, train_id, op_order, dep_sta_id, dep_time, arr_sta_id, arr_time, dep_drop_off_only, arr_pickup_only, dep_platform, arr_platform
+FROM (
+  SELECT
+    t.network_id,
+    train_id,
+    ROW_NUMBER() OVER (PARTITION BY train_id ORDER BY stop_sequence) AS op_order,
+    station_id AS dep_sta_id,
+    COALESCE(dep_time, arr_time) AS dep_time,
+    LEAD(station_id) OVER (PARTITION BY train_id ORDER BY stop_sequence) AS arr_sta_id,
+    COALESCE(
+      LEAD(arr_time) OVER (PARTITION BY train_id ORDER BY stop_sequence),
+      LEAD(dep_time) OVER (PARTITION BY train_id ORDER BY stop_sequence)
+    ) AS arr_time,
+    drop_off_only AS dep_drop_off_only,
+    LEAD(pickup_only) OVER (PARTITION BY train_id ORDER BY stop_sequence) AS arr_pickup_only,
+    platform AS dep_platform,
+    LEAD(platform) OVER (PARTITION BY train_id ORDER BY stop_sequence) AS arr_platform
+  FROM stop_times
+  INNER JOIN trains t ON t.id = stop_times.train_id
+) o
+WHERE arr_sta_id IS NOT NULL;
+
+DROP TABLE line_stations;
+DROP TABLE service_frequencies;
+DROP TABLE service_pattern_stops;
+DROP TABLE service_patterns;
+DROP TABLE lines;
+DROP TABLE agencies;
+DROP TABLE train_types;
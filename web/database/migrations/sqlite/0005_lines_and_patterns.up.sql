@@ -0,0 +1,77 @@
+-- 列車種別・事業者・路線・運行パターンの追加
+-- 既存の列車は、種別・路線・運行パターン・事業者が未設定(NULL)となる
+
+-- train_typesテーブル
+CREATE TABLE train_types (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  name TEXT NOT NULL,
+  name_en TEXT NOT NULL,
+  color TEXT NOT NULL DEFAULT '#333333'
+);
+
+-- agenciesテーブル
+CREATE TABLE agencies (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  name TEXT NOT NULL,
+  name_en TEXT NOT NULL,
+  url TEXT NOT NULL DEFAULT '',
+  fare_system TEXT NOT NULL DEFAULT '',
+  color TEXT NOT NULL DEFAULT '#333333'
+);
+
+-- linesテーブル
+CREATE TABLE lines (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  name TEXT NOT NULL,
+  name_en TEXT NOT NULL,
+  agency_id INTEGER DEFAULT NULL REFERENCES agencies (id) ON DELETE SET NULL
+);
+
+-- service_patternsテーブル
+CREATE TABLE service_patterns (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  name TEXT NOT NULL,
+  train_name_prefix TEXT NOT NULL DEFAULT '',
+  type_id INTEGER DEFAULT NULL REFERENCES train_types (id),
+  line_id INTEGER DEFAULT NULL REFERENCES lines (id) ON DELETE SET NULL
+);
+
+-- service_pattern_stopsテーブル
+CREATE TABLE service_pattern_stops (
+  pattern_id INTEGER NOT NULL REFERENCES service_patterns (id) ON DELETE CASCADE,
+  sequence INTEGER NOT NULL,
+  station_id INTEGER NOT NULL REFERENCES stations (id),
+  run_seconds INTEGER NOT NULL DEFAULT 0,
+  dwell_seconds INTEGER NOT NULL DEFAULT 0,
+  platform TEXT DEFAULT NULL,
+  PRIMARY KEY (pattern_id, sequence)
+);
+
+-- service_frequenciesテーブル
+CREATE TABLE service_frequencies (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  pattern_id INTEGER NOT NULL REFERENCES service_patterns (id) ON DELETE CASCADE,
+  start_time TEXT NOT NULL CHECK (start_time GLOB '[0-9][0-9]:[0-5][0-9]:[0-5][0-9]'),
+  end_time TEXT NOT NULL CHECK (end_time GLOB '[0-9][0-9]:[0-5][0-9]:[0-5][0-9]'),
+  headway_seconds INTEGER NOT NULL
+);
+
+-- line_stationsテーブル
+CREATE TABLE line_stations (
+  line_id INTEGER NOT NULL REFERENCES lines (id) ON DELETE CASCADE,
+  station_id INTEGER NOT NULL REFERENCES stations (id),
+  sequence INTEGER NOT NULL,
+  km REAL NOT NULL DEFAULT 0,
+  PRIMARY KEY (line_id, sequence),
+  UNIQUE (line_id, station_id)
+);
+
+-- trainsテーブル
+ALTER TABLE trains ADD COLUMN type_id INTEGER DEFAULT NULL REFERENCES train_types (id);
+ALTER TABLE trains ADD COLUMN line_id INTEGER DEFAULT NULL REFERENCES lines (id) ON DELETE SET NULL;
+ALTER TABLE trains ADD COLUMN pattern_id INTEGER DEFAULT NULL REFERENCES service_patterns (id);
+ALTER TABLE trains ADD COLUMN agency_id INTEGER DEFAULT NULL REFERENCES agencies (id) ON DELETE SET NULL;
@@ -0,0 +1,68 @@
+-- 運休・運転見合わせ・お知らせ・線路設備・番線間の乗換・列車の直通の追加
+
+-- platform_transfersテーブル
+CREATE TABLE platform_transfers (
+  station_id INTEGER NOT NULL REFERENCES stations (id),
+  from_platform TEXT NOT NULL DEFAULT '',
+  to_platform TEXT NOT NULL DEFAULT '',
+  transfer_seconds INTEGER NOT NULL,
+  step_free INTEGER NOT NULL DEFAULT 1,
+  wheelchair_transfer_seconds INTEGER DEFAULT NULL,
+  PRIMARY KEY (station_id, from_platform, to_platform)
+);
+
+-- train_cancellationsテーブル
+CREATE TABLE train_cancellations (
+  train_id INTEGER NOT NULL REFERENCES trains (id) ON DELETE CASCADE,
+  service_date date NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  PRIMARY KEY (train_id, service_date)
+);
+
+-- segment_suspensionsテーブル
+CREATE TABLE segment_suspensions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  sta_id_a INTEGER NOT NULL REFERENCES stations (id),
+  sta_id_b INTEGER NOT NULL REFERENCES stations (id),
+  start_datetime datetime NOT NULL,
+  end_datetime datetime NOT NULL,
+  reason TEXT NOT NULL DEFAULT ''
+);
+
+-- service_alertsテーブル
+CREATE TABLE service_alerts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  severity TEXT NOT NULL DEFAULT 'info' CHECK (severity IN ('info', 'warning', 'severe')),
+  header TEXT NOT NULL,
+  header_en TEXT NOT NULL DEFAULT '',
+  description TEXT NOT NULL,
+  description_en TEXT NOT NULL,
+  active_from datetime DEFAULT NULL,
+  active_until datetime DEFAULT NULL
+);
+
+-- service_alert_entitiesテーブル
+CREATE TABLE service_alert_entities (
+  alert_id INTEGER NOT NULL REFERENCES service_alerts (id) ON DELETE CASCADE,
+  entity_type TEXT NOT NULL CHECK (entity_type IN ('station', 'train', 'line')),
+  entity_id INTEGER NOT NULL,
+  PRIMARY KEY (alert_id, entity_type, entity_id)
+);
+
+-- track_segmentsテーブル
+CREATE TABLE track_segments (
+  sta_id_a INTEGER NOT NULL REFERENCES stations (id),
+  sta_id_b INTEGER NOT NULL REFERENCES stations (id),
+  single_track INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (sta_id_a, sta_id_b)
+);
+
+-- train_relationsテーブル
+CREATE TABLE train_relations (
+  from_train_id INTEGER NOT NULL REFERENCES trains (id) ON DELETE CASCADE,
+  to_train_id INTEGER NOT NULL REFERENCES trains (id) ON DELETE CASCADE,
+  station_id INTEGER NOT NULL REFERENCES stations (id),
+  relation_type TEXT NOT NULL CHECK (relation_type IN ('through', 'split', 'join')),
+  PRIMARY KEY (from_train_id, to_train_id, station_id)
+);
@@ -0,0 +1,26 @@
+-- お知らせの文言を、service_alertsの日本語・英語の列に戻す(日本語・英語以外の文言は削除される)
+-- NOTE: SQLiteは列を追加する位置を指定できないため、service_alertsテーブルを作り直す(外部キー制約は無効にして実行する)
+
+-- service_alertsテーブル
+CREATE TABLE service_alerts_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  severity TEXT NOT NULL DEFAULT 'info' CHECK (severity IN ('info', 'warning', 'severe')),
+  header TEXT NOT NULL,
+  header_en TEXT NOT NULL DEFAULT '',
+  description TEXT NOT NULL,
+  description_en TEXT NOT NULL,
+  active_from datetime DEFAULT NULL,
+  active_until datetime DEFAULT NULL
+);
+INSERT INTO service_alerts_old (id, network_id, severity, header, header_en, description, description_en, active_from, active_until)
+SELECT a.id, a.network_id, a.severity,
+  COALESCE(ja.header, ''), COALESCE(en.header, ''), COALESCE(ja.description, ''), COALESCE(en.description, ''),
+  a.active_from, a.active_until
+FROM service_alerts a
+LEFT JOIN service_alert_texts ja ON ja.alert_id = a.id AND ja.locale = 'ja'
+LEFT JOIN service_alert_texts en ON en.alert_id = a.id AND en.locale = 'en';
+DROP TABLE service_alerts;
+ALTER TABLE service_alerts_old RENAME TO service_alerts;
+
+DROP TABLE service_alert_texts;
@@ -0,0 +1,29 @@
+-- お知らせの文言を、言語(ロケール)ごとにservice_alert_textsへ保持する
+-- 既存の文言は、日本語(ja)と英語(en、空の場合は除く)として移行する
+-- NOTE: SQLiteは外部キーを持つテーブルの列を削除できないため、service_alertsテーブルを作り直す(外部キー制約は無効にして実行する)
+
+-- service_alert_textsテーブル
+CREATE TABLE service_alert_texts (
+  alert_id INTEGER NOT NULL REFERENCES service_alerts (id) ON DELETE CASCADE,
+  locale TEXT NOT NULL,
+  header TEXT NOT NULL,
+  description TEXT NOT NULL,
+  PRIMARY KEY (alert_id, locale)
+);
+INSERT INTO service_alert_texts (alert_id, locale, header, description)
+SELECT id, 'ja', header, description FROM service_alerts;
+INSERT INTO service_alert_texts (alert_id, locale, header, description)
+SELECT id, 'en', header_en, description_en FROM service_alerts WHERE header_en <> '' OR description_en <> '';
+
+-- service_alertsテーブル
+CREATE TABLE service_alerts_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  network_id INTEGER NOT NULL REFERENCES networks (id),
+  severity TEXT NOT NULL DEFAULT 'info' CHECK (severity IN ('info', 'warning', 'severe')),
+  active_from datetime DEFAULT NULL,
+  active_until datetime DEFAULT NULL
+);
+INSERT INTO service_alerts_new (id, network_id, severity, active_from, active_until)
+SELECT id, network_id, severity, active_from, active_until FROM service_alerts;
+DROP TABLE service_alerts;
+ALTER TABLE service_alerts_new RENAME TO service_alerts;
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// PostgreSQL接続処理(maxRetryCountだけ試行)
+// NOTE: 接続先ホストは環境変数POSTGRES_HOST(未設定の場合はdb)
+func ConnectPostgres(maxRetryCount int) (*sqlx.DB, error) {
+	host := os.Getenv("POSTGRES_HOST")
+	if host == "" {
+		host = "db"
+	}
+
+	for r := 1; r <= maxRetryCount; r++ {
+		db, err := sqlx.Open("postgres", fmt.Sprintf(
+			"host=%s port=5432 user=%s password=%s dbname=%s sslmode=disable",
+			host,
+			os.Getenv("POSTGRES_USER"),
+			os.Getenv("POSTGRES_PASSWORD"),
+			os.Getenv("POSTGRES_DB"),
+		))
+		if err != nil {
+			return nil, fmt.Errorf("dbConnection: %w", err)
+		}
+
+		// DB接続が確立されたら離脱
+		err = db.Ping()
+		if err == nil {
+			log.Printf("DB connection successful")
+			return db, nil
+		}
+
+		// 接続失敗時は5秒待機後リトライ
+		log.Printf("DB Connection Error: %v", err)
+		db.Close()
+		time.Sleep(5 * time.Second)
+	}
+	return nil, fmt.Errorf("DB connection error occured %d times", maxRetryCount)
+}
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLite接続処理(createがfalseで、DBファイルが存在しない場合はエラー)
+// NOTE: 外部キー制約はSQLiteの既定では無効のため、接続ごとに有効化する
+func ConnectSQLite(path string, create bool) (*sqlx.DB, error) {
+	mode := "rw"
+	if create {
+		mode = "rwc"
+	}
+	db, err := sqlx.Open("sqlite3", fmt.Sprintf("file:%s?mode=%s&_foreign_keys=on&_busy_timeout=5000", path, mode))
+	if err != nil {
+		return nil, fmt.Errorf("dbConnection: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("dbConnection: %w", err)
+	}
+	log.Printf("DB connection successful")
+	return db, nil
+}
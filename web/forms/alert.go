@@ -0,0 +1,20 @@
+package forms
+
+import "time"
+
+// お知らせ登録のリクエストフォーマット
+type ServiceAlertForm struct {
+	Severity    string                   `json:"severity" binding:"required,oneof=info warning severe"`
+	Texts       map[string]AlertTextForm `json:"texts" binding:"required,min=1,dive"` // ロケール(ja・en等)から文言
+	ActiveFrom  *time.Time               `json:"active_from"`
+	ActiveUntil *time.Time               `json:"active_until"`
+	StationIDs  []uint                   `json:"station_ids"`
+	TrainIDs    []uint                   `json:"train_ids"`
+	LineIDs     []uint                   `json:"line_ids"`
+}
+
+// お知らせの文言(1言語分)
+type AlertTextForm struct {
+	Header      string `json:"header" binding:"required"`
+	Description string `json:"description"`
+}
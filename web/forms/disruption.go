@@ -0,0 +1,19 @@
+package forms
+
+import "time"
+
+// 運休登録のリクエストフォーマット
+type TrainCancellationForm struct {
+	TrainID     uint   `json:"train_id" binding:"required"`
+	ServiceDate string `json:"service_date" binding:"required"` // YYYY-MM-DD
+	Reason      string `json:"reason"`
+}
+
+// 運転見合わせ登録のリクエストフォーマット
+type SegmentSuspensionForm struct {
+	StationIDA    uint      `json:"station_id_a" binding:"required"`
+	StationIDB    uint      `json:"station_id_b" binding:"required"`
+	StartDatetime time.Time `json:"start_datetime" binding:"required"`
+	EndDatetime   time.Time `json:"end_datetime" binding:"required"`
+	Reason        string    `json:"reason"`
+}
@@ -10,4 +10,45 @@ type TransitSearchForm struct {
 	ArriveStationName *string    `json:"arrive_station_name"`
 	ArriveStationID   *uint      `json:"arrive_station_id"`
 	ArriveDateTime    *time.Time `json:"arrive_datetime"`
+	Mode              string     `json:"mode"` // ""(通常)、"first_train"(始発)、"last_train"(終電)のいずれか
+	SearchOptionsForm
+}
+
+// 出発時刻の範囲を指定した乗換案内探索のリクエストフォーマット
+type TransitRangeSearchForm struct {
+	DepartStationName *string   `json:"depart_station_name"`
+	DepartStationID   *uint     `json:"depart_station_id"`
+	ArriveStationName *string   `json:"arrive_station_name"`
+	ArriveStationID   *uint     `json:"arrive_station_id"`
+	DepartFrom        time.Time `json:"depart_from" binding:"required"`
+	DepartUntil       time.Time `json:"depart_until" binding:"required"`
+	SearchOptionsForm
+}
+
+// 探索の条件・上限(各探索で共通)
+type SearchOptionsForm struct {
+	Wheelchair      bool   `json:"wheelchair"`        // 車いす利用(段差のある駅・乗換を使用しない)
+	ViaStationIDs   []uint `json:"via_station_ids"`   // 経由する必要のある駅(順不同)
+	AvoidStationIDs []uint `json:"avoid_station_ids"` // 通過・停車しない駅
+	AvoidTrainTypes []uint `json:"avoid_train_types"` // 使用しない列車種別のID
+	AgencyIDs       []uint `json:"agency_ids"`        // 使用する事業者のID(指定した事業者の列車のみ使用)
+	// 探索の上限(未指定の場合はサーバの既定値)
+	MaxTransfers     *uint `json:"max_transfers"`
+	MaxTravelMinutes *uint `json:"max_travel_minutes"`
+	MaxResults       *uint `json:"max_results"`
+	MaxWaitMinutes   *uint `json:"max_wait_minutes"`
+}
+
+// 出発駅×到着駅の所要時間行列のリクエストフォーマット
+type MatrixForm struct {
+	OriginStationIDs      []uint    `json:"origin_station_ids" binding:"required"`
+	DestinationStationIDs []uint    `json:"destination_station_ids" binding:"required"`
+	DepartDateTime        time.Time `json:"depart_datetime" binding:"required"`
+	Format                string    `json:"format"` // "json"(既定)または"csv"
+	AvoidTrainTypes       []uint    `json:"avoid_train_types"`
+	AgencyIDs             []uint    `json:"agency_ids"`
+	// 探索の上限(未指定の場合はサーバの既定値)
+	MaxTransfers     *uint `json:"max_transfers"`
+	MaxTravelMinutes *uint `json:"max_travel_minutes"`
+	MaxWaitMinutes   *uint `json:"max_wait_minutes"`
 }
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"outtech105.com/transit_server/views"
+)
+
+// 事業者一覧を取得
+func GetAgencies() func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		agencies := networkOf(ctx).Agencies()
+		agenciesView := make([]views.AgencyView, 0, len(agencies))
+		for _, agency := range agencies {
+			agenciesView = append(agenciesView, views.AgencyView(agency))
+		}
+
+		ctx.JSON(http.StatusOK, views.AgenciesView{Agencies: agenciesView})
+	}
+}
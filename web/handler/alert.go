@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"outtech105.com/transit_server/forms"
+	"outtech105.com/transit_server/models"
+	"outtech105.com/transit_server/views"
+)
+
+// お知らせの文言のロケール(ja・en・zh-Hant等の言語タグ)
+var alertLocalePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// 指定日時(未指定時は現在)に有効なお知らせ一覧を取得
+func GetServiceAlerts(db *sqlx.DB) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		network := networkOf(ctx)
+
+		datetime := time.Now().In(network.Location)
+		if datetimeString := ctx.Query("datetime"); datetimeString != "" {
+			parsed, err := time.Parse(time.RFC3339, datetimeString)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid datetime."})
+				return
+			}
+			datetime = parsed
+		}
+
+		alerts, err := models.GetServiceAlerts(ctx.Request.Context(), db, network.ID, datetime, datetime)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("getServiceAlerts: %s", err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, views.AlertsView{Alerts: newAlertsView(alerts, network.Location)})
+	}
+}
+
+// お知らせを登録(管理用)
+func CreateServiceAlert(db *sqlx.DB) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		var request forms.ServiceAlertForm
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Parameters are missing."})
+			return
+		}
+
+		if request.ActiveFrom != nil && request.ActiveUntil != nil && !request.ActiveFrom.Before(*request.ActiveUntil) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "The active_until must be after the active_from."})
+			return
+		}
+
+		texts := make(map[string]models.AlertText, len(request.Texts))
+		for locale, text := range request.Texts {
+			if len(locale) > 16 || !alertLocalePattern.MatchString(locale) {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid locale."})
+				return
+			}
+			texts[locale] = models.AlertText{Header: text.Header, Description: text.Description}
+		}
+
+		id, err := models.CreateServiceAlert(ctx.Request.Context(), db, networkOf(ctx).ID, models.ServiceAlert{
+			Severity:    request.Severity,
+			Texts:       texts,
+			ActiveFrom:  request.ActiveFrom,
+			ActiveUntil: request.ActiveUntil,
+			StationIDs:  request.StationIDs,
+			TrainIDs:    request.TrainIDs,
+			LineIDs:     request.LineIDs,
+		})
+		if err != nil {
+			switch err {
+			case models.ErrAlertStationIDMissing:
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid station ID."})
+			case models.ErrTrainIDMissing:
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid train ID."})
+			case models.ErrAlertLineIDMissing:
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid line ID."})
+			default:
+				ctx.AbortWithStatus(http.StatusInternalServerError)
+				log.Printf("createServiceAlert: %s", err.Error())
+			}
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, views.AlertCreatedView{ID: id})
+	}
+}
+
+// お知らせを削除(管理用)
+func DeleteServiceAlert(db *sqlx.DB) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid request."})
+			return
+		}
+
+		if err := models.DeleteServiceAlert(ctx.Request.Context(), db, networkOf(ctx).ID, uint(id)); err != nil {
+			if err == sql.ErrNoRows {
+				ctx.AbortWithStatusJSON(http.StatusNotFound, views.ErrorView{Error: "Alert not found."})
+				return
+			}
+
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("deleteServiceAlert: %s", err.Error())
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// お知らせ一覧をレスポンス型に変換(日時はlocに変換)
+func newAlertsView(alerts []models.ServiceAlert, loc *time.Location) []views.AlertView {
+	alertsView := make([]views.AlertView, 0, len(alerts))
+	for _, alert := range alerts {
+		alertView := views.AlertView{
+			ID:         alert.ID,
+			Severity:   alert.Severity,
+			Texts:      make(map[string]views.AlertTextView, len(alert.Texts)),
+			StationIDs: append([]uint{}, alert.StationIDs...),
+			TrainIDs:   append([]uint{}, alert.TrainIDs...),
+			LineIDs:    append([]uint{}, alert.LineIDs...),
+		}
+		for locale, text := range alert.Texts {
+			alertView.Texts[locale] = views.AlertTextView{Header: text.Header, Description: text.Description}
+		}
+		if alert.ActiveFrom != nil {
+			activeFrom := alert.ActiveFrom.In(loc)
+			alertView.ActiveFrom = &activeFrom
+		}
+		if alert.ActiveUntil != nil {
+			activeUntil := alert.ActiveUntil.In(loc)
+			alertView.ActiveUntil = &activeUntil
+		}
+		alertsView = append(alertsView, alertView)
+	}
+	return alertsView
+}
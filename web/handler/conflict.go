@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"outtech105.com/transit_server/controllers"
+	"outtech105.com/transit_server/models"
+	"outtech105.com/transit_server/views"
+)
+
+// 最小運転間隔の既定値(秒)
+const defaultMinHeadwaySeconds = 120
+
+// ダイヤの矛盾(単線での対向競合・駅間での追い越し・運転間隔不足)を検査(管理用)
+func GetTimetableConflicts(db *sqlx.DB, repos models.Repositories) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		minHeadwaySeconds, err := strconv.ParseUint(
+			ctx.DefaultQuery("min_headway_seconds", strconv.Itoa(defaultMinHeadwaySeconds)), 10, 64,
+		)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid min_headway_seconds."})
+			return
+		}
+
+		conflicts, err := controllers.DetectTimetableConflicts(ctx.Request.Context(), networkOf(ctx).ID, time.Duration(minHeadwaySeconds)*time.Second, repos, db)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("detectTimetableConflicts: %s", err.Error())
+			return
+		}
+
+		conflictsView := make([]views.ConflictView, len(conflicts))
+		for i, conflict := range conflicts {
+			conflictsView[i] = views.ConflictView{
+				Type: conflict.Type,
+				Operations: [2]views.TimetableOperationView{
+					newTimetableOperationView(conflict.Operations[0]),
+					newTimetableOperationView(conflict.Operations[1]),
+				},
+			}
+		}
+		ctx.JSON(http.StatusOK, views.ConflictsView{
+			MinHeadwaySeconds: uint(minHeadwaySeconds),
+			Conflicts:         conflictsView,
+		})
+	}
+}
+
+func newTimetableOperationView(op models.Operation) views.TimetableOperationView {
+	return views.TimetableOperationView{
+		TrainID:         op.TrainID,
+		Order:           op.Order,
+		DepartStationID: op.DepartStationID,
+		DepartTime:      op.DepartDatetime.Format("15:04:05"),
+		ArriveStationID: op.ArriveStationID,
+		ArriveTime:      op.ArriveDatetime.Format("15:04:05"),
+	}
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"outtech105.com/transit_server/forms"
+	"outtech105.com/transit_server/models"
+	"outtech105.com/transit_server/views"
+)
+
+// 現在以降に影響する運休・運転見合わせ情報の一覧を取得(管理用)
+func GetDisruptions(db *sqlx.DB) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		network := networkOf(ctx)
+
+		disruptions, err := models.GetDisruptions(ctx.Request.Context(), db, network.ID, time.Now().In(network.Location))
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("getDisruptions: %s", err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, newDisruptionsView(disruptions.Cancellations, disruptions.Suspensions, network.Location))
+	}
+}
+
+// 列車の運休を登録(管理用)
+func CreateTrainCancellation(db *sqlx.DB) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		var request forms.TrainCancellationForm
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Parameters are missing."})
+			return
+		}
+
+		serviceDate, err := time.Parse("2006-01-02", request.ServiceDate)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid service date."})
+			return
+		}
+
+		if err := models.CheckExistsTrainID(ctx.Request.Context(), db, networkOf(ctx).ID, request.TrainID); err != nil {
+			if err == models.ErrTrainIDMissing {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid train ID."})
+			} else {
+				log.Printf("checkExistsTrainID: %v", err)
+				ctx.AbortWithStatus(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		cancellation := models.TrainCancellation{
+			TrainID:     request.TrainID,
+			ServiceDate: serviceDate,
+			Reason:      request.Reason,
+		}
+		if err := models.CreateTrainCancellation(ctx.Request.Context(), db, cancellation); err != nil {
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("createTrainCancellation: %s", err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, newTrainCancellationView(cancellation))
+	}
+}
+
+// 列車の運休を取り消し(管理用)
+func DeleteTrainCancellation(db *sqlx.DB) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		trainID, err := strconv.ParseUint(ctx.Param("train_id"), 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid request."})
+			return
+		}
+		serviceDate, err := time.Parse("2006-01-02", ctx.Param("date"))
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid request."})
+			return
+		}
+
+		if err := models.DeleteTrainCancellation(ctx.Request.Context(), db, networkOf(ctx).ID, uint(trainID), serviceDate); err != nil {
+			if err == sql.ErrNoRows {
+				ctx.AbortWithStatusJSON(http.StatusNotFound, views.ErrorView{Error: "Cancellation not found."})
+				return
+			}
+
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("deleteTrainCancellation: %s", err.Error())
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// 駅間の運転見合わせを登録(管理用)
+func CreateSegmentSuspension(db *sqlx.DB) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		var request forms.SegmentSuspensionForm
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Parameters are missing."})
+			return
+		}
+
+		if !request.StartDatetime.Before(request.EndDatetime) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "The end datetime must be after the start datetime."})
+			return
+		}
+
+		if request.StationIDA == request.StationIDB {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Station ID A and station ID B must be different."})
+			return
+		}
+		for _, staID := range []uint{request.StationIDA, request.StationIDB} {
+			if _, isFound := networkOf(ctx).Station(staID); !isFound {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid station ID."})
+				return
+			}
+		}
+
+		id, err := models.CreateSegmentSuspension(ctx.Request.Context(), db, models.SegmentSuspension{
+			StationIDA:    request.StationIDA,
+			StationIDB:    request.StationIDB,
+			StartDatetime: request.StartDatetime,
+			EndDatetime:   request.EndDatetime,
+			Reason:        request.Reason,
+		})
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("createSegmentSuspension: %s", err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, views.SegmentSuspensionCreatedView{ID: id})
+	}
+}
+
+// 駅間の運転見合わせを取り消し(管理用)
+func DeleteSegmentSuspension(db *sqlx.DB) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid request."})
+			return
+		}
+
+		if err := models.DeleteSegmentSuspension(ctx.Request.Context(), db, networkOf(ctx).ID, uint(id)); err != nil {
+			if err == sql.ErrNoRows {
+				ctx.AbortWithStatusJSON(http.StatusNotFound, views.ErrorView{Error: "Suspension not found."})
+				return
+			}
+
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("deleteSegmentSuspension: %s", err.Error())
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func newTrainCancellationView(c models.TrainCancellation) views.TrainCancellationView {
+	return views.TrainCancellationView{
+		TrainID:     c.TrainID,
+		ServiceDate: c.ServiceDate.Format("2006-01-02"),
+		Reason:      c.Reason,
+	}
+}
+
+// 運休・運転見合わせ情報をレスポンス型に変換(日時はlocに変換)
+func newDisruptionsView(cancellations []models.TrainCancellation, suspensions []models.SegmentSuspension, loc *time.Location) views.DisruptionsView {
+	disruptionsView := views.DisruptionsView{
+		Cancellations: make([]views.TrainCancellationView, 0, len(cancellations)),
+		Suspensions:   make([]views.SegmentSuspensionView, 0, len(suspensions)),
+	}
+	for _, c := range cancellations {
+		disruptionsView.Cancellations = append(disruptionsView.Cancellations, newTrainCancellationView(c))
+	}
+	for _, s := range suspensions {
+		disruptionsView.Suspensions = append(disruptionsView.Suspensions, views.SegmentSuspensionView{
+			ID:            s.ID,
+			StationIDA:    s.StationIDA,
+			StationIDB:    s.StationIDB,
+			StartDatetime: s.StartDatetime.In(loc),
+			EndDatetime:   s.EndDatetime.In(loc),
+			Reason:        s.Reason,
+		})
+	}
+	return disruptionsView
+}
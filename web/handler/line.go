@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"outtech105.com/transit_server/controllers"
+	"outtech105.com/transit_server/models"
+	"outtech105.com/transit_server/views"
+)
+
+// 路線一覧を取得
+func GetLines(db *sqlx.DB) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		lines, err := models.GetLines(ctx.Request.Context(), db, networkOf(ctx).ID)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("getLines: %s", err.Error())
+			return
+		}
+
+		linesView := make([]views.LineView, 0, len(lines))
+		for _, line := range lines {
+			linesView = append(linesView, views.LineView(line))
+		}
+		ctx.JSON(http.StatusOK, views.LinesView{Lines: linesView})
+	}
+}
+
+// 路線IDから、路線情報と構成駅(路線上の順序)を取得
+func GetLineByID(db *sqlx.DB) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid request."})
+			return
+		}
+
+		line, err := models.GetLineByID(ctx.Request.Context(), db, networkOf(ctx).ID, uint(id))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.AbortWithStatusJSON(http.StatusNotFound, views.ErrorView{Error: "Line not found."})
+				return
+			}
+
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("getLineByID: %s", err.Error())
+			return
+		}
+
+		lineStations, err := models.GetLineStations(ctx.Request.Context(), db, line.ID)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("getLineStations: %s", err.Error())
+			return
+		}
+
+		lineStationsView := make([]views.LineStationView, 0, len(lineStations))
+		for _, ls := range lineStations {
+			lineStationsView = append(lineStationsView, views.LineStationView{
+				StationView: views.StationView(ls.Station),
+				Sequence:    ls.Sequence,
+				Km:          ls.Km,
+			})
+		}
+		ctx.JSON(http.StatusOK, views.LineDetailView{
+			LineView: views.LineView(line),
+			Stations: lineStationsView,
+		})
+	}
+}
+
+// 路線の運行図表(ダイヤグラム)をSVGで取得
+func GetLineDiagram(db *sqlx.DB) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid request."})
+			return
+		}
+
+		// 描画範囲(未指定時は0:00〜24:00)
+		from, errFrom := parseClockDuration(ctx.DefaultQuery("from", "00:00"))
+		to, errTo := parseClockDuration(ctx.DefaultQuery("to", "24:00"))
+		if errFrom != nil || errTo != nil || from >= to {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid time range."})
+			return
+		}
+
+		diagram, err := controllers.BuildLineDiagram(ctx.Request.Context(), networkOf(ctx).ID, uint(id), from, to, db)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.AbortWithStatusJSON(http.StatusNotFound, views.ErrorView{Error: "Line not found."})
+				return
+			}
+
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("buildLineDiagram: %s", err.Error())
+			return
+		}
+
+		diagramView := views.DiagramView{
+			Title:    diagram.Line.Name,
+			From:     diagram.From,
+			To:       diagram.To,
+			Stations: make([]views.DiagramStationView, len(diagram.Stations)),
+			Trains:   make([]views.DiagramTrainView, len(diagram.Trains)),
+		}
+		for i, sta := range diagram.Stations {
+			diagramView.Stations[i] = views.DiagramStationView{Name: sta.Station.Name, Position: sta.Position}
+		}
+		for i, train := range diagram.Trains {
+			label := fmt.Sprintf("#%d", train.TrainID)
+			if train.TrainName != nil {
+				label = *train.TrainName
+			}
+			points := make([]views.DiagramPointView, len(train.Points))
+			for j, point := range train.Points {
+				points[j] = views.DiagramPointView(point)
+			}
+			diagramView.Trains[i] = views.DiagramTrainView{Label: label, Color: train.Color, Points: points}
+		}
+
+		ctx.Data(http.StatusOK, "image/svg+xml", diagramView.RenderSVG())
+	}
+}
+
+// "HH:MM"形式の時刻を0:00からの経過時間に変換(日付を跨ぐ指定のため、48:00まで許容)
+func parseClockDuration(s string) (time.Duration, error) {
+	hourString, minuteString, found := strings.Cut(s, ":")
+	if !found {
+		return 0, fmt.Errorf("invalid clock format: %s", s)
+	}
+	hour, err := strconv.ParseUint(hourString, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	minute, err := strconv.ParseUint(minuteString, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if minute >= 60 || hour*60+minute > 48*60 {
+		return 0, fmt.Errorf("clock out of range: %s", s)
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, nil
+}
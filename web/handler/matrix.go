@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"outtech105.com/transit_server/config"
+	"outtech105.com/transit_server/controllers"
+	"outtech105.com/transit_server/forms"
+	"outtech105.com/transit_server/models"
+	"outtech105.com/transit_server/views"
+)
+
+// 出発駅×到着駅の所要時間行列(最早到着・所要時間・乗換回数)を取得
+// format=csvの場合は、出発駅・到着駅の組ごとに1行のCSVで返す
+func SearchTravelTimeMatrixHandler(db *sqlx.DB, repos models.Repositories, limits config.SearchLimits) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		network := networkOf(ctx)
+
+		// リクエストJSONのパラメータ解析
+		var request forms.MatrixForm
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			log.Printf("Error binding JSON in SearchTravelTimeMatrix: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Parameters are missing."})
+			return
+		}
+
+		if request.Format == "" {
+			request.Format = "json"
+		}
+		if request.Format != "json" && request.Format != "csv" {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid format."})
+			return
+		}
+
+		// 出発駅・到着駅の数が、1以上サーバの上限以下であるか
+		if len(request.OriginStationIDs) == 0 || len(request.DestinationStationIDs) == 0 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "origin_station_ids and destination_station_ids must not be empty."})
+			return
+		}
+		if len(request.OriginStationIDs) > int(limits.MaxMatrixStations) || len(request.DestinationStationIDs) > int(limits.MaxMatrixStations) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{
+				Error: fmt.Sprintf("The number of origin or destination stations exceeds the server limit of %d.", limits.MaxMatrixStations),
+			})
+			return
+		}
+
+		// 出発駅・到着駅が存在するか
+		originsView := make([]views.StationView, len(request.OriginStationIDs))
+		for i, stationID := range request.OriginStationIDs {
+			station, isFound := network.Station(stationID)
+			if !isFound {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid origin station ID."})
+				return
+			}
+			originsView[i] = views.StationView(station)
+		}
+		destinationsView := make([]views.StationView, len(request.DestinationStationIDs))
+		for j, stationID := range request.DestinationStationIDs {
+			station, isFound := network.Station(stationID)
+			if !isFound {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid destination station ID."})
+				return
+			}
+			destinationsView[j] = views.StationView(station)
+		}
+
+		// 探索の上限の解析(駅に関する条件は指定できない)
+		constraints, _, ok := parseSearchOptions(ctx, network, forms.SearchOptionsForm{
+			AvoidTrainTypes:  request.AvoidTrainTypes,
+			AgencyIDs:        request.AgencyIDs,
+			MaxTransfers:     request.MaxTransfers,
+			MaxTravelMinutes: request.MaxTravelMinutes,
+			MaxWaitMinutes:   request.MaxWaitMinutes,
+		}, limits, 0, 0, 0)
+		if !ok {
+			return
+		}
+
+		// 読み込んだ時刻を鉄道網のタイムゾーンに変換(時刻表がそのタイムゾーンの時刻のため)
+		departDatetime := request.DepartDateTime.In(network.Location)
+
+		// 出発駅ごとに探索(制限時間を過ぎた場合は、途中までの結果を返す)
+		searchCtx, cancel := context.WithTimeout(ctx.Request.Context(), time.Duration(limits.TimeoutSeconds)*time.Second)
+		defer cancel()
+		result, err := controllers.SearchTravelTimeMatrix(
+			searchCtx,
+			network.ID,
+			controllers.MatrixSearchParams{
+				OriginStationIDs:      request.OriginStationIDs,
+				DestinationStationIDs: request.DestinationStationIDs,
+				DepartDateTime:        departDatetime,
+				SearchConstraints:     constraints,
+			},
+			repos,
+			db,
+		)
+		if err != nil {
+			log.Printf("Error searching travel time matrix: %v", err)
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		cellsView := make([][]*views.MatrixCellView, len(request.OriginStationIDs))
+		for i := range cellsView {
+			cellsView[i] = make([]*views.MatrixCellView, len(request.DestinationStationIDs))
+			if i >= len(result.Cells) {
+				continue
+			}
+			for j, cell := range result.Cells[i] {
+				if cell == nil {
+					continue
+				}
+				cellsView[i][j] = &views.MatrixCellView{
+					ArriveDatetime:  cell.ArriveDatetime,
+					DurationMinutes: int((cell.ArriveDatetime.Sub(departDatetime) + time.Minute - 1) / time.Minute),
+					Transfers:       cell.Transfers,
+				}
+			}
+		}
+		matrixView := views.MatrixView{
+			DepartDatetime: departDatetime,
+			Origins:        originsView,
+			Destinations:   destinationsView,
+			Cells:          cellsView,
+			Disruptions:    newDisruptionsView(result.AvoidedCancellations, result.AvoidedSuspensions, network.Location),
+			Complete:       result.Complete,
+		}
+
+		if request.Format == "csv" {
+			ctx.Data(http.StatusOK, "text/csv; charset=utf-8", matrixView.RenderCSV())
+			return
+		}
+		ctx.JSON(http.StatusOK, matrixView)
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"outtech105.com/transit_server/controllers"
+	"outtech105.com/transit_server/views"
+)
+
+// gin.Contextに鉄道網の索引を保持するキー
+const networkContextKey = "network"
+
+// 鉄道網の一覧を取得
+func GetNetworks(indexes map[string]*controllers.NetworkIndex) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		networksView := make([]views.NetworkView, 0, len(indexes))
+		ids := make(map[string]uint, len(indexes))
+		for code, index := range indexes {
+			networksView = append(networksView, views.NetworkView{
+				Code:     code,
+				Name:     index.Name,
+				TimeZone: index.TimeZone,
+			})
+			ids[code] = index.ID
+		}
+		sort.SliceStable(networksView, func(i, j int) bool {
+			return ids[networksView[i].Code] < ids[networksView[j].Code]
+		})
+
+		ctx.JSON(http.StatusOK, views.NetworksView{Networks: networksView})
+	}
+}
+
+// パスの:networkから鉄道網の索引を解決し、コンテキストに設定するミドルウェア
+func ResolveNetwork(indexes map[string]*controllers.NetworkIndex) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		index, isFound := indexes[ctx.Param("network")]
+		if !isFound {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, views.ErrorView{Error: "Network not found."})
+			return
+		}
+		ctx.Set(networkContextKey, index)
+	}
+}
+
+// 鉄道網ごとの認証情報で、管理用エンドポイントをBasic認証するミドルウェア
+// 認証情報が未設定の鉄道網では、管理用エンドポイントを無効とする
+// NOTE: ResolveNetworkの後に適用する
+func NetworkAdminAuth() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		network := networkOf(ctx)
+		if !network.HasAdminAccount() {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, views.ErrorView{Error: "Admin endpoints are disabled for this network."})
+			return
+		}
+
+		user, password, ok := ctx.Request.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(user), []byte(*network.AdminUser)) != 1 ||
+			bcrypt.CompareHashAndPassword([]byte(*network.AdminPasswordHash), []byte(password)) != nil {
+			ctx.Header("WWW-Authenticate", `Basic realm="`+network.Code+`"`)
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		ctx.Set(gin.AuthUserKey, user)
+	}
+}
+
+// ResolveNetworkで設定した鉄道網の索引を返す
+func networkOf(ctx *gin.Context) *controllers.NetworkIndex {
+	return ctx.MustGet(networkContextKey).(*controllers.NetworkIndex)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"outtech105.com/transit_server/controllers"
+	"outtech105.com/transit_server/models"
+)
+
+// 管理用エンドポイントは、鉄道網ごとの認証情報でBasic認証し、認証情報が未設定の鉄道網では無効とする
+func TestNetworkAdminAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword: %v", err)
+	}
+	user, passwordHash := "admin", string(hash)
+	otherUser := "other"
+	indexes := map[string]*controllers.NetworkIndex{
+		"east":  {Network: models.Network{ID: 1, Code: "east", AdminUser: &user, AdminPasswordHash: &passwordHash}},
+		"west":  {Network: models.Network{ID: 2, Code: "west", AdminUser: &otherUser, AdminPasswordHash: &passwordHash}},
+		"north": {Network: models.Network{ID: 3, Code: "north"}},
+	}
+
+	router := gin.New()
+	router.GET("/:network/admin/ping", ResolveNetwork(indexes), NetworkAdminAuth(), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, ctx.GetString(gin.AuthUserKey))
+	})
+
+	tests := []struct {
+		name          string
+		network       string
+		user          string
+		password      string
+		noCredentials bool
+		wantStatus    int
+		wantBody      string
+		wantChallenge string // WWW-Authenticateヘッダ
+	}{
+		{name: "authorized", network: "east", user: "admin", password: "secret", wantStatus: http.StatusOK, wantBody: "admin"},
+		{name: "wrong password", network: "east", user: "admin", password: "wrong", wantStatus: http.StatusUnauthorized, wantChallenge: `Basic realm="east"`},
+		{name: "wrong user", network: "east", user: "other", password: "secret", wantStatus: http.StatusUnauthorized, wantChallenge: `Basic realm="east"`},
+		{name: "no credentials", network: "east", noCredentials: true, wantStatus: http.StatusUnauthorized, wantChallenge: `Basic realm="east"`},
+		{name: "credentials of another network", network: "west", user: "admin", password: "secret", wantStatus: http.StatusUnauthorized, wantChallenge: `Basic realm="west"`},
+		{
+			name: "admin disabled", network: "north", user: "admin", password: "secret",
+			wantStatus: http.StatusNotFound, wantBody: `{"error":"Admin endpoints are disabled for this network."}`,
+		},
+		{
+			name: "unknown network", network: "south", user: "admin", password: "secret",
+			wantStatus: http.StatusNotFound, wantBody: `{"error":"Network not found."}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.network+"/admin/ping", nil)
+			if !tt.noCredentials {
+				req.SetBasicAuth(tt.user, tt.password)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+		})
+	}
+}
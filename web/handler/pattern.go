@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"outtech105.com/transit_server/controllers"
+	"outtech105.com/transit_server/views"
+)
+
+// 運行パターンを列車・運行に展開(管理用)
+// クエリパラメータdry_run=trueの場合、DBを更新せず展開結果のみ返す
+// NOTE: 時刻表をメモリ上に読み込んでいる場合(inMemory)、展開した列車は再起動まで経路探索等に反映されないため、DBの更新は受け付けない
+func ExpandServicePattern(db *sqlx.DB, inMemory bool) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid request."})
+			return
+		}
+		dryRun := ctx.Query("dry_run") == "true"
+		if inMemory && !dryRun {
+			ctx.AbortWithStatusJSON(http.StatusConflict, views.ErrorView{Error: "The timetable is loaded in memory. Disable STORAGE_IN_MEMORY to expand service patterns."})
+			return
+		}
+
+		expansion, err := controllers.ExpandServicePattern(ctx.Request.Context(), networkOf(ctx).ID, uint(id), dryRun, db)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.AbortWithStatusJSON(http.StatusNotFound, views.ErrorView{Error: "Service pattern not found."})
+				return
+			}
+			if errors.Is(err, controllers.ErrInvalidServicePattern) {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: err.Error()})
+				return
+			}
+
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("expandServicePattern: %s", err.Error())
+			return
+		}
+
+		trainsView := make([]views.PatternTrainView, len(expansion.Trains))
+		for i, train := range expansion.Trains {
+			operationsView := make([]views.TimetableOperationView, len(train.Operations))
+			for j, op := range train.Operations {
+				operationsView[j] = newTimetableOperationView(op)
+			}
+			trainsView[i] = views.PatternTrainView{Name: train.Name, Operations: operationsView}
+			if !dryRun {
+				trainsView[i].TrainID = &expansion.TrainIDs[i]
+				for j := range trainsView[i].Operations {
+					trainsView[i].Operations[j].TrainID = expansion.TrainIDs[i]
+				}
+			}
+		}
+		ctx.JSON(http.StatusOK, views.PatternExpansionView{
+			PatternID: expansion.Pattern.ID,
+			DryRun:    dryRun,
+			Trains:    trainsView,
+		})
+	}
+}
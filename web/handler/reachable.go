@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"outtech105.com/transit_server/config"
+	"outtech105.com/transit_server/controllers"
+	"outtech105.com/transit_server/models"
+	"outtech105.com/transit_server/views"
+)
+
+// 駅IDと指定日時(未指定時は現在)から、max_minutes分以内に到達可能な駅と最早到着を取得
+// format=geojsonの場合は、座標を持つ駅のみGeoJSONで返す
+func GetReachableStations(db *sqlx.DB, repos models.Repositories, limits config.SearchLimits) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		network := networkOf(ctx)
+
+		id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid request."})
+			return
+		}
+
+		maxMinutes, err := strconv.ParseUint(ctx.DefaultQuery("max_minutes", strconv.FormatUint(uint64(limits.DefaultTravelMinutes), 10)), 10, 64)
+		if err != nil || maxMinutes == 0 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid max_minutes."})
+			return
+		}
+		if maxMinutes > uint64(limits.MaxTravelMinutes) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{
+				Error: fmt.Sprintf("max_minutes exceeds the server limit of %d.", limits.MaxTravelMinutes),
+			})
+			return
+		}
+
+		format := ctx.DefaultQuery("format", "json")
+		if format != "json" && format != "geojson" {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid format."})
+			return
+		}
+
+		datetime := time.Now()
+		if datetimeString := ctx.Query("datetime"); datetimeString != "" {
+			datetime, err = time.Parse(time.RFC3339, datetimeString)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid datetime."})
+				return
+			}
+		}
+		// 時刻表は鉄道網のタイムゾーンの時刻のため、そのタイムゾーンで判定する
+		datetime = datetime.In(network.Location)
+
+		station, isFound := network.Station(uint(id))
+		if !isFound {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, views.ErrorView{Error: "Station not found."})
+			return
+		}
+
+		// 乗換回数・待ち時間はサーバの上限まで許容する(制限時間を過ぎた場合は、途中までの結果を返す)
+		searchCtx, cancel := context.WithTimeout(ctx.Request.Context(), time.Duration(limits.TimeoutSeconds)*time.Second)
+		defer cancel()
+		result, err := controllers.SearchReachableStations(
+			searchCtx,
+			network.ID,
+			controllers.ReachableSearchParams{
+				DepartStationID: station.ID,
+				DepartDateTime:  datetime,
+				SearchConstraints: controllers.SearchConstraints{
+					MaxTransfers: int(limits.MaxTransfers),
+					MaxTravel:    time.Duration(maxMinutes) * time.Minute,
+					MaxWait:      time.Duration(limits.MaxWaitMinutes) * time.Minute,
+				},
+			},
+			repos,
+			db,
+		)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("searchReachableStations: %s", err.Error())
+			return
+		}
+
+		// 到達可能な駅の情報は、鉄道網の索引から取得
+		reachableView := make([]views.ReachableStationView, 0, len(result.Stations))
+		for _, reachable := range result.Stations {
+			reachableStation, _ := network.Station(reachable.StationID)
+			reachableView = append(reachableView, views.ReachableStationView{
+				Station:        views.StationView(reachableStation),
+				ArriveDatetime: reachable.ArriveDatetime,
+				TravelMinutes:  int((reachable.ArriveDatetime.Sub(datetime) + time.Minute - 1) / time.Minute),
+				Transfers:      reachable.Transfers,
+			})
+		}
+
+		if format == "geojson" {
+			ctx.JSON(http.StatusOK, newReachableGeoJSON(reachableView, result.Complete))
+			return
+		}
+		ctx.JSON(http.StatusOK, views.ReachableView{
+			Station:        views.StationView(station),
+			DepartDatetime: datetime,
+			MaxMinutes:     uint(maxMinutes),
+			Stations:       reachableView,
+			Disruptions:    newDisruptionsView(result.AvoidedCancellations, result.AvoidedSuspensions, network.Location),
+			Complete:       result.Complete,
+		})
+	}
+}
+
+// 到達可能な駅を、Pointの地物としてGeoJSONに変換(座標未設定の駅は除く)
+func newReachableGeoJSON(reachableView []views.ReachableStationView, complete bool) views.GeoJSONFeatureCollection {
+	collection := views.GeoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]views.GeoJSONFeature, 0, len(reachableView)),
+		Complete: complete,
+	}
+	for _, reachable := range reachableView {
+		if reachable.Station.Lat == nil || reachable.Station.Lon == nil {
+			continue
+		}
+		collection.Features = append(collection.Features, views.GeoJSONFeature{
+			Type: "Feature",
+			Geometry: views.GeoJSONPoint{
+				Type:        "Point",
+				Coordinates: [2]float64{*reachable.Station.Lon, *reachable.Station.Lat},
+			},
+			Properties: map[string]any{
+				"id":              reachable.Station.ID,
+				"name":            reachable.Station.Name,
+				"name_en":         reachable.Station.EngName,
+				"arrive_datetime": reachable.ArriveDatetime,
+				"travel_minutes":  reachable.TravelMinutes,
+				"transfers":       reachable.Transfers,
+			},
+		})
+	}
+	return collection
+}
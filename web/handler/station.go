@@ -1,19 +1,26 @@
 package handler
 
 import (
-	"database/sql"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
+	"outtech105.com/transit_server/controllers"
 	"outtech105.com/transit_server/models"
 	"outtech105.com/transit_server/views"
 )
 
+// 発車案内の取得件数
+const (
+	defaultDeparturesLimit = 20
+	maxDeparturesLimit     = 100
+)
+
 // 駅名キーワードから部分一致で駅を検索
-func GetStationsByKeyword(db *sqlx.DB) func(*gin.Context) {
+func GetStationsByKeyword(repos models.Repositories) func(*gin.Context) {
 	return func(ctx *gin.Context) {
 		keyword := ctx.Query("keyword")
 		if keyword == "" {
@@ -21,7 +28,7 @@ func GetStationsByKeyword(db *sqlx.DB) func(*gin.Context) {
 			return
 		}
 
-		stations, err := models.GetStationsByKeyword(db, keyword)
+		stations, err := repos.Stations.GetStationsByKeyword(ctx.Request.Context(), networkOf(ctx).ID, keyword)
 		if err != nil {
 			ctx.AbortWithStatus(http.StatusInternalServerError)
 			log.Printf("getStationByKeyword: %s", err.Error())
@@ -37,7 +44,7 @@ func GetStationsByKeyword(db *sqlx.DB) func(*gin.Context) {
 }
 
 // 駅IDから駅情報を取得
-func GetStationByID(db *sqlx.DB) func(*gin.Context) {
+func GetStationByID() func(*gin.Context) {
 	return func(ctx *gin.Context) {
 		id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
 		if err != nil {
@@ -45,18 +52,84 @@ func GetStationByID(db *sqlx.DB) func(*gin.Context) {
 			return
 		}
 
-		station, err := models.GetStationByID(db, uint(id))
+		station, isFound := networkOf(ctx).Station(uint(id))
+		if !isFound {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, views.ErrorView{Error: "Station not found."})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, views.StationView(station))
+	}
+}
+
+// 駅IDから、指定日時(未指定時は現在)以降の発車案内を取得
+func GetStationDepartures(db *sqlx.DB, repos models.Repositories) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		network := networkOf(ctx)
+
+		id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
 		if err != nil {
-			if err == sql.ErrNoRows {
-				ctx.AbortWithStatusJSON(http.StatusNotFound, views.ErrorView{Error: "Station not found."})
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid request."})
+			return
+		}
+
+		limit, err := strconv.ParseUint(ctx.DefaultQuery("limit", strconv.Itoa(defaultDeparturesLimit)), 10, 64)
+		if err != nil || limit == 0 || limit > maxDeparturesLimit {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid limit."})
+			return
+		}
+
+		datetime := time.Now()
+		if datetimeString := ctx.Query("datetime"); datetimeString != "" {
+			datetime, err = time.Parse(time.RFC3339, datetimeString)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid datetime."})
 				return
 			}
+		}
+		// 時刻表は鉄道網のタイムゾーンの時刻のため、そのタイムゾーンで判定する
+		datetime = datetime.In(network.Location)
+
+		station, isFound := network.Station(uint(id))
+		if !isFound {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, views.ErrorView{Error: "Station not found."})
+			return
+		}
 
+		departures, err := controllers.GetStationDepartures(ctx.Request.Context(), network.ID, station.ID, datetime, uint(limit), repos, db)
+		if err != nil {
 			ctx.AbortWithStatus(http.StatusInternalServerError)
-			log.Printf("getStationByID: %s", err.Error())
+			log.Printf("getStationDepartures: %s", err.Error())
 			return
 		}
 
-		ctx.JSON(http.StatusOK, views.StationView(station))
+		// 行先駅の情報を鉄道網の索引から取得してセット
+		departuresView := make([]views.DepartureView, 0, len(departures))
+		for _, departure := range departures {
+			destinationStation, isFound := network.Station(departure.DestinationStationID)
+			if !isFound {
+				ctx.AbortWithStatus(http.StatusInternalServerError)
+				log.Printf("destination station %d is not in network %s", departure.DestinationStationID, network.Code)
+				return
+			}
+			destination := views.StationView(destinationStation)
+
+			departuresView = append(departuresView, views.DepartureView{
+				TrainID:        departure.TrainID,
+				TrainName:      departure.TrainName,
+				ArriveDatetime: departure.ArriveDatetime,
+				DepartDatetime: departure.DepartDatetime,
+				Destination:    destination,
+				Platform:       departure.Platform,
+				PickupOnly:     departure.PickupOnly,
+				DropOffOnly:    departure.DropOffOnly,
+				Cancelled:      departure.Cancelled,
+			})
+		}
+
+		ctx.JSON(http.StatusOK, views.DeparturesView{
+			Station:    views.StationView(station),
+			Departures: departuresView,
+		})
 	}
 }
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"outtech105.com/transit_server/controllers"
+	"outtech105.com/transit_server/models"
+	"outtech105.com/transit_server/views"
+)
+
+// 指定日時(未指定時は現在)に走行中の列車の位置を取得
+func GetTrainPositions(db *sqlx.DB, repos models.Repositories) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		network := networkOf(ctx)
+
+		datetime := time.Now()
+		if datetimeString := ctx.Query("datetime"); datetimeString != "" {
+			parsed, err := time.Parse(time.RFC3339, datetimeString)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid datetime."})
+				return
+			}
+			datetime = parsed
+		}
+		// 時刻表は鉄道網のタイムゾーンの時刻のため、そのタイムゾーンで判定する
+		datetime = datetime.In(network.Location)
+
+		positions, err := controllers.GetTrainPositions(ctx.Request.Context(), network.ID, datetime, repos, db)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			log.Printf("getTrainPositions: %s", err.Error())
+			return
+		}
+
+		trainsView := make([]views.TrainPositionView, 0, len(positions))
+		for _, position := range positions {
+			trainsView = append(trainsView, views.TrainPositionView{
+				TrainID:         position.Operation.TrainID,
+				TrainName:       position.TrainName,
+				Order:           position.Operation.Order,
+				DepartStationID: position.Operation.DepartStationID,
+				DepartDatetime:  position.Operation.DepartDatetime,
+				ArriveStationID: position.Operation.ArriveStationID,
+				ArriveDatetime:  position.Operation.ArriveDatetime,
+				Progress:        position.Progress,
+				Lat:             position.Lat,
+				Lon:             position.Lon,
+			})
+		}
+		ctx.JSON(http.StatusOK, views.TrainPositionsView{Datetime: datetime, Trains: trainsView})
+	}
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"sort"
@@ -9,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 
+	"outtech105.com/transit_server/config"
 	"outtech105.com/transit_server/controllers"
 	"outtech105.com/transit_server/forms"
 	"outtech105.com/transit_server/models"
@@ -16,8 +19,11 @@ import (
 )
 
 // 乗換案内探索
-func SearchTransitHandler(db *sqlx.DB) func(*gin.Context) {
+// 探索の上限は、リクエストでの指定がlimitsを超える場合エラーとする
+func SearchTransitHandler(db *sqlx.DB, repos models.Repositories, limits config.SearchLimits) func(*gin.Context) {
 	return func(ctx *gin.Context) {
+		network := networkOf(ctx)
+
 		// リクエストJSONのパラメータ解析
 		var request forms.TransitSearchForm
 		if err := ctx.ShouldBindJSON(&request); err != nil {
@@ -26,21 +32,30 @@ func SearchTransitHandler(db *sqlx.DB) func(*gin.Context) {
 			return
 		}
 
-		// 時刻設定が出発・到着の片方のみであるか
-		if !IsEitherNil(request.DepartDateTime, request.ArriveDateTime) {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Either the departure time or the arrival time must be set, but not both."})
+		// 探索モードが正しいか
+		if request.Mode != "" && request.Mode != "first_train" && request.Mode != "last_train" {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid mode."})
+			return
+		}
+		// 始発・終電探索では経由駅の指定に対応しない
+		if request.Mode != "" && len(request.ViaStationIDs) > 0 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "via_station_ids is not supported in first_train and last_train mode."})
 			return
 		}
 
-		// 出発駅指定が、ID/名前の片方のみであるか
-		if !IsEitherNil(request.DepartStationID, request.DepartStationName) {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Eithor the departure station name or the departure station id must be set, but not both."})
+		// 時刻設定が出発・到着の片方のみであるか
+		if !IsEitherNil(request.DepartDateTime, request.ArriveDateTime) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Either the departure time or the arrival time must be set, but not both."})
 			return
 		}
 
-		// 到着駅指定が、ID/名前の片方のみであるか
-		if !IsEitherNil(request.ArriveStationID, request.ArriveStationName) {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Eithor the arrive station name or the arrive station id must be set, but not both."})
+		// 出発・到着駅の解析
+		departStationID, arriveStationID, ok := resolveSearchStations(
+			ctx, network,
+			request.DepartStationID, request.DepartStationName,
+			request.ArriveStationID, request.ArriveStationName,
+		)
+		if !ok {
 			return
 		}
 
@@ -50,137 +65,471 @@ func SearchTransitHandler(db *sqlx.DB) func(*gin.Context) {
 			return
 		}
 
-		// 出発駅の解析
-		if request.DepartStationName != nil {
-			stationCandidates, err := models.GetStationsByName(db, *request.DepartStationName)
-			if err != nil {
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-				log.Printf("Error getting depart station by name: %v", err)
-				return
+		// 探索の条件・上限の解析
+		constraints, maxResults, ok := parseSearchOptions(ctx, network, request.SearchOptionsForm, limits, limits.DefaultMaxResults, departStationID, arriveStationID)
+		if !ok {
+			return
+		}
+
+		// 読み込んだ時刻を鉄道網のタイムゾーンに変換(時刻表がそのタイムゾーンの時刻のため)
+		departDatetime := request.DepartDateTime.In(network.Location)
+
+		// 出発時刻を基準に乗換探索(制限時間を過ぎた場合は、途中までの結果を返す)
+		// 始発・終電探索では、出発日時の属する運行日を対象とする
+		searchCtx, cancel := context.WithTimeout(ctx.Request.Context(), time.Duration(limits.TimeoutSeconds)*time.Second)
+		defer cancel()
+		var (
+			result controllers.TransitSearchResult
+			err    error
+		)
+		switch request.Mode {
+		case "first_train", "last_train":
+			params := controllers.TransitSearchParamsByServiceDay{
+				DepartStationID:   departStationID,
+				ServiceDatetime:   departDatetime,
+				ArriveStationID:   arriveStationID,
+				SearchConstraints: constraints,
 			}
-			if len(stationCandidates) != 1 {
-				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Error resolving departure station name."})
-				return
+			if request.Mode == "first_train" {
+				result, err = controllers.SearchFirstTrain(searchCtx, network.ID, params, repos, db)
+			} else {
+				result, err = controllers.SearchLastTrain(searchCtx, network.ID, params, repos, db)
 			}
-			request.DepartStationID = &stationCandidates[0].ID
+			// お知らせは運行日の開始以降を対象とする
+			departDatetime, _ = controllers.ServiceDayRange(departDatetime)
+		default:
+			result, err = controllers.SearchTransitByDepart(
+				searchCtx,
+				network.ID,
+				controllers.TransitSearchParamsByDepart{
+					DepartStationID:   departStationID,
+					DepartDateTime:    departDatetime,
+					ArriveStationID:   arriveStationID,
+					SearchConstraints: constraints,
+				},
+				repos,
+				db,
+			)
 		}
+		if err != nil {
+			log.Printf("Error searching transit: %v", err)
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		routes := result.Routes
 
-		// 到着駅の解析
-		if request.ArriveStationName != nil {
-			stationCandidates, err := models.GetStationsByName(db, *request.ArriveStationName)
-			if err != nil {
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-				log.Printf("Error getting arrive station by name: %v", err)
-				return
-			}
-			if len(stationCandidates) != 1 {
-				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Error resolving arrive station name."})
-				return
+		// 到着時刻順(同時刻の場合は乗換回数順)にソート
+		sort.SliceStable(routes, func(i, j int) bool {
+			arriveI := routes[i].Operations[len(routes[i].Operations)-1].ArriveDatetime
+			arriveJ := routes[j].Operations[len(routes[j].Operations)-1].ArriveDatetime
+			if !arriveI.Equal(arriveJ) {
+				return arriveI.Before(arriveJ)
 			}
-			request.ArriveStationID = &stationCandidates[0].ID
+			return routes[i].Transfers < routes[j].Transfers
+		})
+
+		// 結果をmaxResults件以下に制限
+		result = result.WithRoutes(routes[0:min(len(routes), int(maxResults))])
+
+		// 検索結果リクエストを返却
+		searchView, err := newTransitSearchView(ctx.Request.Context(), db, network, result, departDatetime)
+		if err != nil {
+			log.Printf("Error building search result: %v", err)
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
 		}
+		ctx.JSON(http.StatusOK, searchView)
+	}
+}
 
-		// 出発・到着駅IDが異なるか
-		if request.DepartStationID == request.ArriveStationID {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Departure station ID and arrival station ID must be different."})
+// 出発時刻の範囲を指定した乗換案内探索
+// 範囲内に出発する経路のうち、出発が遅い・到着が早い・乗換が少ない、のいずれでも他に劣る経路を除いてすべて返す
+func SearchTransitRangeHandler(db *sqlx.DB, repos models.Repositories, limits config.SearchLimits) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		network := networkOf(ctx)
+
+		// リクエストJSONのパラメータ解析
+		var request forms.TransitRangeSearchForm
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			log.Printf("Error binding JSON in SearchTransitRange: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Parameters are missing."})
 			return
 		}
 
-		// 出発・到着駅IDが存在するか
-		if err := models.CheckExistsStationID(db, *request.DepartStationID); err != nil {
-			if err == models.ErrStationIDsMissing {
-				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid depart station ID."})
-			} else {
-				log.Print("checkExistsStationIDs: %w", err)
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-			}
+		// 出発時刻の範囲が正しく、サーバの上限以内であるか
+		if !request.DepartUntil.After(request.DepartFrom) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "The depart_until must be after the depart_from."})
 			return
 		}
-		if err := models.CheckExistsStationID(db, *request.ArriveStationID); err != nil {
-			if err == models.ErrStationIDsMissing {
-				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid arrive station ID."})
-			} else {
-				log.Print("checkExistsStationIDs: %w", err)
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-			}
+		if request.DepartUntil.Sub(request.DepartFrom) > time.Duration(limits.MaxRangeMinutes)*time.Minute {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{
+				Error: fmt.Sprintf("The departure window exceeds the server limit of %d minutes.", limits.MaxRangeMinutes),
+			})
 			return
 		}
 
-		// 読み込んだ時刻をJSTに変換(DBがJSTのため)
-		jst, err := time.LoadLocation("Asia/Tokyo")
-		if err != nil {
-			log.Printf("Error loading location: %v", err)
-			ctx.AbortWithStatus(http.StatusInternalServerError)
+		// 経由駅の指定には対応しない
+		if len(request.ViaStationIDs) > 0 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "via_station_ids is not supported in range search."})
 			return
 		}
-		if request.DepartDateTime != nil {
-			*request.DepartDateTime = (*request.DepartDateTime).In(jst)
+
+		// 出発・到着駅の解析
+		departStationID, arriveStationID, ok := resolveSearchStations(
+			ctx, network,
+			request.DepartStationID, request.DepartStationName,
+			request.ArriveStationID, request.ArriveStationName,
+		)
+		if !ok {
+			return
 		}
-		if request.ArriveDateTime != nil {
-			*request.ArriveDateTime = (*request.ArriveDateTime).In(jst)
+
+		// 探索の条件・上限の解析(max_results未指定の場合は、すべての経路を返す)
+		constraints, maxResults, ok := parseSearchOptions(ctx, network, request.SearchOptionsForm, limits, 0, departStationID, arriveStationID)
+		if !ok {
+			return
 		}
 
-		// 出発時刻を基準に乗換探索
-		routes, err := controllers.SearchTransitByDepart(
-			controllers.TransitSearchParamsByDepart{
-				DepartStationID: *request.DepartStationID,
-				DepartDateTime:  *request.DepartDateTime,
-				ArriveStationID: *request.ArriveStationID,
+		// 読み込んだ時刻を鉄道網のタイムゾーンに変換(時刻表がそのタイムゾーンの時刻のため)
+		departFrom, departUntil := request.DepartFrom.In(network.Location), request.DepartUntil.In(network.Location)
+
+		// プロファイル探索(制限時間を過ぎた場合は、途中までの結果を返す)
+		searchCtx, cancel := context.WithTimeout(ctx.Request.Context(), time.Duration(limits.TimeoutSeconds)*time.Second)
+		defer cancel()
+		result, err := controllers.SearchTransitByRange(
+			searchCtx,
+			network.ID,
+			controllers.TransitSearchParamsByRange{
+				DepartStationID:   departStationID,
+				DepartFrom:        departFrom,
+				DepartUntil:       departUntil,
+				ArriveStationID:   arriveStationID,
+				SearchConstraints: constraints,
 			},
+			repos,
 			db,
 		)
 		if err != nil {
-			log.Printf("Error searching transit: %v", err)
+			log.Printf("Error searching transit range: %v", err)
 			ctx.AbortWithStatus(http.StatusInternalServerError)
 			return
 		}
 
-		// 到着時刻順にソート
-		sort.SliceStable(routes, func(i, j int) bool {
-			return routes[i].Operations[len(routes[i].Operations)-1].ArriveDatetime.Before(
-				routes[j].Operations[len(routes[j].Operations)-1].ArriveDatetime,
-			)
-		})
+		// 結果は出発時刻順。max_results指定時は、その件数以下に制限
+		if maxResults > 0 {
+			result = result.WithRoutes(result.Routes[0:min(len(result.Routes), int(maxResults))])
+		}
+
+		// 検索結果リクエストを返却
+		searchView, err := newTransitSearchView(ctx.Request.Context(), db, network, result, departFrom)
+		if err != nil {
+			log.Printf("Error building search result: %v", err)
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		ctx.JSON(http.StatusOK, searchView)
+	}
+}
+
+// 出発・到着駅のID/駅名指定を検証し、鉄道網の索引を用いて駅IDに解決する
+// 不正な指定の場合はレスポンスを返し、okにfalseを返す
+func resolveSearchStations(
+	ctx *gin.Context,
+	network *controllers.NetworkIndex,
+	departStationID *uint,
+	departStationName *string,
+	arriveStationID *uint,
+	arriveStationName *string,
+) (uint, uint, bool) {
+	// 出発駅指定が、ID/名前の片方のみであるか
+	if !IsEitherNil(departStationID, departStationName) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Eithor the departure station name or the departure station id must be set, but not both."})
+		return 0, 0, false
+	}
 
-		// 結果を5件以下に制限
-		routes = routes[0:min(len(routes), 5)]
-
-		// 検索結果をroutesViewにセット
-		viaStationsSet := make(map[uint]struct{})
-		routesView := make([]views.RouteView, len(routes))
-		for i, route := range routes {
-			operationsView := make([]views.OperationView, len(route.Operations))
-			for j, operation := range route.Operations {
-				operationsView[j] = views.OperationView(operation)
-				viaStationsSet[operation.DepartStationID] = struct{}{}
-				viaStationsSet[operation.ArriveStationID] = struct{}{}
+	// 到着駅指定が、ID/名前の片方のみであるか
+	if !IsEitherNil(arriveStationID, arriveStationName) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Eithor the arrive station name or the arrive station id must be set, but not both."})
+		return 0, 0, false
+	}
+
+	// 出発駅の解析
+	if departStationName != nil {
+		stationCandidates := network.StationsByName(*departStationName)
+		if len(stationCandidates) != 1 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Error resolving departure station name."})
+			return 0, 0, false
+		}
+		departStationID = &stationCandidates[0].ID
+	}
+
+	// 到着駅の解析
+	if arriveStationName != nil {
+		stationCandidates := network.StationsByName(*arriveStationName)
+		if len(stationCandidates) != 1 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Error resolving arrive station name."})
+			return 0, 0, false
+		}
+		arriveStationID = &stationCandidates[0].ID
+	}
+
+	// 出発・到着駅IDが異なるか
+	if *departStationID == *arriveStationID {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Departure station ID and arrival station ID must be different."})
+		return 0, 0, false
+	}
+
+	// 出発・到着駅IDが存在するか
+	if _, isFound := network.Station(*departStationID); !isFound {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid depart station ID."})
+		return 0, 0, false
+	}
+	if _, isFound := network.Station(*arriveStationID); !isFound {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid arrive station ID."})
+		return 0, 0, false
+	}
+
+	return *departStationID, *arriveStationID, true
+}
+
+// 探索の条件・上限を検証し、探索条件と返却するルート数の上限を返す
+// 上限の未指定時は既定値(ルート数はdefaultMaxResults、0の場合は無制限)を用い、サーバの上限を超える場合はエラーとする
+// 不正な指定の場合はレスポンスを返し、okにfalseを返す
+func parseSearchOptions(
+	ctx *gin.Context,
+	network *controllers.NetworkIndex,
+	options forms.SearchOptionsForm,
+	limits config.SearchLimits,
+	defaultMaxResults uint,
+	departStationID uint,
+	arriveStationID uint,
+) (controllers.SearchConstraints, uint, bool) {
+	// 探索の上限を決定(未指定の場合は既定値、サーバの上限を超える場合はエラー)
+	maxTransfers, maxTravelMinutes, maxResults, maxWaitMinutes :=
+		limits.MaxTransfers, limits.DefaultTravelMinutes, defaultMaxResults, limits.MaxWaitMinutes
+	for _, option := range []struct {
+		name      string
+		requested *uint
+		limit     uint
+		value     *uint
+	}{
+		{"max_transfers", options.MaxTransfers, limits.MaxTransfers, &maxTransfers},
+		{"max_travel_minutes", options.MaxTravelMinutes, limits.MaxTravelMinutes, &maxTravelMinutes},
+		{"max_results", options.MaxResults, limits.MaxResults, &maxResults},
+		{"max_wait_minutes", options.MaxWaitMinutes, limits.MaxWaitMinutes, &maxWaitMinutes},
+	} {
+		if option.requested == nil {
+			continue
+		}
+		if *option.requested > option.limit {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{
+				Error: fmt.Sprintf("%s exceeds the server limit of %d.", option.name, option.limit),
+			})
+			return controllers.SearchConstraints{}, 0, false
+		}
+		*option.value = *option.requested
+	}
+	if maxTravelMinutes == 0 || (options.MaxResults != nil && *options.MaxResults == 0) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "max_travel_minutes and max_results must be at least 1."})
+		return controllers.SearchConstraints{}, 0, false
+	}
+
+	// 経由駅・避ける駅が存在するか
+	for _, stationID := range options.ViaStationIDs {
+		if _, isFound := network.Station(stationID); !isFound {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid via station ID."})
+			return controllers.SearchConstraints{}, 0, false
+		}
+	}
+	for _, stationID := range options.AvoidStationIDs {
+		if _, isFound := network.Station(stationID); !isFound {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid avoid station ID."})
+			return controllers.SearchConstraints{}, 0, false
+		}
+	}
+
+	// 事業者が存在するか
+	for _, agencyID := range options.AgencyIDs {
+		if _, isFound := network.Agency(agencyID); !isFound {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Invalid agency ID."})
+			return controllers.SearchConstraints{}, 0, false
+		}
+	}
+
+	// 出発・到着駅、経由駅は避ける駅に含められない
+	for _, avoidStationID := range options.AvoidStationIDs {
+		if avoidStationID == departStationID || avoidStationID == arriveStationID {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Departure and arrival stations must not be avoided."})
+			return controllers.SearchConstraints{}, 0, false
+		}
+		for _, viaStationID := range options.ViaStationIDs {
+			if avoidStationID == viaStationID {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: "Via stations must not be avoided."})
+				return controllers.SearchConstraints{}, 0, false
 			}
-			routesView[i] = views.RouteView{
-				Operations: operationsView,
+		}
+	}
+
+	// 車いす利用時は、出発・到着駅が段差なしでホームまで移動できる必要がある
+	if options.Wheelchair {
+		for _, endpoint := range []struct {
+			stationID uint
+			message   string
+		}{
+			{departStationID, "Depart station is not step-free accessible."},
+			{arriveStationID, "Arrive station is not step-free accessible."},
+		} {
+			if station, _ := network.Station(endpoint.stationID); !station.StepFree {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, views.ErrorView{Error: endpoint.message})
+				return controllers.SearchConstraints{}, 0, false
 			}
 		}
+	}
 
-		// 経由駅一覧をDB問い合わせの上、viaStationViewにセット・昇順ソート
-		viaStationsView := make([]views.StationView, 0, len(viaStationsSet))
-		for id := range viaStationsSet {
-			station, err := models.GetStationByID(db, id)
-			if err != nil {
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-				log.Printf("get station with ID: %s", err.Error())
-				return
+	return controllers.SearchConstraints{
+		Wheelchair:      options.Wheelchair,
+		ViaStationIDs:   options.ViaStationIDs,
+		AvoidStationIDs: options.AvoidStationIDs,
+		AvoidTypeIDs:    options.AvoidTrainTypes,
+		AgencyIDs:       options.AgencyIDs,
+		MaxTransfers:    int(maxTransfers),
+		MaxTravel:       time.Duration(maxTravelMinutes) * time.Minute,
+		MaxWait:         time.Duration(maxWaitMinutes) * time.Minute,
+	}, maxResults, true
+}
+
+// 探索結果から、お知らせ・経由駅情報などを問い合わせてレスポンスを生成
+// departDatetimeは、お知らせを取得する期間の始点
+func newTransitSearchView(
+	ctx context.Context,
+	db *sqlx.DB,
+	network *controllers.NetworkIndex,
+	result controllers.TransitSearchResult,
+	departDatetime time.Time,
+) (views.TransitSearchView, error) {
+	routes := result.Routes
+
+	// 経路の所要期間中に有効なお知らせを取得
+	alertsUntil := departDatetime
+	for _, route := range routes {
+		if arriveDatetime := route.Operations[len(route.Operations)-1].ArriveDatetime; arriveDatetime.After(alertsUntil) {
+			alertsUntil = arriveDatetime
+		}
+	}
+	alerts, err := models.GetServiceAlerts(ctx, db, network.ID, departDatetime, alertsUntil)
+	if err != nil {
+		return views.TransitSearchView{}, fmt.Errorf("getServiceAlerts: %w", err)
+	}
+
+	// 路線を対象とするお知らせのため、経路上の列車の所属路線を取得
+	routeTrainIDs := make([]uint, 0, len(routes))
+	for _, route := range routes {
+		for _, operation := range route.Operations {
+			routeTrainIDs = append(routeTrainIDs, operation.TrainID)
+		}
+	}
+	trainLineIDs, err := models.GetTrainLineIDs(ctx, db, routeTrainIDs)
+	if err != nil {
+		return views.TransitSearchView{}, fmt.Errorf("getTrainLineIDs: %w", err)
+	}
+
+	// 経路上の列車を運行する事業者を取得
+	trainAgencyIDs, err := models.GetTrainAgencyIDs(ctx, db, routeTrainIDs)
+	if err != nil {
+		return views.TransitSearchView{}, fmt.Errorf("getTrainAgencyIDs: %w", err)
+	}
+
+	// 乗ったまま移る先の列車の終着駅を取得
+	throughTrainIDs := make([]uint, 0)
+	for _, route := range routes {
+		for _, relation := range route.ThroughServices {
+			throughTrainIDs = append(throughTrainIDs, relation.ToTrainID)
+		}
+	}
+	terminalStationIDs, err := models.GetTrainTerminalStationIDs(ctx, db, throughTrainIDs)
+	if err != nil {
+		return views.TransitSearchView{}, fmt.Errorf("getTrainTerminalStationIDs: %w", err)
+	}
+
+	// 検索結果をroutesViewにセット
+	viaStationsSet := make(map[uint]struct{})
+	routeAgenciesSet := make(map[uint]struct{})
+	routesView := make([]views.RouteView, len(routes))
+	for i, route := range routes {
+		operationsView := make([]views.OperationView, len(route.Operations))
+		for j, operation := range route.Operations {
+			operationsView[j] = views.OperationView{
+				TrainID:         operation.TrainID,
+				Order:           operation.Order,
+				DepartStationID: operation.DepartStationID,
+				DepartDatetime:  operation.DepartDatetime,
+				ArriveStationID: operation.ArriveStationID,
+				ArriveDatetime:  operation.ArriveDatetime,
+				DepartPlatform:  operation.DepartPlatform,
+				ArrivePlatform:  operation.ArrivePlatform,
+			}
+			if agencyID, hasAgency := trainAgencyIDs[operation.TrainID]; hasAgency {
+				operationsView[j].AgencyID = &agencyID
+				routeAgenciesSet[agencyID] = struct{}{}
+			}
+			viaStationsSet[operation.DepartStationID] = struct{}{}
+			viaStationsSet[operation.ArriveStationID] = struct{}{}
+
+			// 「この車両は○○行きになります」の案内
+			if relation, isRelated := route.ThroughServices[j]; isRelated {
+				operationsView[j].ThroughService = &views.ThroughServiceView{
+					Type:                 relation.Type,
+					TrainID:              relation.ToTrainID,
+					DestinationStationID: terminalStationIDs[relation.ToTrainID],
+				}
+				viaStationsSet[terminalStationIDs[relation.ToTrainID]] = struct{}{}
 			}
-			viaStationsView = append(viaStationsView, views.StationView(station))
 		}
-		sort.SliceStable(viaStationsView, func(i, j int) bool {
-			return viaStationsView[i].ID < viaStationsView[j].ID
-		})
+		// 事業者ごとの区間と、事業者が変わる回数
+		agencySections := controllers.AgencySectionsOf(route, trainAgencyIDs)
+		agencySectionsView := make([]views.AgencySectionView, len(agencySections))
+		for k, section := range agencySections {
+			agencySectionsView[k] = views.AgencySectionView(section)
+		}
 
-		// 検索結果リクエストを返却
-		ctx.JSON(http.StatusOK, views.TransitSearchView{
-			Stations: viaStationsView,
-			Routes:   routesView,
-		})
+		routesView[i] = views.RouteView{
+			Operations:     operationsView,
+			Transfers:      route.Transfers,
+			AgencySections: agencySectionsView,
+			AgencyChanges:  max(len(agencySections)-1, 0),
+			Alerts:         newAlertsView(controllers.AlertsForRoute(alerts, route, trainLineIDs), network.Location),
+			Disruptions:    newDisruptionsView(route.AvoidedCancellations, route.AvoidedSuspensions, network.Location),
+		}
+	}
+
+	// 経由駅一覧を鉄道網の索引から取得の上、viaStationViewにセット・昇順ソート
+	viaStationsView := make([]views.StationView, 0, len(viaStationsSet))
+	for id := range viaStationsSet {
+		station, isFound := network.Station(id)
+		if !isFound {
+			return views.TransitSearchView{}, fmt.Errorf("station %d is not in network %s", id, network.Code)
+		}
+		viaStationsView = append(viaStationsView, views.StationView(station))
 	}
+	sort.SliceStable(viaStationsView, func(i, j int) bool {
+		return viaStationsView[i].ID < viaStationsView[j].ID
+	})
+
+	// 経路上の事業者一覧を、鉄道網の索引からID順にセット
+	agenciesView := make([]views.AgencyView, 0, len(routeAgenciesSet))
+	for _, agency := range network.Agencies() {
+		if _, isUsed := routeAgenciesSet[agency.ID]; isUsed {
+			agenciesView = append(agenciesView, views.AgencyView(agency))
+		}
+	}
+
+	return views.TransitSearchView{
+		Stations:    viaStationsView,
+		Agencies:    agenciesView,
+		Routes:      routesView,
+		Disruptions: newDisruptionsView(result.AvoidedCancellations, result.AvoidedSuspensions, network.Location),
+		Complete:    result.Complete,
+	}, nil
 }
 
 func IsEitherNil[T, U any](x *T, y *U) bool {
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"outtech105.com/transit_server/config"
+	"outtech105.com/transit_server/controllers"
+	"outtech105.com/transit_server/forms"
+	"outtech105.com/transit_server/views"
+)
+
+// 探索の上限は、未指定の場合は既定値とし、サーバの上限を超える指定はエラーとする
+func TestParseSearchOptionsLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limits := config.SearchLimits{
+		MaxTransfers:         3,
+		MaxTravelMinutes:     300,
+		MaxWaitMinutes:       60,
+		MaxResults:           10,
+		DefaultTravelMinutes: 180,
+	}
+	value := func(v uint) *uint { return &v }
+
+	tests := []struct {
+		name              string
+		options           forms.SearchOptionsForm
+		defaultMaxResults uint
+		// 探索条件の上限(乗換回数, 所要時間, 待ち時間)とルート数の上限
+		wantTransfers int
+		wantTravel    time.Duration
+		wantWait      time.Duration
+		wantResults   uint
+		wantErr       string
+	}{
+		{
+			name:              "defaults",
+			defaultMaxResults: 5,
+			wantTransfers:     3, wantTravel: 180 * time.Minute, wantWait: 60 * time.Minute, wantResults: 5,
+		},
+		{
+			name:              "unlimited results by default",
+			defaultMaxResults: 0,
+			wantTransfers:     3, wantTravel: 180 * time.Minute, wantWait: 60 * time.Minute, wantResults: 0,
+		},
+		{
+			name: "requested",
+			options: forms.SearchOptionsForm{
+				MaxTransfers: value(1), MaxTravelMinutes: value(60), MaxWaitMinutes: value(10), MaxResults: value(2),
+			},
+			defaultMaxResults: 5,
+			wantTransfers:     1, wantTravel: 60 * time.Minute, wantWait: 10 * time.Minute, wantResults: 2,
+		},
+		{
+			name: "server limits",
+			options: forms.SearchOptionsForm{
+				MaxTransfers: value(3), MaxTravelMinutes: value(300), MaxWaitMinutes: value(60), MaxResults: value(10),
+			},
+			defaultMaxResults: 5,
+			wantTransfers:     3, wantTravel: 300 * time.Minute, wantWait: 60 * time.Minute, wantResults: 10,
+		},
+		{
+			name:              "no transfers",
+			options:           forms.SearchOptionsForm{MaxTransfers: value(0), MaxWaitMinutes: value(0)},
+			defaultMaxResults: 5,
+			wantTransfers:     0, wantTravel: 180 * time.Minute, wantWait: 0, wantResults: 5,
+		},
+		{
+			name:              "too many transfers",
+			options:           forms.SearchOptionsForm{MaxTransfers: value(4)},
+			defaultMaxResults: 5,
+			wantErr:           "max_transfers exceeds the server limit of 3.",
+		},
+		{
+			name:              "too long travel",
+			options:           forms.SearchOptionsForm{MaxTravelMinutes: value(301)},
+			defaultMaxResults: 5,
+			wantErr:           "max_travel_minutes exceeds the server limit of 300.",
+		},
+		{
+			name:              "too many results",
+			options:           forms.SearchOptionsForm{MaxResults: value(11)},
+			defaultMaxResults: 5,
+			wantErr:           "max_results exceeds the server limit of 10.",
+		},
+		{
+			name:              "too long wait",
+			options:           forms.SearchOptionsForm{MaxWaitMinutes: value(61)},
+			defaultMaxResults: 5,
+			wantErr:           "max_wait_minutes exceeds the server limit of 60.",
+		},
+		{
+			name:              "zero travel",
+			options:           forms.SearchOptionsForm{MaxTravelMinutes: value(0)},
+			defaultMaxResults: 5,
+			wantErr:           "max_travel_minutes and max_results must be at least 1.",
+		},
+		{
+			name:              "zero results",
+			options:           forms.SearchOptionsForm{MaxResults: value(0)},
+			defaultMaxResults: 5,
+			wantErr:           "max_travel_minutes and max_results must be at least 1.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+
+			constraints, maxResults, ok := parseSearchOptions(ctx, &controllers.NetworkIndex{}, tt.options, limits, tt.defaultMaxResults, 1, 2)
+			if tt.wantErr != "" {
+				var errorView views.ErrorView
+				if err := json.Unmarshal(w.Body.Bytes(), &errorView); err != nil {
+					t.Fatalf("unmarshal response %q: %v", w.Body.String(), err)
+				}
+				if ok || w.Code != http.StatusBadRequest || errorView.Error != tt.wantErr {
+					t.Fatalf("parseSearchOptions() = ok %v, %d %q, want %d %q", ok, w.Code, errorView.Error, http.StatusBadRequest, tt.wantErr)
+				}
+				return
+			}
+
+			if !ok {
+				t.Fatalf("parseSearchOptions() failed: %d %s", w.Code, w.Body.String())
+			}
+			if constraints.MaxTransfers != tt.wantTransfers || constraints.MaxTravel != tt.wantTravel || constraints.MaxWait != tt.wantWait {
+				t.Errorf("constraints = transfers %d, travel %v, wait %v, want %d, %v, %v",
+					constraints.MaxTransfers, constraints.MaxTravel, constraints.MaxWait, tt.wantTransfers, tt.wantTravel, tt.wantWait)
+			}
+			if maxResults != tt.wantResults {
+				t.Errorf("max results = %d, want %d", maxResults, tt.wantResults)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DBのagenciesスキーマに対応
+type Agency struct {
+	ID         uint   `db:"id"`
+	Name       string `db:"name"`
+	EngName    string `db:"name_en"`
+	URL        string `db:"url"`
+	FareSystem string `db:"fare_system"` // 運賃体系の識別子
+	Color      string `db:"color"`       // 表示色(#RRGGBB)
+}
+
+// 鉄道網の事業者一覧をID順に返す
+func GetAgencies(ctx context.Context, db *sqlx.DB, networkID uint) ([]Agency, error) {
+	agencies := make([]Agency, 0, 10)
+	query := `SELECT id, name, name_en, url, fare_system, color FROM agencies WHERE network_id = ? ORDER BY id`
+	if err := db.SelectContext(ctx, &agencies, db.Rebind(query), networkID); err != nil {
+		return nil, fmt.Errorf("selectAgencies: %w", err)
+	}
+	return agencies, nil
+}
+
+// 列車IDから、運行する事業者IDへの対応を返す(事業者未設定の列車は含まない)
+// 列車に事業者が設定されていない場合は、所属路線の事業者とする
+func GetTrainAgencyIDs(ctx context.Context, db *sqlx.DB, trainIDs []uint) (map[uint]uint, error) {
+	trainAgencyIDs := make(map[uint]uint, len(trainIDs))
+	if len(trainIDs) == 0 {
+		return trainAgencyIDs, nil
+	}
+
+	// NOTE: LINESはMySQLの予約語のため、バッククォートで囲む
+	query, args, err := sqlx.In(
+		"SELECT t.id, COALESCE(t.agency_id, l.agency_id) FROM trains t LEFT JOIN "+quoteIdentifier(db.DriverName(), "lines")+" l ON l.id = t.line_id "+
+			"WHERE t.id IN (?) AND COALESCE(t.agency_id, l.agency_id) IS NOT NULL",
+		trainIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("buildQuery: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("executeQuery: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trainID, agencyID uint
+		if err := rows.Scan(&trainID, &agencyID); err != nil {
+			return nil, fmt.Errorf("scanRecord: %w", err)
+		}
+		trainAgencyIDs[trainID] = agencyID
+	}
+
+	return trainAgencyIDs, rows.Err()
+}
+
+// 鉄道網の列車のうち、指定した事業者のいずれかが運行する列車のID集合を返す
+func GetTrainIDsByAgencyIDs(ctx context.Context, db *sqlx.DB, networkID uint, agencyIDs []uint) (map[uint]struct{}, error) {
+	trainIDs := make(map[uint]struct{})
+	if len(agencyIDs) == 0 {
+		return trainIDs, nil
+	}
+
+	query, args, err := sqlx.In(
+		"SELECT t.id FROM trains t LEFT JOIN "+quoteIdentifier(db.DriverName(), "lines")+" l ON l.id = t.line_id "+
+			"WHERE t.network_id = ? AND COALESCE(t.agency_id, l.agency_id) IN (?)",
+		networkID,
+		agencyIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("buildQuery: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("executeQuery: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trainID uint
+		if err := rows.Scan(&trainID); err != nil {
+			return nil, fmt.Errorf("scanRecord: %w", err)
+		}
+		trainIDs[trainID] = struct{}{}
+	}
+
+	return trainIDs, rows.Err()
+}
@@ -0,0 +1,251 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// お知らせの重要度
+const (
+	AlertSeverityInfo    = "info"
+	AlertSeverityWarning = "warning"
+	AlertSeveritySevere  = "severe"
+)
+
+// お知らせの影響対象の種類
+const (
+	AlertEntityStation = "station"
+	AlertEntityTrain   = "train"
+	AlertEntityLine    = "line"
+)
+
+var (
+	ErrAlertStationIDMissing = errors.New("invalid alert station ID")
+	ErrAlertLineIDMissing    = errors.New("invalid alert line ID")
+)
+
+// DBのservice_alertsスキーマに対応
+// 文言はservice_alert_textsから、影響対象はservice_alert_entitiesから取得する
+type ServiceAlert struct {
+	ID          uint                 `db:"id"`
+	Severity    string               `db:"severity"`
+	Texts       map[string]AlertText `db:"-"` // ロケール(ja・en等)から、その言語の文言への対応
+	ActiveFrom  *time.Time           `db:"active_from"`
+	ActiveUntil *time.Time           `db:"active_until"`
+	StationIDs  []uint               `db:"-"`
+	TrainIDs    []uint               `db:"-"`
+	LineIDs     []uint               `db:"-"`
+}
+
+// DBのservice_alert_textsスキーマに対応
+type AlertText struct {
+	Header      string `db:"header"`
+	Description string `db:"description"`
+}
+
+// 指定期間(from〜until)に有効な、鉄道網のお知らせを取得
+func GetServiceAlerts(ctx context.Context, db *sqlx.DB, networkID uint, from time.Time, until time.Time) ([]ServiceAlert, error) {
+	alerts := make([]ServiceAlert, 0, 10)
+	query := `
+SELECT id, severity, active_from, active_until
+FROM service_alerts
+WHERE network_id = ?
+AND (active_from IS NULL OR active_from <= ?)
+AND (active_until IS NULL OR active_until > ?)
+ORDER BY id
+`
+	if err := db.SelectContext(ctx, &alerts, db.Rebind(query), networkID, until, from); err != nil {
+		return nil, fmt.Errorf("selectAlerts: %w", err)
+	}
+	if len(alerts) == 0 {
+		return alerts, nil
+	}
+
+	alertIndexes := make(map[uint]int, len(alerts))
+	alertIDs := make([]uint, len(alerts))
+	for i, alert := range alerts {
+		alertIndexes[alert.ID] = i
+		alertIDs[i] = alert.ID
+		alerts[i].Texts = make(map[string]AlertText)
+	}
+
+	// 文言を取得し、各お知らせに振り分け
+	query, args, err := sqlx.In(
+		`SELECT alert_id, locale, header, description FROM service_alert_texts WHERE alert_id IN (?)`,
+		alertIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("buildTextsQuery: %w", err)
+	}
+	textRows, err := db.QueryContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("selectTexts: %w", err)
+	}
+	defer textRows.Close()
+
+	for textRows.Next() {
+		var (
+			alertID uint
+			locale  string
+			text    AlertText
+		)
+		if err := textRows.Scan(&alertID, &locale, &text.Header, &text.Description); err != nil {
+			return nil, fmt.Errorf("scanText: %w", err)
+		}
+		alerts[alertIndexes[alertID]].Texts[locale] = text
+	}
+	if err := textRows.Err(); err != nil {
+		return nil, fmt.Errorf("selectTexts: %w", err)
+	}
+
+	// 影響対象を取得し、各お知らせに振り分け
+	query, args, err = sqlx.In(
+		`SELECT alert_id, entity_type, entity_id FROM service_alert_entities WHERE alert_id IN (?) ORDER BY entity_id`,
+		alertIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("buildEntitiesQuery: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("selectEntities: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			alertID    uint
+			entityType string
+			entityID   uint
+		)
+		if err := rows.Scan(&alertID, &entityType, &entityID); err != nil {
+			return nil, fmt.Errorf("scanRecord: %w", err)
+		}
+
+		alert := &alerts[alertIndexes[alertID]]
+		switch entityType {
+		case AlertEntityStation:
+			alert.StationIDs = append(alert.StationIDs, entityID)
+		case AlertEntityTrain:
+			alert.TrainIDs = append(alert.TrainIDs, entityID)
+		case AlertEntityLine:
+			alert.LineIDs = append(alert.LineIDs, entityID)
+		}
+	}
+
+	return alerts, rows.Err()
+}
+
+// お知らせの影響対象の種類ごとの、存在チェックに用いるテーブルとエラー
+var alertEntityTables = []struct {
+	entityType string
+	table      string
+	err        error
+}{
+	{entityType: AlertEntityStation, table: "stations", err: ErrAlertStationIDMissing},
+	{entityType: AlertEntityTrain, table: "trains", err: ErrTrainIDMissing},
+	{entityType: AlertEntityLine, table: "lines", err: ErrAlertLineIDMissing},
+}
+
+// 鉄道網のお知らせを文言・影響対象とともに登録し、採番されたIDを返す
+// NOTE: 影響対象に他の鉄道網(または存在しない)駅・列車・路線が含まれる場合は、登録せずにエラーを返す
+func CreateServiceAlert(ctx context.Context, db *sqlx.DB, networkID uint, alert ServiceAlert) (uint, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id, err := insertReturningID(
+		ctx,
+		tx,
+		`INSERT INTO service_alerts (network_id, severity, active_from, active_until) VALUES (?, ?, ?, ?)`,
+		networkID,
+		alert.Severity,
+		alert.ActiveFrom,
+		alert.ActiveUntil,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insertAlert: %w", err)
+	}
+
+	for locale, text := range alert.Texts {
+		_, err := tx.ExecContext(
+			ctx,
+			tx.Rebind(`INSERT INTO service_alert_texts (alert_id, locale, header, description) VALUES (?, ?, ?, ?)`),
+			id,
+			locale,
+			text.Header,
+			text.Description,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("insertText: %w", err)
+		}
+	}
+
+	entities := map[string][]uint{
+		AlertEntityStation: alert.StationIDs,
+		AlertEntityTrain:   alert.TrainIDs,
+		AlertEntityLine:    alert.LineIDs,
+	}
+	// NOTE: DBの種類に依らないよう、重複する影響対象はINSERT前に除く
+	for entityType, entityIDs := range entities {
+		inserted := make(map[uint]struct{}, len(entityIDs))
+		for _, entityID := range entityIDs {
+			if _, isInserted := inserted[entityID]; isInserted {
+				continue
+			}
+			inserted[entityID] = struct{}{}
+
+			_, err := tx.ExecContext(
+				ctx,
+				tx.Rebind(`INSERT INTO service_alert_entities (alert_id, entity_type, entity_id) VALUES (?, ?, ?)`),
+				id,
+				entityType,
+				entityID,
+			)
+			if err != nil {
+				return 0, fmt.Errorf("insertEntity: %w", err)
+			}
+		}
+	}
+
+	// 影響対象が、鉄道網に所属しているか確認
+	for _, entity := range alertEntityTables {
+		var outside int
+		err := tx.QueryRowContext(
+			ctx,
+			tx.Rebind(`
+SELECT COUNT(*) FROM service_alert_entities
+WHERE alert_id = ? AND entity_type = ? AND entity_id NOT IN (SELECT id FROM `+quoteIdentifier(tx.DriverName(), entity.table)+` WHERE network_id = ?)
+`),
+			id,
+			entity.entityType,
+			networkID,
+		).Scan(&outside)
+		if err != nil {
+			return 0, fmt.Errorf("checkEntities: %w", err)
+		}
+		if outside > 0 {
+			return 0, entity.err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// 鉄道網のお知らせを削除(文言・影響対象はCASCADEで削除される)
+func DeleteServiceAlert(ctx context.Context, db *sqlx.DB, networkID uint, id uint) error {
+	result, err := db.ExecContext(ctx, db.Rebind(`DELETE FROM service_alerts WHERE id = ? AND network_id = ?`), id, networkID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
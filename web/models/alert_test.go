@@ -0,0 +1,115 @@
+package models
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestCreateServiceAlert(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend testBackend, db *sqlx.DB) {
+		ctx := context.Background()
+		lines := quoteIdentifier(db.DriverName(), "lines")
+		for _, query := range []string{
+			`INSERT INTO ` + lines + ` (id, network_id, name, name_en) VALUES (1, 1, '本線', 'Main Line')`,
+			`INSERT INTO ` + lines + ` (id, network_id, name, name_en) VALUES (2, 2, '支線', 'Branch Line')`,
+		} {
+			if _, err := db.Exec(query); err != nil {
+				t.Fatalf("exec %q: %v", query, err)
+			}
+		}
+		created := make([]uint, 0, 1)
+		t.Cleanup(func() {
+			for _, id := range created {
+				if _, err := db.Exec(db.Rebind(`DELETE FROM service_alerts WHERE id = ?`), id); err != nil {
+					t.Errorf("cleanup alert %d: %v", id, err)
+				}
+			}
+			if _, err := db.Exec(`DELETE FROM ` + lines + ` WHERE id IN (1, 2)`); err != nil {
+				t.Errorf("cleanup lines: %v", err)
+			}
+		})
+
+		texts := map[string]AlertText{
+			"ja": {Header: "工事のお知らせ", Description: "番線を変更します。"},
+			"en": {Header: "Construction notice"},
+		}
+		tests := []struct {
+			name    string
+			alert   ServiceAlert
+			wantErr error
+		}{
+			{
+				name:  "entities in network",
+				alert: ServiceAlert{Severity: AlertSeverityWarning, Texts: texts, StationIDs: []uint{2, 1, 2}, TrainIDs: []uint{101}, LineIDs: []uint{1}},
+			},
+			{
+				name:    "station of other network",
+				alert:   ServiceAlert{Severity: AlertSeverityInfo, Texts: texts, StationIDs: []uint{1, 5}},
+				wantErr: ErrAlertStationIDMissing,
+			},
+			{
+				name:    "unknown station",
+				alert:   ServiceAlert{Severity: AlertSeverityInfo, Texts: texts, StationIDs: []uint{99}},
+				wantErr: ErrAlertStationIDMissing,
+			},
+			{
+				name:    "train of other network",
+				alert:   ServiceAlert{Severity: AlertSeverityInfo, Texts: texts, TrainIDs: []uint{201}},
+				wantErr: ErrTrainIDMissing,
+			},
+			{
+				name:    "line of other network",
+				alert:   ServiceAlert{Severity: AlertSeverityInfo, Texts: texts, LineIDs: []uint{2}},
+				wantErr: ErrAlertLineIDMissing,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				id, err := CreateServiceAlert(ctx, db, 1, tt.alert)
+				if err != tt.wantErr {
+					t.Fatalf("CreateServiceAlert() error = %v, want %v", err, tt.wantErr)
+				}
+				if err == nil {
+					created = append(created, id)
+				}
+			})
+		}
+
+		// 影響対象が不正なお知らせは、文言も含めて登録されない
+		alerts, err := GetServiceAlerts(ctx, db, 1, testDatetime(0, 0), testDatetime(0, 0))
+		if err != nil {
+			t.Fatalf("GetServiceAlerts: %v", err)
+		}
+		if len(alerts) != 1 || len(created) != 1 || alerts[0].ID != created[0] {
+			t.Fatalf("alerts = %+v, want only the alert %v", alerts, created)
+		}
+		got := alerts[0]
+		if !reflect.DeepEqual(got.Texts, texts) {
+			t.Errorf("texts = %v, want %v", got.Texts, texts)
+		}
+		if !reflect.DeepEqual(got.StationIDs, []uint{1, 2}) || !reflect.DeepEqual(got.TrainIDs, []uint{101}) || !reflect.DeepEqual(got.LineIDs, []uint{1}) {
+			t.Errorf("entities = %v %v %v, want [1 2] [101] [1]", got.StationIDs, got.TrainIDs, got.LineIDs)
+		}
+
+		// 他の鉄道網からは取得・削除できない
+		if alerts, err := GetServiceAlerts(ctx, db, 2, testDatetime(0, 0), testDatetime(0, 0)); err != nil || len(alerts) != 0 {
+			t.Errorf("GetServiceAlerts(network 2) = %v, %v, want none", alerts, err)
+		}
+		if err := DeleteServiceAlert(ctx, db, 2, got.ID); err == nil {
+			t.Error("DeleteServiceAlert(network 2) succeeded, want error")
+		}
+		if err := DeleteServiceAlert(ctx, db, 1, got.ID); err != nil {
+			t.Fatalf("DeleteServiceAlert: %v", err)
+		}
+		var remaining int
+		if err := db.Get(&remaining, db.Rebind(`SELECT COUNT(*) FROM service_alert_texts WHERE alert_id = ?`), got.ID); err != nil {
+			t.Fatalf("count texts: %v", err)
+		}
+		if remaining != 0 {
+			t.Errorf("%d texts remain after delete, want 0", remaining)
+		}
+	})
+}
@@ -0,0 +1,243 @@
+package models
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"outtech105.com/transit_server/database"
+)
+
+// 駅・時刻表の取得先として対応するDB
+// NOTE: MySQL・PostgreSQLは、環境変数に接続先がある場合のみテストする(テスト専用の空のDBを指定する)
+//
+//	TEST_MYSQL_DSN    例: user:password@tcp(localhost:3306)/transit_test?parseTime=true
+//	TEST_POSTGRES_DSN 例: host=localhost user=postgres password=postgres dbname=transit_test sslmode=disable
+type testBackend struct {
+	name       string
+	driverName string
+	dsnEnv     string                         // 接続先の環境変数(SQLiteは空文字列)
+	newRepos   func(db *sqlx.DB) Repositories // DBの種類に応じた取得先
+	queries    dialectQueries
+}
+
+var testBackends = []testBackend{
+	{name: "sqlite", driverName: "sqlite3", newRepos: NewSQLiteRepositories, queries: sqliteQueries},
+	{name: "mysql", driverName: "mysql", dsnEnv: "TEST_MYSQL_DSN", newRepos: NewMySQLRepositories, queries: mysqlQueries},
+	{name: "postgres", driverName: "postgres", dsnEnv: "TEST_POSTGRES_DSN", newRepos: NewPostgresRepositories, queries: postgresQueries},
+}
+
+// テスト用のDBに接続する(SQLiteは一時ディレクトリに作成し、他は接続先が無ければスキップする)
+func (b testBackend) connect(t *testing.T) *sqlx.DB {
+	t.Helper()
+	var db *sqlx.DB
+	var err error
+	if b.dsnEnv == "" {
+		db, err = database.ConnectSQLite(filepath.Join(t.TempDir(), "test.db"), true)
+	} else {
+		dsn := os.Getenv(b.dsnEnv)
+		if dsn == "" {
+			t.Skipf("%s is not set", b.dsnEnv)
+		}
+		db, err = sqlx.Connect(b.driverName, dsn)
+	}
+	if err != nil {
+		t.Fatalf("connect %s: %v", b.name, err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// すべてのDBで、テスト用の駅・時刻表を登録してfnを実行する
+func forEachBackend(t *testing.T, fn func(t *testing.T, backend testBackend, db *sqlx.DB)) {
+	for _, backend := range testBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			db := backend.connect(t)
+			setupTestTimetable(t, db)
+			fn(t, backend, db)
+		})
+	}
+}
+
+// 各DBから直接取得した結果・メモリに読み込んだ結果と、NewMemoryRepositoriesで生成した結果が一致するか
+func TestRepositoryConformance(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		repos, err := NewMemoryRepositories(testStations, testTrains)
+		if err != nil {
+			t.Fatalf("NewMemoryRepositories: %v", err)
+		}
+		testRepositories(t, repos)
+	})
+
+	forEachBackend(t, func(t *testing.T, backend testBackend, db *sqlx.DB) {
+		t.Run("sql", func(t *testing.T) {
+			testRepositories(t, backend.newRepos(db))
+		})
+		t.Run("loaded", func(t *testing.T) {
+			repos, err := LoadMemoryRepositories(context.Background(), db)
+			if err != nil {
+				t.Fatalf("LoadMemoryRepositories: %v", err)
+			}
+			testRepositories(t, repos)
+		})
+	})
+}
+
+// DBごとのSQLが、引数の数どおりに記述され、そのDBで実行できるか
+func TestDialectQueries(t *testing.T) {
+	queries := []struct {
+		name  string
+		query func(q dialectQueries) string
+		args  []any
+	}{
+		{name: "stationsByKeyword", query: func(q dialectQueries) string { return q.stationsByKeyword }, args: []any{1, "%o%", "%o%"}},
+		{name: "nextDepartOperations", query: func(q dialectQueries) string { return q.nextDepartOperations }, args: []any{8 * 3600, 1}},
+		{name: "runningOperations", query: func(q dialectQueries) string { return q.runningOperations }, args: []any{1, 8 * 3600, 8 * 3600, 8 * 3600, 8 * 3600}},
+		{name: "stationDepartures", query: func(q dialectQueries) string { return q.stationDepartures }, args: []any{2, 8 * 3600, 3}},
+	}
+
+	forEachBackend(t, func(t *testing.T, backend testBackend, db *sqlx.DB) {
+		for _, tt := range queries {
+			t.Run(tt.name, func(t *testing.T) {
+				query := tt.query(backend.queries)
+				if got := strings.Count(query, "?"); got != len(tt.args) {
+					t.Fatalf("placeholders = %d, want %d", got, len(tt.args))
+				}
+				rows, err := db.QueryxContext(context.Background(), db.Rebind(query), tt.args...)
+				if err != nil {
+					t.Fatalf("query: %v", err)
+				}
+				defer rows.Close()
+				count := 0
+				for rows.Next() {
+					count++
+				}
+				if err := rows.Err(); err != nil {
+					t.Fatalf("rows: %v", err)
+				}
+				if count == 0 {
+					t.Errorf("query returned no rows")
+				}
+			})
+		}
+	})
+}
+
+// 次の発車の取得は、時刻表全体を走査せず、発駅のインデックスで停車駅を絞り込む
+func TestNextDepartOperationsSearchesByStation(t *testing.T) {
+	db := testBackends[0].connect(t)
+	setupTestTimetable(t, db)
+
+	rows, err := db.Queryx(`EXPLAIN QUERY PLAN `+sqliteQueries.nextDepartOperations, 8*3600, 1)
+	if err != nil {
+		t.Fatalf("explain: %v", err)
+	}
+	defer rows.Close()
+	plan := make([]string, 0)
+	for rows.Next() {
+		var (
+			id, parent, notused int
+			detail              string
+		)
+		if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		plan = append(plan, detail)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows: %v", err)
+	}
+	for _, detail := range plan {
+		if strings.HasPrefix(detail, "SCAN stop_times") || strings.HasPrefix(detail, "SCAN dep") || strings.HasPrefix(detail, "SCAN arr") {
+			t.Errorf("plan scans stop_times: %q", plan)
+		}
+	}
+	if !strings.Contains(strings.Join(plan, "\n"), "SEARCH dep USING INDEX") {
+		t.Errorf("plan = %q, want a search of dep by index", plan)
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		driverName string
+		want       string
+	}{
+		{driverName: "mysql", want: "`lines`"},
+		{driverName: "sqlite3", want: "`lines`"},
+		{driverName: "postgres", want: `"lines"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.driverName, func(t *testing.T) {
+			if got := quoteIdentifier(tt.driverName, "lines"); got != tt.want {
+				t.Errorf("quoteIdentifier(%q) = %s, want %s", tt.driverName, got, tt.want)
+			}
+		})
+	}
+
+	// 予約語のテーブル名を、各DBで参照できるか
+	forEachBackend(t, func(t *testing.T, backend testBackend, db *sqlx.DB) {
+		var count int
+		if err := db.Get(&count, "SELECT COUNT(*) FROM "+quoteIdentifier(db.DriverName(), "lines")); err != nil {
+			t.Fatalf("select lines: %v", err)
+		}
+	})
+}
+
+// 採番されたidを返し、トランザクション内でも使用できるか
+func TestInsertReturningID(t *testing.T) {
+	const query = `INSERT INTO agencies (network_id, name, name_en) VALUES (?, ?, ?)`
+
+	forEachBackend(t, func(t *testing.T, backend testBackend, db *sqlx.DB) {
+		ctx := context.Background()
+		t.Cleanup(func() {
+			if _, err := db.Exec(db.Rebind(`DELETE FROM agencies WHERE name LIKE ?`), "テスト事業者%"); err != nil {
+				t.Errorf("cleanup agencies: %v", err)
+			}
+		})
+
+		first, err := insertReturningID(ctx, db, query, 1, "テスト事業者1", "Test agency 1")
+		if err != nil {
+			t.Fatalf("insertReturningID: %v", err)
+		}
+		second, err := insertReturningID(ctx, db, query, 1, "テスト事業者2", "Test agency 2")
+		if err != nil {
+			t.Fatalf("insertReturningID: %v", err)
+		}
+		if first <= 0 || second <= first {
+			t.Fatalf("ids = %d, %d, want increasing positive ids", first, second)
+		}
+		var name string
+		if err := db.Get(&name, db.Rebind(`SELECT name FROM agencies WHERE id = ?`), second); err != nil {
+			t.Fatalf("select agency: %v", err)
+		}
+		if name != "テスト事業者2" {
+			t.Errorf("agency %d name = %q, want テスト事業者2", second, name)
+		}
+
+		// ロールバックした行は残らない
+		tx, err := db.BeginTxx(ctx, nil)
+		if err != nil {
+			t.Fatalf("begin: %v", err)
+		}
+		id, err := insertReturningID(ctx, tx, query, 1, "テスト事業者3", "Test agency 3")
+		if err != nil {
+			tx.Rollback()
+			t.Fatalf("insertReturningID in transaction: %v", err)
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Fatalf("rollback: %v", err)
+		}
+		var count int
+		if err := db.Get(&count, db.Rebind(`SELECT COUNT(*) FROM agencies WHERE id = ?`), id); err != nil {
+			t.Fatalf("count agencies: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("agency %d remains after rollback", id)
+		}
+	})
+}
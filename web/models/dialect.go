@@ -0,0 +1,32 @@
+package models
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 識別子(テーブル名など)を、DBの種類に応じた引用符で囲む
+// NOTE: linesはMySQLの予約語のため引用符が必要だが、PostgreSQLはバッククォートに対応しない
+func quoteIdentifier(driverName string, name string) string {
+	if sqlx.BindType(driverName) == sqlx.DOLLAR {
+		return `"` + name + `"`
+	}
+	return "`" + name + "`"
+}
+
+// INSERTを実行し、採番されたidを返す(問い合わせの引数は?で記述する)
+// NOTE: PostgreSQL(lib/pq)はLastInsertIdに対応しないため、RETURNING句で取得する
+func insertReturningID(ctx context.Context, db sqlx.ExtContext, query string, args ...any) (int64, error) {
+	if sqlx.BindType(db.DriverName()) == sqlx.DOLLAR {
+		var id int64
+		err := db.QueryRowxContext(ctx, db.Rebind(query+" RETURNING id"), args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := db.ExecContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DBのtrain_cancellationsスキーマに対応
+type TrainCancellation struct {
+	TrainID     uint      `db:"train_id"`
+	ServiceDate time.Time `db:"service_date"`
+	Reason      string    `db:"reason"`
+}
+
+// DBのsegment_suspensionsスキーマに対応
+// NOTE: 駅A-B間の上下線両方に適用される
+type SegmentSuspension struct {
+	ID            uint      `db:"id"`
+	StationIDA    uint      `db:"sta_id_a"`
+	StationIDB    uint      `db:"sta_id_b"`
+	StartDatetime time.Time `db:"start_datetime"`
+	EndDatetime   time.Time `db:"end_datetime"`
+	Reason        string    `db:"reason"`
+}
+
+// 運休・運転見合わせ情報の集合
+type Disruptions struct {
+	Cancellations []TrainCancellation
+	Suspensions   []SegmentSuspension
+}
+
+// 指定日時以降に影響しうる、鉄道網の運休・運転見合わせ情報を取得
+// NOTE: 運休は列車、運転見合わせは駅A(駅Bも同じ鉄道網)の所属する鉄道網で判定する
+func GetDisruptions(ctx context.Context, db *sqlx.DB, networkID uint, since time.Time) (Disruptions, error) {
+	disruptions := Disruptions{
+		Cancellations: make([]TrainCancellation, 0, 10),
+		Suspensions:   make([]SegmentSuspension, 0, 10),
+	}
+
+	// 日付を跨ぐ運行を考慮し、前日分の運休から取得する
+	err := db.SelectContext(
+		ctx,
+		&disruptions.Cancellations,
+		db.Rebind(`
+SELECT c.train_id, c.service_date, c.reason
+FROM train_cancellations c
+INNER JOIN trains t ON t.id = c.train_id
+WHERE t.network_id = ? AND c.service_date >= ?
+ORDER BY c.service_date, c.train_id
+`),
+		networkID,
+		since.AddDate(0, 0, -1).Format("2006-01-02"),
+	)
+	if err != nil {
+		return Disruptions{}, fmt.Errorf("selectCancellations: %w", err)
+	}
+
+	err = db.SelectContext(
+		ctx,
+		&disruptions.Suspensions,
+		db.Rebind(`
+SELECT ss.id, ss.sta_id_a, ss.sta_id_b, ss.start_datetime, ss.end_datetime, ss.reason
+FROM segment_suspensions ss
+INNER JOIN stations s ON s.id = ss.sta_id_a
+WHERE s.network_id = ? AND ss.end_datetime > ?
+ORDER BY ss.start_datetime, ss.id
+`),
+		networkID,
+		since,
+	)
+	if err != nil {
+		return Disruptions{}, fmt.Errorf("selectSuspensions: %w", err)
+	}
+
+	return disruptions, nil
+}
+
+// 1区間移動に影響する運休情報を返す
+// NOTE: 運休日は、列車の運行日(未設定の場合は、その区間の出発日時が属する運行日)で判定する
+func (d Disruptions) CancellationOf(op Operation) (TrainCancellation, bool) {
+	serviceDate := op.ServiceDate
+	if serviceDate.IsZero() {
+		serviceDate = ServiceDate(op.DepartDatetime)
+	}
+	for _, c := range d.Cancellations {
+		if c.TrainID == op.TrainID && c.ServiceDate.Format("2006-01-02") == serviceDate.Format("2006-01-02") {
+			return c, true
+		}
+	}
+	return TrainCancellation{}, false
+}
+
+// 1区間移動に影響する運転見合わせ情報を返す
+// 見合わせ期間と、区間の出発〜到着時刻が重なる場合に影響ありとする
+func (d Disruptions) SuspensionOf(op Operation) (SegmentSuspension, bool) {
+	for _, s := range d.Suspensions {
+		isSameSegment := (s.StationIDA == op.DepartStationID && s.StationIDB == op.ArriveStationID) ||
+			(s.StationIDA == op.ArriveStationID && s.StationIDB == op.DepartStationID)
+		if !isSameSegment {
+			continue
+		}
+		if op.DepartDatetime.Before(s.EndDatetime) && s.StartDatetime.Before(op.ArriveDatetime) {
+			return s, true
+		}
+	}
+	return SegmentSuspension{}, false
+}
+
+// 運休情報を登録(同一列車・同一日の登録は理由を上書き)
+// NOTE: DBの種類に依らないよう、上書きは削除・登録をトランザクションで行う
+func CreateTrainCancellation(ctx context.Context, db *sqlx.DB, c TrainCancellation) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	serviceDate := c.ServiceDate.Format("2006-01-02")
+	if _, err := tx.ExecContext(ctx, tx.Rebind(`DELETE FROM train_cancellations WHERE train_id = ? AND service_date = ?`), c.TrainID, serviceDate); err != nil {
+		return fmt.Errorf("deleteCancellation: %w", err)
+	}
+	if _, err := tx.ExecContext(
+		ctx,
+		tx.Rebind(`INSERT INTO train_cancellations (train_id, service_date, reason) VALUES (?, ?, ?)`),
+		c.TrainID,
+		serviceDate,
+		c.Reason,
+	); err != nil {
+		return fmt.Errorf("insertCancellation: %w", err)
+	}
+	return tx.Commit()
+}
+
+// 鉄道網の列車の運休情報を削除
+func DeleteTrainCancellation(ctx context.Context, db *sqlx.DB, networkID uint, trainID uint, serviceDate time.Time) error {
+	result, err := db.ExecContext(
+		ctx,
+		db.Rebind(`
+DELETE FROM train_cancellations
+WHERE train_id IN (SELECT id FROM trains WHERE network_id = ?) AND train_id = ? AND service_date = ?
+`),
+		networkID,
+		trainID,
+		serviceDate.Format("2006-01-02"),
+	)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// 運転見合わせ情報を登録し、採番されたIDを返す
+func CreateSegmentSuspension(ctx context.Context, db *sqlx.DB, s SegmentSuspension) (uint, error) {
+	id, err := insertReturningID(
+		ctx,
+		db,
+		`INSERT INTO segment_suspensions (sta_id_a, sta_id_b, start_datetime, end_datetime, reason) VALUES (?, ?, ?, ?, ?)`,
+		s.StationIDA,
+		s.StationIDB,
+		s.StartDatetime,
+		s.EndDatetime,
+		s.Reason,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// 鉄道網の運転見合わせ情報を削除
+func DeleteSegmentSuspension(ctx context.Context, db *sqlx.DB, networkID uint, id uint) error {
+	result, err := db.ExecContext(
+		ctx,
+		db.Rebind(`
+DELETE FROM segment_suspensions
+WHERE sta_id_a IN (SELECT id FROM stations WHERE network_id = ?) AND id = ?
+`),
+		networkID,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// 更新・削除対象の行が存在したか確認(存在しない場合はsql.ErrNoRows)
+func checkAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
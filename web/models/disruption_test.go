@@ -0,0 +1,110 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// 運休は、列車の運行日(24:00以降の時刻は前日の運行日)で判定する
+func TestCancellationOf(t *testing.T) {
+	setTestServiceDayStart(t, 4*time.Hour)
+	disruptions := Disruptions{Cancellations: []TrainCancellation{
+		{TrainID: 1, ServiceDate: testDate(1), Reason: "車両故障"},
+	}}
+
+	tests := []struct {
+		name string
+		op   Operation
+		want bool
+	}{
+		{
+			name: "same service date",
+			op:   Operation{TrainID: 1, DepartDatetime: testDayDatetime(1, 8, 0), ServiceDate: testDate(1)},
+			want: true,
+		},
+		{
+			name: "after midnight of the service date",
+			op:   Operation{TrainID: 1, DepartDatetime: testDayDatetime(2, 0, 30), ServiceDate: testDate(1)},
+			want: true,
+		},
+		{
+			name: "after midnight without service date",
+			op:   Operation{TrainID: 1, DepartDatetime: testDayDatetime(2, 3, 59)},
+			want: true,
+		},
+		{
+			name: "service day start without service date",
+			op:   Operation{TrainID: 1, DepartDatetime: testDayDatetime(2, 4, 0)},
+			want: false,
+		},
+		{
+			// 前日の運行日の列車が、0:00を過ぎて走る場合
+			name: "previous service date after midnight",
+			op:   Operation{TrainID: 1, DepartDatetime: testDayDatetime(1, 0, 30), ServiceDate: testDate(1).AddDate(0, 0, -1)},
+			want: false,
+		},
+		{
+			name: "before service day start without service date",
+			op:   Operation{TrainID: 1, DepartDatetime: testDayDatetime(1, 3, 59)},
+			want: false,
+		},
+		{
+			name: "next service date",
+			op:   Operation{TrainID: 1, DepartDatetime: testDayDatetime(2, 8, 0), ServiceDate: testDate(2)},
+			want: false,
+		},
+		{
+			name: "other train",
+			op:   Operation{TrainID: 2, DepartDatetime: testDayDatetime(1, 8, 0), ServiceDate: testDate(1)},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, got := disruptions.CancellationOf(tt.op)
+			if got != tt.want {
+				t.Fatalf("CancellationOf() = %v, want %v", got, tt.want)
+			}
+			if got && c.Reason != "車両故障" {
+				t.Errorf("cancellation = %+v, want the cancellation of train 1", c)
+			}
+		})
+	}
+}
+
+// 運転見合わせは、向きを問わず同じ駅間で、見合わせ期間と出発〜到着が重なる区間に影響する(境界の時刻ちょうどは重ならない)
+func TestSuspensionOf(t *testing.T) {
+	disruptions := Disruptions{Suspensions: []SegmentSuspension{{
+		ID:            1,
+		StationIDA:    2,
+		StationIDB:    3,
+		StartDatetime: testDatetime(9, 0),
+		EndDatetime:   testDatetime(12, 0),
+	}}}
+
+	tests := []struct {
+		name string
+		op   Operation
+		want bool
+	}{
+		{name: "arrives at start", op: testOperation(1, 2, testDatetime(8, 50), 3, testDatetime(9, 0)), want: false},
+		{name: "runs across start", op: testOperation(1, 2, testDatetime(8, 55), 3, testDatetime(9, 5)), want: true},
+		{name: "within window", op: testOperation(1, 2, testDatetime(10, 0), 3, testDatetime(10, 10)), want: true},
+		{name: "opposite direction", op: testOperation(1, 3, testDatetime(10, 0), 2, testDatetime(10, 10)), want: true},
+		{name: "runs across end", op: testOperation(1, 2, testDatetime(11, 55), 3, testDatetime(12, 5)), want: true},
+		{name: "departs at end", op: testOperation(1, 2, testDatetime(12, 0), 3, testDatetime(12, 10)), want: false},
+		{name: "other segment", op: testOperation(1, 1, testDatetime(10, 0), 2, testDatetime(10, 10)), want: false},
+		{name: "adjacent segment", op: testOperation(1, 3, testDatetime(10, 0), 4, testDatetime(10, 10)), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, got := disruptions.SuspensionOf(tt.op)
+			if got != tt.want {
+				t.Fatalf("SuspensionOf() = %v, want %v", got, tt.want)
+			}
+			if got && s.ID != 1 {
+				t.Errorf("suspension = %+v, want suspension 1", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// 夏時間のある地域(2024-03-10 2:00 ESTに開始、2024-11-03 2:00 EDTに終了)
+func newYorkLocation(t *testing.T) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	return location
+}
+
+// 時差offset(時間)の日時
+func offsetDatetime(month time.Month, day int, hour int, minute int, offset int) time.Time {
+	return time.Date(2024, month, day, hour, minute, 0, 0, time.FixedZone("", offset*60*60))
+}
+
+func TestServiceDateDST(t *testing.T) {
+	newYork := newYorkLocation(t)
+	setTestServiceDayStart(t, 4*time.Hour)
+
+	tests := []struct {
+		name     string
+		datetime time.Time
+		want     time.Time
+	}{
+		{name: "spring before gap", datetime: offsetDatetime(3, 10, 1, 59, -5), want: time.Date(2024, 3, 9, 0, 0, 0, 0, newYork)},
+		// 0:00からの経過時間は2:30だが、壁時計の3:30で判定する
+		{name: "spring after gap", datetime: offsetDatetime(3, 10, 3, 30, -4), want: time.Date(2024, 3, 9, 0, 0, 0, 0, newYork)},
+		{name: "spring at start", datetime: offsetDatetime(3, 10, 4, 0, -4), want: time.Date(2024, 3, 10, 0, 0, 0, 0, newYork)},
+		{name: "fall first 1:30", datetime: offsetDatetime(11, 3, 1, 30, -4), want: time.Date(2024, 11, 2, 0, 0, 0, 0, newYork)},
+		{name: "fall second 1:30", datetime: offsetDatetime(11, 3, 1, 30, -5), want: time.Date(2024, 11, 2, 0, 0, 0, 0, newYork)},
+		// 0:00からの経過時間は4:59だが、壁時計の3:59で判定する
+		{name: "fall before start", datetime: offsetDatetime(11, 3, 3, 59, -5), want: time.Date(2024, 11, 2, 0, 0, 0, 0, newYork)},
+		{name: "fall at start", datetime: offsetDatetime(11, 3, 4, 0, -5), want: time.Date(2024, 11, 3, 0, 0, 0, 0, newYork)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ServiceDate(tt.datetime.In(newYork)); !got.Equal(tt.want) {
+				t.Fatalf("ServiceDate(%v) = %v, want %v", tt.datetime, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceDatetimeDST(t *testing.T) {
+	newYork := newYorkLocation(t)
+	march9, november2 := time.Date(2024, 3, 9, 0, 0, 0, 0, newYork), time.Date(2024, 11, 2, 0, 0, 0, 0, newYork)
+
+	tests := []struct {
+		name        string
+		serviceDate time.Time
+		d           time.Duration
+		want        time.Time
+	}{
+		{name: "spring 25:59", serviceDate: march9, d: 25*time.Hour + 59*time.Minute, want: offsetDatetime(3, 10, 1, 59, -5)},
+		// 存在しない時刻は、切替前の時差で解釈する
+		{name: "spring 26:30 in gap", serviceDate: march9, d: 26*time.Hour + 30*time.Minute, want: offsetDatetime(3, 10, 3, 30, -4)},
+		{name: "spring 27:00", serviceDate: march9, d: 27 * time.Hour, want: offsetDatetime(3, 10, 3, 0, -4)},
+		{name: "spring end of service day", serviceDate: march9, d: 28 * time.Hour, want: offsetDatetime(3, 10, 4, 0, -4)},
+		// 2回ある時刻は、先の時刻とする
+		{name: "fall 25:30 repeated", serviceDate: november2, d: 25*time.Hour + 30*time.Minute, want: offsetDatetime(11, 3, 1, 30, -4)},
+		{name: "fall 26:00", serviceDate: november2, d: 26 * time.Hour, want: offsetDatetime(11, 3, 2, 0, -5)},
+		{name: "fall end of service day", serviceDate: november2, d: 28 * time.Hour, want: offsetDatetime(11, 3, 4, 0, -5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ServiceDatetime(tt.serviceDate, tt.d); !got.Equal(tt.want) {
+				t.Fatalf("ServiceDatetime(%v) = %v, want %v", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeString2DatetimeDST(t *testing.T) {
+	newYork := newYorkLocation(t)
+
+	tests := []struct {
+		name       string
+		base       time.Time
+		timeString string
+		forward    time.Time // baseより後で最も早い日時
+		backward   time.Time // baseより前で最も遅い日時
+	}{
+		{
+			name:       "spring across gap",
+			base:       offsetDatetime(3, 10, 1, 50, -5),
+			timeString: "02:10:00",
+			forward:    offsetDatetime(3, 10, 3, 10, -4),
+			backward:   offsetDatetime(3, 9, 2, 10, -5),
+		},
+		{
+			name:       "spring after gap",
+			base:       offsetDatetime(3, 10, 3, 10, -4),
+			timeString: "01:50:00",
+			forward:    offsetDatetime(3, 11, 1, 50, -4),
+			backward:   offsetDatetime(3, 10, 1, 50, -5),
+		},
+		{
+			name:       "fall first to second occurrence",
+			base:       offsetDatetime(11, 3, 1, 40, -4),
+			timeString: "01:10:00",
+			forward:    offsetDatetime(11, 3, 1, 10, -5),
+			backward:   offsetDatetime(11, 3, 1, 10, -4),
+		},
+		{
+			name:       "fall within first occurrence",
+			base:       offsetDatetime(11, 3, 1, 40, -4),
+			timeString: "01:50:00",
+			forward:    offsetDatetime(11, 3, 1, 50, -4),
+			backward:   offsetDatetime(11, 2, 1, 50, -4),
+		},
+		{
+			name:       "fall within second occurrence",
+			base:       offsetDatetime(11, 3, 1, 20, -5),
+			timeString: "01:30:00",
+			forward:    offsetDatetime(11, 3, 1, 30, -5),
+			backward:   offsetDatetime(11, 3, 1, 30, -4),
+		},
+		{
+			name:       "fall after second occurrence",
+			base:       offsetDatetime(11, 3, 1, 40, -5),
+			timeString: "01:10:00",
+			forward:    offsetDatetime(11, 4, 1, 10, -5),
+			backward:   offsetDatetime(11, 3, 1, 10, -5),
+		},
+		{
+			name:       "fall previous day second occurrence",
+			base:       offsetDatetime(11, 4, 0, 30, -5),
+			timeString: "01:30:00",
+			forward:    offsetDatetime(11, 4, 1, 30, -5),
+			backward:   offsetDatetime(11, 3, 1, 30, -5),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := tt.base.In(newYork)
+			forward, err := timeString2DatetimeForward(base, tt.timeString)
+			if err != nil {
+				t.Fatalf("timeString2DatetimeForward: %v", err)
+			}
+			if !forward.Equal(tt.forward) {
+				t.Errorf("timeString2DatetimeForward(%v, %q) = %v, want %v", base, tt.timeString, forward, tt.forward)
+			}
+
+			backward, err := timeString2DatetimeBackward(base, tt.timeString)
+			if err != nil {
+				t.Fatalf("timeString2DatetimeBackward: %v", err)
+			}
+			if !backward.Equal(tt.backward) {
+				t.Errorf("timeString2DatetimeBackward(%v, %q) = %v, want %v", base, tt.timeString, backward, tt.backward)
+			}
+		})
+	}
+}
+
+// 夏時間の切替を跨ぐ区間移動も、運行日は時刻表の時刻で判定する
+func TestServiceDateOfDST(t *testing.T) {
+	newYork := newYorkLocation(t)
+	setTestServiceDayStart(t, 4*time.Hour)
+
+	tests := []struct {
+		name       string
+		datetime   time.Time
+		timeString string
+		want       time.Time
+	}{
+		{name: "spring 02:30 in gap", datetime: offsetDatetime(3, 10, 3, 30, -4), timeString: "02:30:00", want: time.Date(2024, 3, 9, 0, 0, 0, 0, newYork)},
+		{name: "spring 26:30 in gap", datetime: offsetDatetime(3, 10, 3, 30, -4), timeString: "26:30:00", want: time.Date(2024, 3, 9, 0, 0, 0, 0, newYork)},
+		{name: "fall second 01:30", datetime: offsetDatetime(11, 3, 1, 30, -5), timeString: "01:30:00", want: time.Date(2024, 11, 2, 0, 0, 0, 0, newYork)},
+		{name: "fall 04:00", datetime: offsetDatetime(11, 3, 4, 0, -5), timeString: "04:00:00", want: time.Date(2024, 11, 3, 0, 0, 0, 0, newYork)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serviceDateOf(tt.datetime.In(newYork), tt.timeString)
+			if err != nil {
+				t.Fatalf("serviceDateOf: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("serviceDateOf(%v, %q) = %v, want %v", tt.datetime, tt.timeString, got, tt.want)
+			}
+		})
+	}
+}
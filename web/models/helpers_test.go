@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// modelsのテストで共有する時刻・区間移動・列車
+
+var testLocation = time.FixedZone("JST", 9*60*60)
+
+// 運行日の開始時刻をstartとし、テスト終了時に元に戻す
+func setTestServiceDayStart(t *testing.T, start time.Duration) {
+	t.Helper()
+	original := ServiceDayStart()
+	SetServiceDayStart(start)
+	t.Cleanup(func() { SetServiceDayStart(original) })
+}
+
+// 2024-10-(day)の0:00
+func testDate(day int) time.Time {
+	return time.Date(2024, 10, day, 0, 0, 0, 0, testLocation)
+}
+
+// 2024-10-(day)の時刻(HH:MM)
+func testDayDatetime(day int, hour int, minute int) time.Time {
+	return time.Date(2024, 10, day, hour, minute, 0, 0, testLocation)
+}
+
+// 2024-10-01の時刻(HH:MM)
+func testDatetime(hour int, minute int) time.Time {
+	return testDayDatetime(1, hour, minute)
+}
+
+// 列車trainIDの、from駅からto駅への移動
+func testOperation(trainID uint, from uint, depart time.Time, to uint, arrive time.Time) Operation {
+	return Operation{TrainID: trainID, DepartStationID: from, DepartDatetime: depart, ArriveStationID: to, ArriveDatetime: arrive}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// 停車駅の時刻(始発駅の到着・終着駅の発車は空文字列)
+type testStop struct {
+	stationID   uint
+	arrive      string
+	depart      string
+	pickupOnly  bool
+	dropOffOnly bool
+	platform    *string
+}
+
+// テスト用の列車
+func testMemoryTrain(id uint, networkID uint, name *string, stops ...testStop) MemoryTrain {
+	train := MemoryTrain{ID: id, NetworkID: networkID, Name: name}
+	for i, stop := range stops {
+		stopTime := StopTime{
+			TrainID:      id,
+			StopSequence: uint(i + 1),
+			StationID:    stop.stationID,
+			PickupOnly:   stop.pickupOnly,
+			DropOffOnly:  stop.dropOffOnly,
+			Platform:     stop.platform,
+		}
+		if stop.arrive != "" {
+			stopTime.ArriveTime = ptr(stop.arrive)
+		}
+		if stop.depart != "" {
+			stopTime.DepartTime = ptr(stop.depart)
+		}
+		train.StopTimes = append(train.StopTimes, stopTime)
+	}
+	return train
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DBのlinesスキーマに対応
+// NOTE: LINESはMySQLの予約語のため、クエリ中ではバッククォートで囲む
+type Line struct {
+	ID       uint   `db:"id"`
+	Name     string `db:"name"`
+	EngName  string `db:"name_en"`
+	AgencyID *uint  `db:"agency_id"` // 路線を運行する事業者(未設定の場合はnil)
+}
+
+// DBのline_stationsスキーマに対応(駅情報付き)
+type LineStation struct {
+	Station
+	Sequence uint    `db:"sequence"`
+	Km       float64 `db:"km"`
+}
+
+// 鉄道網の路線一覧をID順に返す
+func GetLines(ctx context.Context, db *sqlx.DB, networkID uint) ([]Line, error) {
+	lines := make([]Line, 0, 10)
+	query := "SELECT id, name, name_en, agency_id FROM " + quoteIdentifier(db.DriverName(), "lines") + " WHERE network_id = ? ORDER BY id"
+	if err := db.SelectContext(ctx, &lines, db.Rebind(query), networkID); err != nil {
+		return nil, fmt.Errorf("selectLines: %w", err)
+	}
+	return lines, nil
+}
+
+// 鉄道網の路線IDからDB問い合わせをし、路線情報を返す
+func GetLineByID(ctx context.Context, db *sqlx.DB, networkID uint, id uint) (Line, error) {
+	var line Line
+	query := "SELECT id, name, name_en, agency_id FROM " + quoteIdentifier(db.DriverName(), "lines") + " WHERE id = ? AND network_id = ?"
+	err := db.QueryRowxContext(
+		ctx,
+		db.Rebind(query),
+		id,
+		networkID,
+	).StructScan(&line)
+	return line, err
+}
+
+// 路線を構成する駅を、路線上の順序で返す
+func GetLineStations(ctx context.Context, db *sqlx.DB, lineID uint) ([]LineStation, error) {
+	lineStations := make([]LineStation, 0, 20)
+	query := `
+SELECT s.id, s.name, s.name_en, s.lat, s.lon, s.elevator, s.step_free, s.accessible_toilet, ls.sequence, ls.km
+FROM line_stations ls
+INNER JOIN stations s ON s.id = ls.station_id
+WHERE ls.line_id = ?
+ORDER BY ls.sequence
+`
+	if err := db.SelectContext(ctx, &lineStations, db.Rebind(query), lineID); err != nil {
+		return nil, fmt.Errorf("selectLineStations: %w", err)
+	}
+	return lineStations, nil
+}
@@ -0,0 +1,367 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// メモリ上の時刻表に登録する列車
+type MemoryTrain struct {
+	ID        uint
+	NetworkID uint
+	Name      *string
+	StopTimes []StopTime // 停車順(stop_sequence順)
+}
+
+// メモリ上のデータから駅・時刻表を取得する(DBへの問い合わせを行わない)
+// NOTE: 生成後にDBの駅・時刻表を変更しても反映されない
+type memoryRepository struct {
+	stations    map[uint][]Station // 鉄道網IDから駅一覧(ID順)
+	stationByID map[uint]Station
+	operations  []memoryOperation          // 列車・運行順
+	byDepart    map[uint][]memoryOperation // 出発駅IDから、その駅を出発する区間移動
+	stops       map[uint][]memoryDeparture // 駅IDから、その駅を発車する停車
+}
+
+// メモリ上の区間移動(operationsビューの1行に対応)
+type memoryOperation struct {
+	networkID  uint
+	trainName  *string
+	operation  Operation // 日時・運行日は未設定
+	dwellTime  string    // 出発駅への到着時刻(始発駅は出発時刻)
+	departTime string
+	arriveTime string
+	dwellSec   int
+	departSec  int // 0:00からの秒数(24:00以降の時刻は、翌日の0:00からの秒数)
+	arriveSec  int
+}
+
+// メモリ上の駅の発車(発車案内の1行の元データ)
+type memoryDeparture struct {
+	trainName            *string
+	stopTime             StopTime
+	destinationStationID uint
+	departSec            int // 0:00からの秒数(24:00以降の時刻は、翌日の0:00からの秒数)
+}
+
+// 鉄道網ごとの駅一覧(鉄道網IDから駅一覧)と列車から、メモリ上の駅・時刻表を生成する
+// 時刻の形式が不正な場合や、始発・終着駅以外で時刻が未設定の停車がある場合はエラー
+func NewMemoryRepositories(stations map[uint][]Station, trains []MemoryTrain) (Repositories, error) {
+	repo := &memoryRepository{
+		stations:    make(map[uint][]Station, len(stations)),
+		stationByID: make(map[uint]Station),
+		operations:  make([]memoryOperation, 0, len(trains)*10),
+		byDepart:    make(map[uint][]memoryOperation),
+		stops:       make(map[uint][]memoryDeparture),
+	}
+	for networkID, networkStations := range stations {
+		sorted := append([]Station{}, networkStations...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+		repo.stations[networkID] = sorted
+		for _, station := range sorted {
+			repo.stationByID[station.ID] = station
+		}
+	}
+
+	sortedTrains := append([]MemoryTrain{}, trains...)
+	sort.Slice(sortedTrains, func(i, j int) bool { return sortedTrains[i].ID < sortedTrains[j].ID })
+	for _, train := range sortedTrains {
+		if err := repo.addTrain(train); err != nil {
+			return Repositories{}, fmt.Errorf("train %d: %w", train.ID, err)
+		}
+	}
+
+	return Repositories{Stations: repo, Operations: repo}, nil
+}
+
+// SQLのDB(MySQL・SQLite・PostgreSQL)の駅・時刻表をすべて読み込み、メモリ上の駅・時刻表を生成する
+func LoadMemoryRepositories(ctx context.Context, db *sqlx.DB) (Repositories, error) {
+	networks, err := GetNetworks(ctx, db)
+	if err != nil {
+		return Repositories{}, err
+	}
+	stationRepo := &sqlStationRepository{db: db}
+	stations := make(map[uint][]Station, len(networks))
+	for _, network := range networks {
+		stations[network.ID], err = stationRepo.GetStations(ctx, network.ID)
+		if err != nil {
+			return Repositories{}, err
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, `
+SELECT t.id, t.network_id, t.name,
+	st.stop_sequence, st.station_id, st.arr_time, st.dep_time, st.pickup_only, st.drop_off_only, st.platform
+FROM trains t
+INNER JOIN stop_times st ON st.train_id = t.id
+ORDER BY t.id, st.stop_sequence
+`)
+	if err != nil {
+		return Repositories{}, fmt.Errorf("executeQuery: %w", err)
+	}
+	defer rows.Close()
+
+	trains := make([]MemoryTrain, 0, 100)
+	for rows.Next() {
+		var (
+			train    MemoryTrain
+			stopTime StopTime
+		)
+		err := rows.Scan(
+			&train.ID, &train.NetworkID, &train.Name,
+			&stopTime.StopSequence, &stopTime.StationID, &stopTime.ArriveTime, &stopTime.DepartTime,
+			&stopTime.PickupOnly, &stopTime.DropOffOnly, &stopTime.Platform,
+		)
+		if err != nil {
+			return Repositories{}, fmt.Errorf("scanRecord: %w", err)
+		}
+		stopTime.TrainID = train.ID
+
+		if len(trains) == 0 || trains[len(trains)-1].ID != train.ID {
+			trains = append(trains, train)
+		}
+		trains[len(trains)-1].StopTimes = append(trains[len(trains)-1].StopTimes, stopTime)
+	}
+	if err := rows.Err(); err != nil {
+		return Repositories{}, fmt.Errorf("scanRecord: %w", err)
+	}
+
+	return NewMemoryRepositories(stations, trains)
+}
+
+// 列車の停車駅から、区間移動と駅の発車を登録する
+// NOTE: 区間移動の導出は、operationsビューと同じ規則とする
+func (r *memoryRepository) addTrain(train MemoryTrain) error {
+	if len(train.StopTimes) == 0 {
+		return nil
+	}
+	destinationStationID := train.StopTimes[len(train.StopTimes)-1].StationID
+
+	for i, stopTime := range train.StopTimes {
+		if stopTime.DepartTime != nil {
+			departSec, err := memoryTimeSeconds(*stopTime.DepartTime)
+			if err != nil {
+				return err
+			}
+			stopTime.TrainID = train.ID
+			r.stops[stopTime.StationID] = append(r.stops[stopTime.StationID], memoryDeparture{
+				trainName:            train.Name,
+				stopTime:             stopTime,
+				destinationStationID: destinationStationID,
+				departSec:            departSec,
+			})
+		}
+
+		if i == len(train.StopTimes)-1 {
+			break
+		}
+		next := train.StopTimes[i+1]
+		dwellTime := coalesceTime(stopTime.ArriveTime, stopTime.DepartTime)
+		departTime := coalesceTime(stopTime.DepartTime, stopTime.ArriveTime)
+		arriveTime := coalesceTime(next.ArriveTime, next.DepartTime)
+		if departTime == nil || arriveTime == nil {
+			return fmt.Errorf("stop %d or %d has no time", stopTime.StopSequence, next.StopSequence)
+		}
+		dwellSec, err := memoryTimeSeconds(*dwellTime)
+		if err != nil {
+			return err
+		}
+		departSec, err := memoryTimeSeconds(*departTime)
+		if err != nil {
+			return err
+		}
+		arriveSec, err := memoryTimeSeconds(*arriveTime)
+		if err != nil {
+			return err
+		}
+
+		op := memoryOperation{
+			networkID: train.NetworkID,
+			trainName: train.Name,
+			operation: Operation{
+				TrainID:         train.ID,
+				Order:           uint(i + 1),
+				DepartStationID: stopTime.StationID,
+				ArriveStationID: next.StationID,
+				NoBoarding:      stopTime.DropOffOnly,
+				NoAlighting:     next.PickupOnly,
+				DepartPlatform:  stopTime.Platform,
+				ArrivePlatform:  next.Platform,
+			},
+			dwellTime:  *dwellTime,
+			departTime: *departTime,
+			arriveTime: *arriveTime,
+			dwellSec:   dwellSec,
+			departSec:  departSec,
+			arriveSec:  arriveSec,
+		}
+		r.operations = append(r.operations, op)
+		r.byDepart[op.operation.DepartStationID] = append(r.byDepart[op.operation.DepartStationID], op)
+	}
+
+	return nil
+}
+
+// 鉄道網に属する駅の一覧をID順に返す
+func (r *memoryRepository) GetStations(ctx context.Context, networkID uint) ([]Station, error) {
+	return append([]Station{}, r.stations[networkID]...), nil
+}
+
+// キーワードから部分一致検索で駅一覧を返す(大文字・小文字は区別しない)
+func (r *memoryRepository) GetStationsByKeyword(ctx context.Context, networkID uint, keyword string) ([]Station, error) {
+	keyword = strings.ToLower(keyword)
+	stations := make([]Station, 0, 10)
+	for _, station := range r.stations[networkID] {
+		if strings.Contains(strings.ToLower(station.Name), keyword) || strings.Contains(strings.ToLower(station.EngName), keyword) {
+			stations = append(stations, station)
+		}
+	}
+	return stations, nil
+}
+
+// 段差なしでホームまで移動できる駅のID集合を返す
+func (r *memoryRepository) GetStepFreeStationIDs(ctx context.Context, networkID uint) (map[uint]struct{}, error) {
+	stationIDs := make(map[uint]struct{})
+	for _, station := range r.stations[networkID] {
+		if station.StepFree {
+			stationIDs[station.ID] = struct{}{}
+		}
+	}
+	return stationIDs, nil
+}
+
+// 指定駅から指定時間以降に発車する列車を取得
+// NOTE: 並び順はSQLの実装と同じく、次停車駅ごとに待ち時間が短い順とする
+func (r *memoryRepository) SearchNextDepartOperations(ctx context.Context, departStationID uint, fastestDepartDatetime time.Time) ([]Operation, error) {
+	seconds := secondsOfDay(fastestDepartDatetime)
+	candidates := append([]memoryOperation{}, r.byDepart[departStationID]...)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].operation.ArriveStationID != candidates[j].operation.ArriveStationID {
+			return candidates[i].operation.ArriveStationID < candidates[j].operation.ArriveStationID
+		}
+		return waitSeconds(candidates[i].departSec, seconds) < waitSeconds(candidates[j].departSec, seconds)
+	})
+
+	operations := make([]Operation, 0, len(candidates))
+	for _, candidate := range candidates {
+		op, err := setForwardDatetimes(candidate.operation, fastestDepartDatetime, candidate.departTime, candidate.arriveTime)
+		if err != nil {
+			return []Operation{}, err
+		}
+		operations = append(operations, op)
+	}
+	return operations, nil
+}
+
+// 指定日時に鉄道網の駅間を走行中、または駅に停車中の列車を取得
+// NOTE: 日付を跨ぐ区間(0:00からの秒数でdep > arr)も考慮する
+func (r *memoryRepository) GetRunningOperations(ctx context.Context, networkID uint, datetime time.Time) ([]RunningOperation, error) {
+	seconds := secondsOfDay(datetime)
+	operations := make([]RunningOperation, 0, 10)
+	for _, candidate := range r.operations {
+		if candidate.networkID != networkID {
+			continue
+		}
+		dwellSec, arrSec := candidate.dwellSec%86400, candidate.arriveSec%86400
+		isRunning := (dwellSec <= arrSec && dwellSec <= seconds && seconds < arrSec) ||
+			(dwellSec > arrSec && (dwellSec <= seconds || seconds < arrSec))
+		if !isRunning {
+			continue
+		}
+
+		op := RunningOperation{Operation: candidate.operation, TrainName: candidate.trainName}
+		if station, isFound := r.stationByID[op.DepartStationID]; isFound {
+			op.DepartLat, op.DepartLon = station.Lat, station.Lon
+		}
+		if station, isFound := r.stationByID[op.ArriveStationID]; isFound {
+			op.ArriveLat, op.ArriveLon = station.Lat, station.Lon
+		}
+		op, err := setRunningDatetimes(op, datetime, candidate.dwellTime, candidate.departTime, candidate.arriveTime)
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, op)
+	}
+	return operations, nil
+}
+
+// 鉄道網の全列車の区間移動を、列車・運行順に取得
+// 時刻は、列車ごとにbaseDatetime(0:00)の日の始発から連続するよう変換する
+func (r *memoryRepository) GetTimetableOperations(ctx context.Context, networkID uint, baseDatetime time.Time) ([]Operation, error) {
+	operations := make([]Operation, 0, 100)
+	for _, candidate := range r.operations {
+		if candidate.networkID != networkID {
+			continue
+		}
+		var err error
+		operations, err = appendTimetableOperation(operations, candidate.operation, baseDatetime, candidate.departTime, candidate.arriveTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return operations, nil
+}
+
+// 指定駅を指定日時以降に発車する列車を、発車の早い順にlimit件取得
+func (r *memoryRepository) GetStationDepartures(ctx context.Context, stationID uint, fastestDepartDatetime time.Time, limit uint) ([]StationDeparture, error) {
+	seconds := secondsOfDay(fastestDepartDatetime)
+	candidates := append([]memoryDeparture{}, r.stops[stationID]...)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		waitI, waitJ := waitSeconds(candidates[i].departSec, seconds), waitSeconds(candidates[j].departSec, seconds)
+		if waitI != waitJ {
+			return waitI < waitJ
+		}
+		return candidates[i].stopTime.TrainID < candidates[j].stopTime.TrainID
+	})
+	if uint(len(candidates)) > limit {
+		candidates = candidates[:limit]
+	}
+
+	departures := make([]StationDeparture, 0, len(candidates))
+	for _, candidate := range candidates {
+		departure := StationDeparture{
+			TrainID:              candidate.stopTime.TrainID,
+			TrainName:            candidate.trainName,
+			StopSequence:         candidate.stopTime.StopSequence,
+			DestinationStationID: candidate.destinationStationID,
+			PickupOnly:           candidate.stopTime.PickupOnly,
+			DropOffOnly:          candidate.stopTime.DropOffOnly,
+			Platform:             candidate.stopTime.Platform,
+		}
+		departure, err := setDepartureDatetimes(departure, fastestDepartDatetime, candidate.stopTime.ArriveTime, *candidate.stopTime.DepartTime)
+		if err != nil {
+			return nil, err
+		}
+		departures = append(departures, departure)
+	}
+	return departures, nil
+}
+
+// 0:00からの秒数がseconds以降で最も早い、departSecの時刻までの待ち時間(秒)
+func waitSeconds(departSec int, seconds int) int {
+	return ((departSec-seconds)%86400 + 86400) % 86400
+}
+
+// 時刻の文字列を、0:00からの秒数に変換(形式の検証を兼ねる)
+func memoryTimeSeconds(timeString string) (int, error) {
+	d, err := ParseServiceTime(timeString)
+	if err != nil {
+		return 0, err
+	}
+	return int(d / time.Second), nil
+}
+
+// SQLのCOALESCEと同じく、nilでない最初の時刻を返す
+func coalesceTime(times ...*string) *string {
+	for _, t := range times {
+		if t != nil {
+			return t
+		}
+	}
+	return nil
+}
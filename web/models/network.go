@@ -0,0 +1,38 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DBのnetworksスキーマに対応
+type Network struct {
+	ID                uint    `db:"id"`
+	Code              string  `db:"code"` // URLで鉄道網を指定する識別子
+	Name              string  `db:"name"`
+	TimeZone          string  `db:"time_zone"`           // IANAタイムゾーン名(例: Asia/Tokyo)
+	AdminUser         *string `db:"admin_user"`          // 管理用エンドポイントの認証情報(未設定の場合はnil)
+	AdminPasswordHash *string `db:"admin_password_hash"` // bcryptのハッシュ
+}
+
+// 鉄道網の一覧をID順に返す
+func GetNetworks(ctx context.Context, db *sqlx.DB) ([]Network, error) {
+	networks := make([]Network, 0, 5)
+	query := `SELECT id, code, name, time_zone, admin_user, admin_password_hash FROM networks ORDER BY id`
+	if err := db.SelectContext(ctx, &networks, query); err != nil {
+		return nil, fmt.Errorf("selectNetworks: %w", err)
+	}
+	return networks, nil
+}
+
+// 鉄道網のタイムゾーンを読み込む
+func (n Network) Location() (*time.Location, error) {
+	loc, err := time.LoadLocation(n.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("loadLocation %s: %w", n.TimeZone, err)
+	}
+	return loc, nil
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DBのservice_patternsスキーマに対応(停車駅・運転間隔付き)
+type ServicePattern struct {
+	ID              uint   `db:"id"`
+	NetworkID       uint   `db:"network_id"`
+	Name            string `db:"name"`
+	TrainNamePrefix string `db:"train_name_prefix"`
+	TypeID          *uint  `db:"type_id"`
+	LineID          *uint  `db:"line_id"`
+	Stops           []ServicePatternStop
+	Frequencies     []ServiceFrequency
+}
+
+// DBのservice_pattern_stopsスキーマに対応
+type ServicePatternStop struct {
+	Sequence  uint `db:"sequence"`
+	StationID uint `db:"station_id"`
+	Run       time.Duration
+	Dwell     time.Duration
+	Platform  *string `db:"platform"`
+}
+
+// DBのservice_frequenciesスキーマに対応(時刻は0:00からの経過時間)
+type ServiceFrequency struct {
+	Start   time.Duration
+	End     time.Duration
+	Headway time.Duration
+}
+
+// 運行パターンから生成した列車
+// Operationsの時刻は、生成時の基準日(0:00)からの日時
+type PatternTrain struct {
+	Name       *string
+	Operations []Operation
+}
+
+// 鉄道網の運行パターンIDから、停車駅(順序通り)・運転間隔を含む運行パターンを返す
+func GetServicePatternByID(ctx context.Context, db *sqlx.DB, networkID uint, id uint) (ServicePattern, error) {
+	var pattern ServicePattern
+	err := db.QueryRowxContext(
+		ctx,
+		db.Rebind(`SELECT id, network_id, name, train_name_prefix, type_id, line_id FROM service_patterns WHERE id = ? AND network_id = ?`),
+		id,
+		networkID,
+	).StructScan(&pattern)
+	if err != nil {
+		return ServicePattern{}, err
+	}
+
+	stopRows, err := db.QueryContext(
+		ctx,
+		db.Rebind(`SELECT sequence, station_id, run_seconds, dwell_seconds, platform FROM service_pattern_stops WHERE pattern_id = ? ORDER BY sequence`),
+		id,
+	)
+	if err != nil {
+		return ServicePattern{}, fmt.Errorf("selectStops: %w", err)
+	}
+	defer stopRows.Close()
+
+	for stopRows.Next() {
+		var (
+			stop                     ServicePatternStop
+			runSeconds, dwellSeconds uint
+		)
+		if err := stopRows.Scan(&stop.Sequence, &stop.StationID, &runSeconds, &dwellSeconds, &stop.Platform); err != nil {
+			return ServicePattern{}, fmt.Errorf("scanStop: %w", err)
+		}
+		stop.Run = time.Duration(runSeconds) * time.Second
+		stop.Dwell = time.Duration(dwellSeconds) * time.Second
+		pattern.Stops = append(pattern.Stops, stop)
+	}
+	if err := stopRows.Err(); err != nil {
+		return ServicePattern{}, err
+	}
+
+	frequencyRows, err := db.QueryContext(
+		ctx,
+		db.Rebind(`SELECT start_time, end_time, headway_seconds FROM service_frequencies WHERE pattern_id = ? ORDER BY start_time`),
+		id,
+	)
+	if err != nil {
+		return ServicePattern{}, fmt.Errorf("selectFrequencies: %w", err)
+	}
+	defer frequencyRows.Close()
+
+	for frequencyRows.Next() {
+		var (
+			startTimeString string
+			endTimeString   string
+			headwaySeconds  uint
+		)
+		if err := frequencyRows.Scan(&startTimeString, &endTimeString, &headwaySeconds); err != nil {
+			return ServicePattern{}, fmt.Errorf("scanFrequency: %w", err)
+		}
+		// NOTE: DBの種類に依らないよう、時刻は文字列で取得して変換する
+		start, err := ParseServiceTime(startTimeString)
+		if err != nil {
+			return ServicePattern{}, fmt.Errorf("scanFrequency: %w", err)
+		}
+		end, err := ParseServiceTime(endTimeString)
+		if err != nil {
+			return ServicePattern{}, fmt.Errorf("scanFrequency: %w", err)
+		}
+		pattern.Frequencies = append(pattern.Frequencies, ServiceFrequency{
+			Start:   start,
+			End:     end,
+			Headway: time.Duration(headwaySeconds) * time.Second,
+		})
+	}
+
+	return pattern, frequencyRows.Err()
+}
+
+// 運行パターンから生成済みの列車を削除し、新たに生成した列車に置き換える
+// 採番された列車IDを、trainsの順に返す
+func ReplacePatternTrains(ctx context.Context, db *sqlx.DB, pattern ServicePattern, trains []PatternTrain) ([]uint, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 生成済みの列車と、その停車駅を削除
+	_, err = tx.ExecContext(
+		ctx,
+		tx.Rebind(`DELETE FROM stop_times WHERE train_id IN (SELECT id FROM trains WHERE pattern_id = ?)`),
+		pattern.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("deleteStopTimes: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, tx.Rebind(`DELETE FROM trains WHERE pattern_id = ?`), pattern.ID); err != nil {
+		return nil, fmt.Errorf("deleteTrains: %w", err)
+	}
+
+	trainIDs := make([]uint, 0, len(trains))
+	for _, train := range trains {
+		trainID, err := insertReturningID(
+			ctx,
+			tx,
+			`INSERT INTO trains (network_id, name, type_id, line_id, pattern_id) VALUES (?, ?, ?, ?, ?)`,
+			pattern.NetworkID,
+			train.Name,
+			pattern.TypeID,
+			pattern.LineID,
+			pattern.ID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("insertTrain: %w", err)
+		}
+
+		for _, stopTime := range operationsToStopTimes(train.Operations) {
+			_, err := tx.ExecContext(
+				ctx,
+				tx.Rebind(`INSERT INTO stop_times (train_id, stop_sequence, station_id, arr_time, dep_time, platform) VALUES (?, ?, ?, ?, ?, ?)`),
+				trainID,
+				stopTime.StopSequence,
+				stopTime.StationID,
+				stopTime.ArriveTime,
+				stopTime.DepartTime,
+				stopTime.Platform,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("insertStopTime: %w", err)
+			}
+		}
+		trainIDs = append(trainIDs, uint(trainID))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return trainIDs, nil
+}
+
+// 連続する区間移動を、停車駅ごとの到着・発車時刻(HH:MM:SS)・番線に変換
+// 時刻は始発の運行日の0:00からの経過時間とし、日付を跨いだ後は24:00以降の時刻とする
+func operationsToStopTimes(operations []Operation) []StopTime {
+	stopTimes := make([]StopTime, 0, len(operations)+1)
+	if len(operations) == 0 {
+		return stopTimes
+	}
+	serviceDate := ServiceDate(operations[0].DepartDatetime)
+	for i, op := range operations {
+		departTime := FormatServiceTime(ServiceTimeOf(serviceDate, op.DepartDatetime))
+		if i == 0 {
+			stopTimes = append(stopTimes, StopTime{StopSequence: 1, StationID: op.DepartStationID, DepartTime: &departTime, Platform: op.DepartPlatform})
+		} else {
+			stopTimes[i].DepartTime = &departTime
+		}
+
+		arriveTime := FormatServiceTime(ServiceTimeOf(serviceDate, op.ArriveDatetime))
+		stopTimes = append(stopTimes, StopTime{StopSequence: uint(i + 2), StationID: op.ArriveStationID, ArriveTime: &arriveTime, Platform: op.ArrivePlatform})
+	}
+	return stopTimes
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 駅構内の番線間の乗換(番線が空文字の場合は任意の番線)
+type PlatformTransferKey struct {
+	StationID    uint
+	FromPlatform string
+	ToPlatform   string
+}
+
+// DBのplatform_transfersスキーマに対応
+type PlatformTransfer struct {
+	Duration           time.Duration
+	WheelchairDuration time.Duration // 車いす利用時の乗換時間
+	StepFree           bool          // 段差なしで乗り換えられる
+}
+
+// 番線間の乗換
+type PlatformTransfers map[PlatformTransferKey]PlatformTransfer
+
+// 鉄道網の全駅の番線間の乗換を取得
+func GetPlatformTransfers(ctx context.Context, db *sqlx.DB, networkID uint) (PlatformTransfers, error) {
+	rows, err := db.QueryContext(ctx, db.Rebind(`
+SELECT pt.station_id, pt.from_platform, pt.to_platform, pt.transfer_seconds, pt.step_free, pt.wheelchair_transfer_seconds
+FROM platform_transfers pt
+INNER JOIN stations s ON s.id = pt.station_id
+WHERE s.network_id = ?
+`), networkID)
+	if err != nil {
+		return nil, fmt.Errorf("executeQuery: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := make(PlatformTransfers)
+	for rows.Next() {
+		var (
+			key                       PlatformTransferKey
+			transfer                  PlatformTransfer
+			transferSeconds           uint
+			wheelchairTransferSeconds *uint
+		)
+		err := rows.Scan(
+			&key.StationID, &key.FromPlatform, &key.ToPlatform,
+			&transferSeconds, &transfer.StepFree, &wheelchairTransferSeconds,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanRecord: %w", err)
+		}
+		transfer.Duration = time.Duration(transferSeconds) * time.Second
+		transfer.WheelchairDuration = transfer.Duration
+		if wheelchairTransferSeconds != nil {
+			transfer.WheelchairDuration = time.Duration(*wheelchairTransferSeconds) * time.Second
+		}
+		transfers[key] = transfer
+	}
+
+	return transfers, rows.Err()
+}
+
+// 駅stationIDで、番線fromPlatformから番線toPlatformへ乗り換える際の条件
+// 番線が一致する登録を優先し、無ければ任意の番線の登録、それも無ければ乗換時間0・段差なしとする
+func (p PlatformTransfers) Find(stationID uint, fromPlatform *string, toPlatform *string) PlatformTransfer {
+	from, to := "", ""
+	if fromPlatform != nil {
+		from = *fromPlatform
+	}
+	if toPlatform != nil {
+		to = *toPlatform
+	}
+
+	for _, key := range []PlatformTransferKey{
+		{StationID: stationID, FromPlatform: from, ToPlatform: to},
+		{StationID: stationID, FromPlatform: from, ToPlatform: ""},
+		{StationID: stationID, FromPlatform: "", ToPlatform: to},
+		{StationID: stationID, FromPlatform: "", ToPlatform: ""},
+	} {
+		if transfer, isFound := p[key]; isFound {
+			return transfer
+		}
+	}
+	return PlatformTransfer{StepFree: true}
+}
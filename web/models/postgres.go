@@ -0,0 +1,72 @@
+package models
+
+import "fmt"
+
+// PostgreSQL用のSQL
+// NOTE: 時刻はinterval型で保存する(24:00以降の時刻も可)。基準時刻との差をintervalで求め、秒数に変換して比較する
+var postgresQueries = dialectQueries{
+	// NOTE: MySQLの照合順序と同様に、大文字・小文字を区別せず検索する
+	stationsByKeyword: `
+SELECT id, name, name_en, lat, lon, elevator, step_free, accessible_toilet FROM stations
+WHERE network_id = ? AND (name ILIKE ? OR name_en ILIKE ?)
+ORDER BY id
+`,
+	nextDepartOperations: fmt.Sprintf(`
+SELECT dep.train_id, dep.stop_sequence AS op_order, dep.station_id AS dep_sta_id, COALESCE(dep.dep_time, dep.arr_time) AS dep_time,
+arr.station_id AS arr_sta_id, COALESCE(arr.arr_time, arr.dep_time) AS arr_time,
+dep.drop_off_only AS dep_drop_off_only, arr.pickup_only AS arr_pickup_only, dep.platform AS dep_platform, arr.platform AS arr_platform,
+ROW_NUMBER() OVER (
+	PARTITION BY arr.station_id
+	ORDER BY MOD(%s + 86400, 86400)
+) dep_order_arr_grouped
+FROM stop_times dep
+INNER JOIN stop_times arr ON arr.train_id = dep.train_id AND arr.stop_sequence = dep.stop_sequence + 1
+WHERE dep.station_id = ?
+ORDER BY arr_sta_id, dep_order_arr_grouped
+`, postgresSecondsSince("COALESCE(dep.dep_time, dep.arr_time)")),
+	runningOperations: fmt.Sprintf(`
+SELECT train_id, name, op_order, dep_sta_id, dwell_time, dep_time, arr_sta_id, arr_time, dep_lat, dep_lon, arr_lat, arr_lon
+FROM (
+	SELECT dep.train_id, t.name, dep.stop_sequence AS op_order, dep.station_id AS dep_sta_id,
+		COALESCE(dep.arr_time, dep.dep_time) AS dwell_time, COALESCE(dep.dep_time, dep.arr_time) AS dep_time,
+		arr.station_id AS arr_sta_id, COALESCE(arr.arr_time, arr.dep_time) AS arr_time,
+		s1.lat AS dep_lat, s1.lon AS dep_lon, s2.lat AS arr_lat, s2.lon AS arr_lon,
+		MOD(%s, 86400) AS dwell_sec, MOD(%s, 86400) AS arr_sec
+	FROM stop_times dep
+	INNER JOIN stop_times arr ON arr.train_id = dep.train_id AND arr.stop_sequence = dep.stop_sequence + 1
+	INNER JOIN trains t ON t.id = dep.train_id
+	INNER JOIN stations s1 ON s1.id = dep.station_id
+	INNER JOIN stations s2 ON s2.id = arr.station_id
+	WHERE t.network_id = ?
+) ro
+WHERE (dwell_sec <= arr_sec AND dwell_sec <= ? AND ? < arr_sec)
+OR (dwell_sec > arr_sec AND (dwell_sec <= ? OR ? < arr_sec))
+ORDER BY train_id
+`, postgresIntervalToSec("COALESCE(dep.arr_time, dep.dep_time)"), postgresIntervalToSec("COALESCE(arr.arr_time, arr.dep_time)")),
+	stationDepartures: fmt.Sprintf(`
+SELECT st.train_id, t.name, st.stop_sequence, st.arr_time, st.dep_time, st.pickup_only, st.drop_off_only, st.platform,
+	(
+		SELECT st2.station_id FROM stop_times st2
+		WHERE st2.train_id = st.train_id
+		ORDER BY st2.stop_sequence DESC
+		LIMIT 1
+	) AS destination_station_id
+FROM stop_times st
+INNER JOIN trains t ON t.id = st.train_id
+WHERE st.station_id = ?
+AND st.dep_time IS NOT NULL
+ORDER BY MOD(%s + 86400, 86400), st.train_id
+LIMIT ?
+`, postgresSecondsSince("st.dep_time")),
+}
+
+// interval型の列を、秒数(整数)に変換するPostgreSQLの式
+func postgresIntervalToSec(column string) string {
+	return fmt.Sprintf("CAST(EXTRACT(EPOCH FROM %s) AS INTEGER)", column)
+}
+
+// interval型の時刻の列から、基準時刻(引数: 0:00からの秒数)を引いた差を秒数に変換するPostgreSQLの式
+// NOTE: 差は-86400秒より大きいため、86400を足した剰余で基準時刻からの待ち時間になる
+func postgresSecondsSince(column string) string {
+	return postgresIntervalToSec(fmt.Sprintf("(%s - CAST(? AS INTEGER) * INTERVAL '1 second')", column))
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 列車の関係の種類
+const (
+	RelationThrough = "through" // 直通運転(列車番号の変更)
+	RelationSplit   = "split"   // 分割
+	RelationJoin    = "join"    // 併合
+)
+
+// DBのtrain_relationsスキーマに対応
+// FromTrainIDの車両は、StationIDからToTrainIDとして運行を続ける
+type TrainRelation struct {
+	FromTrainID uint   `db:"from_train_id"`
+	ToTrainID   uint   `db:"to_train_id"`
+	StationID   uint   `db:"station_id"`
+	Type        string `db:"relation_type"`
+}
+
+// 列車の関係の集合(キーは[FromTrainID, ToTrainID, StationID])
+type TrainRelations map[[3]uint]TrainRelation
+
+// 鉄道網の全ての列車の関係を取得
+func GetTrainRelations(ctx context.Context, db *sqlx.DB, networkID uint) (TrainRelations, error) {
+	relationList := make([]TrainRelation, 0, 10)
+	err := db.SelectContext(
+		ctx,
+		&relationList,
+		db.Rebind(`
+SELECT r.from_train_id, r.to_train_id, r.station_id, r.relation_type
+FROM train_relations r
+INNER JOIN trains t ON t.id = r.from_train_id
+WHERE t.network_id = ?
+`),
+		networkID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("selectRelations: %w", err)
+	}
+
+	relations := make(TrainRelations, len(relationList))
+	for _, r := range relationList {
+		relations[[3]uint{r.FromTrainID, r.ToTrainID, r.StationID}] = r
+	}
+	return relations, nil
+}
+
+// 駅stationIDで、列車fromTrainIDからtoTrainIDへ乗ったまま移れる関係を返す
+func (r TrainRelations) Find(fromTrainID uint, toTrainID uint, stationID uint) (TrainRelation, bool) {
+	relation, isExists := r[[3]uint{fromTrainID, toTrainID, stationID}]
+	return relation, isExists
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 駅情報の取得
+// NOTE: DBの種類(MySQL・SQLite・PostgreSQL)やメモリ上のデータごとに実装する
+type StationRepository interface {
+	// 鉄道網に属する駅の一覧をID順に返す
+	GetStations(ctx context.Context, networkID uint) ([]Station, error)
+	// キーワードから部分一致検索で駅一覧をID順に返す(大文字・小文字は区別しない)
+	GetStationsByKeyword(ctx context.Context, networkID uint, keyword string) ([]Station, error)
+	// 段差なしでホームまで移動できる駅のID集合を返す
+	GetStepFreeStationIDs(ctx context.Context, networkID uint) (map[uint]struct{}, error)
+}
+
+// 時刻表(列車の区間移動・駅の発車)の取得
+// NOTE: DBの種類(MySQL・SQLite・PostgreSQL)やメモリ上のデータごとに実装する
+type OperationRepository interface {
+	// 指定駅から指定時間以降に発車する列車を取得
+	SearchNextDepartOperations(ctx context.Context, departStationID uint, fastestDepartDatetime time.Time) ([]Operation, error)
+	// 指定日時に鉄道網の駅間を走行中の列車を取得
+	GetRunningOperations(ctx context.Context, networkID uint, datetime time.Time) ([]RunningOperation, error)
+	// 鉄道網の全列車の区間移動を、列車・運行順に取得
+	GetTimetableOperations(ctx context.Context, networkID uint, baseDatetime time.Time) ([]Operation, error)
+	// 指定駅を指定日時以降に発車する列車を、発車の早い順にlimit件取得
+	GetStationDepartures(ctx context.Context, stationID uint, fastestDepartDatetime time.Time, limit uint) ([]StationDeparture, error)
+}
+
+// 駅・時刻表の取得先
+type Repositories struct {
+	Stations   StationRepository
+	Operations OperationRepository
+}
+
+// MySQLから駅・時刻表を取得する
+func NewMySQLRepositories(db *sqlx.DB) Repositories {
+	return newSQLRepositories(db, mysqlQueries)
+}
+
+// SQLiteから駅・時刻表を取得する(スキーマはdatabase/migrations/sqlite)
+func NewSQLiteRepositories(db *sqlx.DB) Repositories {
+	return newSQLRepositories(db, sqliteQueries)
+}
+
+// PostgreSQLから駅・時刻表を取得する(スキーマはdatabase/migrations/postgres)
+func NewPostgresRepositories(db *sqlx.DB) Repositories {
+	return newSQLRepositories(db, postgresQueries)
+}
+
+func newSQLRepositories(db *sqlx.DB, queries dialectQueries) Repositories {
+	return Repositories{
+		Stations:   &sqlStationRepository{db: db, queries: queries},
+		Operations: &sqlOperationRepository{db: db, queries: queries},
+	}
+}
+
+// DBの種類ごとに異なるSQL
+// NOTE: 時刻の秒数への変換・剰余・文字列照合の書き方がDBごとに異なるため、該当する問い合わせのみ切り替える
+type dialectQueries struct {
+	stationsByKeyword    string // 引数: 鉄道網ID, 検索パターン×2
+	nextDepartOperations string // 引数: 基準時刻(0:00からの秒数), 出発駅ID
+	runningOperations    string // 引数: 鉄道網ID, 基準時刻(0:00からの秒数)×4
+	stationDepartures    string // 引数: 駅ID, 基準時刻(0:00からの秒数), 件数
+}
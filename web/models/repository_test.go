@@ -0,0 +1,396 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"outtech105.com/transit_server/database"
+)
+
+// テスト用の駅(鉄道網IDから駅一覧)
+// 鉄道網1: 甲(1)・乙(2)・丙(3)・丁(4)、鉄道網2: 戊(5)・己(6)
+var testStations = map[uint][]Station{
+	1: {
+		{ID: 1, Name: "甲", EngName: "Ko", Lat: ptr(35.0), Lon: ptr(139.0), StepFree: true},
+		{ID: 2, Name: "乙", EngName: "Otsu", Lat: ptr(35.1), Lon: ptr(139.1), Elevator: true},
+		{ID: 3, Name: "丙", EngName: "Hei", StepFree: true, AccessibleToilet: true},
+		{ID: 4, Name: "丁", EngName: "Tei"},
+	},
+	2: {
+		{ID: 5, Name: "戊", EngName: "Bo"},
+		{ID: 6, Name: "己", EngName: "Ki"},
+	},
+}
+
+// テスト用の列車
+var testTrains = []MemoryTrain{
+	testMemoryTrain(101, 1, ptr("普通101"),
+		testStop{stationID: 1, depart: "08:00:00", platform: ptr("1")},
+		testStop{stationID: 2, arrive: "08:10:00", depart: "08:12:00"},
+		testStop{stationID: 3, arrive: "08:30:00"},
+	),
+	testMemoryTrain(102, 1, ptr("普通102"),
+		testStop{stationID: 1, depart: "08:20:00", platform: ptr("2")},
+		testStop{stationID: 2, arrive: "08:30:00", depart: "08:31:00"},
+		testStop{stationID: 3, arrive: "08:50:00"},
+	),
+	// 乙は降車のみ
+	testMemoryTrain(103, 1, ptr("快速103"),
+		testStop{stationID: 1, depart: "08:55:00"},
+		testStop{stationID: 2, arrive: "09:00:00", depart: "09:01:00", dropOffOnly: true},
+		testStop{stationID: 4, arrive: "09:15:00"},
+	),
+	// 日付を跨ぐ列車
+	testMemoryTrain(104, 1, ptr("深夜104"),
+		testStop{stationID: 3, depart: "23:50:00"},
+		testStop{stationID: 4, arrive: "24:20:00"},
+	),
+	// 名前の無い列車(甲は乗車のみ)
+	testMemoryTrain(105, 1, nil,
+		testStop{stationID: 2, depart: "07:00:00"},
+		testStop{stationID: 1, arrive: "07:10:00", pickupOnly: true},
+	),
+	testMemoryTrain(201, 2, ptr("普通201"),
+		testStop{stationID: 5, depart: "10:00:00"},
+		testStop{stationID: 6, arrive: "10:30:00"},
+	),
+}
+
+// 最新のスキーマを作成し、テスト用の駅・時刻表を登録する(テスト終了時に削除する)
+// NOTE: 駅・列車のIDを固定で登録するため、駅が未登録のDBのみ使用できる
+func setupTestTimetable(t *testing.T, db *sqlx.DB) {
+	t.Helper()
+	ctx := context.Background()
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	var count int
+	if err := db.GetContext(ctx, &count, `SELECT COUNT(*) FROM stations`); err != nil {
+		t.Fatalf("count stations: %v", err)
+	}
+	if count > 0 {
+		t.Fatalf("test database must not have stations (found %d)", count)
+	}
+
+	exec := func(query string, args ...any) {
+		t.Helper()
+		if _, err := db.ExecContext(ctx, db.Rebind(query), args...); err != nil {
+			t.Fatalf("exec %q: %v", query, err)
+		}
+	}
+	boolean := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	// 鉄道網1はマイグレーションで作成される既定の鉄道網を使用する
+	createdNetworks := make([]uint, 0, len(testStations))
+	for networkID := range testStations {
+		if err := db.GetContext(ctx, &count, db.Rebind(`SELECT COUNT(*) FROM networks WHERE id = ?`), networkID); err != nil {
+			t.Fatalf("count networks: %v", err)
+		}
+		if count == 0 {
+			exec(`INSERT INTO networks (id, code, name, time_zone) VALUES (?, ?, ?, ?)`,
+				networkID, fmt.Sprintf("test%d", networkID), fmt.Sprintf("test%d", networkID), "Asia/Tokyo")
+			createdNetworks = append(createdNetworks, networkID)
+		}
+	}
+	t.Cleanup(func() {
+		cleanup := func(query string, id uint) {
+			if _, err := db.Exec(db.Rebind(query), id); err != nil {
+				t.Errorf("cleanup %q (%d): %v", query, id, err)
+			}
+		}
+		for _, train := range testTrains {
+			cleanup(`DELETE FROM stop_times WHERE train_id = ?`, train.ID)
+			cleanup(`DELETE FROM trains WHERE id = ?`, train.ID)
+		}
+		for _, stations := range testStations {
+			for _, s := range stations {
+				cleanup(`DELETE FROM stations WHERE id = ?`, s.ID)
+			}
+		}
+		for _, networkID := range createdNetworks {
+			cleanup(`DELETE FROM networks WHERE id = ?`, networkID)
+		}
+	})
+
+	for networkID, stations := range testStations {
+		for _, s := range stations {
+			exec(`INSERT INTO stations (id, network_id, name, name_en, lat, lon, elevator, step_free, accessible_toilet) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				s.ID, networkID, s.Name, s.EngName, s.Lat, s.Lon, boolean(s.Elevator), boolean(s.StepFree), boolean(s.AccessibleToilet))
+		}
+	}
+	for _, train := range testTrains {
+		exec(`INSERT INTO trains (id, network_id, name) VALUES (?, ?, ?)`, train.ID, train.NetworkID, train.Name)
+		for _, st := range train.StopTimes {
+			exec(`INSERT INTO stop_times (train_id, stop_sequence, station_id, arr_time, dep_time, pickup_only, drop_off_only, platform) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				st.TrainID, st.StopSequence, st.StationID, st.ArriveTime, st.DepartTime, boolean(st.PickupOnly), boolean(st.DropOffOnly), st.Platform)
+		}
+	}
+}
+
+func formatOptional[T any](v *T) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprint(*v)
+}
+
+// 区間移動の要約(列車ID#順序 出発駅>到着駅 出発-到着 運行日 乗降制限 番線)
+func operationSummary(op Operation) string {
+	return fmt.Sprintf("%d#%d %d>%d %s-%s %s nb=%t na=%t %s>%s",
+		op.TrainID, op.Order, op.DepartStationID, op.ArriveStationID,
+		op.DepartDatetime.Format("01-02 15:04"), op.ArriveDatetime.Format("01-02 15:04"),
+		op.ServiceDate.Format("01-02"), op.NoBoarding, op.NoAlighting,
+		formatOptional(op.DepartPlatform), formatOptional(op.ArrivePlatform),
+	)
+}
+
+// 駅・時刻表の取得結果を、実装によらず比較できる文字列に変換するテストケース
+type repositoryCase struct {
+	name string
+	run  func(ctx context.Context, repos Repositories) ([]string, error)
+	want []string
+}
+
+var repositoryCases = []repositoryCase{
+	{
+		name: "GetStations",
+		run: func(ctx context.Context, repos Repositories) ([]string, error) {
+			stations, err := repos.Stations.GetStations(ctx, 1)
+			result := make([]string, 0, len(stations))
+			for _, s := range stations {
+				result = append(result, fmt.Sprintf("%d %s %s %s,%s e=%t s=%t a=%t",
+					s.ID, s.Name, s.EngName, formatOptional(s.Lat), formatOptional(s.Lon), s.Elevator, s.StepFree, s.AccessibleToilet))
+			}
+			return result, err
+		},
+		want: []string{
+			"1 甲 Ko 35,139 e=false s=true a=false",
+			"2 乙 Otsu 35.1,139.1 e=true s=false a=false",
+			"3 丙 Hei -,- e=false s=true a=true",
+			"4 丁 Tei -,- e=false s=false a=false",
+		},
+	},
+	{
+		name: "GetStations other network",
+		run: func(ctx context.Context, repos Repositories) ([]string, error) {
+			return stationIDs(repos.Stations.GetStations(ctx, 2))
+		},
+		want: []string{"5", "6"},
+	},
+	{
+		name: "GetStationsByKeyword case insensitive",
+		run: func(ctx context.Context, repos Repositories) ([]string, error) {
+			return stationIDs(repos.Stations.GetStationsByKeyword(ctx, 1, "O"))
+		},
+		want: []string{"1", "2"},
+	},
+	{
+		name: "GetStationsByKeyword name",
+		run: func(ctx context.Context, repos Repositories) ([]string, error) {
+			return stationIDs(repos.Stations.GetStationsByKeyword(ctx, 1, "丙"))
+		},
+		want: []string{"3"},
+	},
+	{
+		name: "GetStationsByKeyword scoped to network",
+		run: func(ctx context.Context, repos Repositories) ([]string, error) {
+			return stationIDs(repos.Stations.GetStationsByKeyword(ctx, 1, "Bo"))
+		},
+		want: []string{},
+	},
+	{
+		name: "GetStepFreeStationIDs",
+		run: func(ctx context.Context, repos Repositories) ([]string, error) {
+			ids, err := repos.Stations.GetStepFreeStationIDs(ctx, 1)
+			result := make([]string, 0, len(ids))
+			for id := range ids {
+				result = append(result, fmt.Sprint(id))
+			}
+			sort.Strings(result)
+			return result, err
+		},
+		want: []string{"1", "3"},
+	},
+	{
+		name: "SearchNextDepartOperations",
+		run: func(ctx context.Context, repos Repositories) ([]string, error) {
+			return operationSummaries(repos.Operations.SearchNextDepartOperations(ctx, 1, testDatetime(8, 5)))
+		},
+		want: []string{
+			"102#1 1>2 10-01 08:20-10-01 08:30 10-01 nb=false na=false 2>-",
+			"103#1 1>2 10-01 08:55-10-01 09:00 10-01 nb=false na=false ->-",
+			"101#1 1>2 10-02 08:00-10-02 08:10 10-02 nb=false na=false 1>-",
+		},
+	},
+	{
+		name: "SearchNextDepartOperations boarding restrictions",
+		run: func(ctx context.Context, repos Repositories) ([]string, error) {
+			return operationSummaries(repos.Operations.SearchNextDepartOperations(ctx, 2, testDatetime(8, 15)))
+		},
+		want: []string{
+			"105#1 2>1 10-02 07:00-10-02 07:10 10-02 nb=false na=true ->-",
+			"102#2 2>3 10-01 08:31-10-01 08:50 10-01 nb=false na=false ->-",
+			"101#2 2>3 10-02 08:12-10-02 08:30 10-02 nb=false na=false ->-",
+			"103#2 2>4 10-01 09:01-10-01 09:15 10-01 nb=true na=false ->-",
+		},
+	},
+	{
+		name: "GetRunningOperations",
+		run: func(ctx context.Context, repos Repositories) ([]string, error) {
+			return runningSummaries(repos.Operations.GetRunningOperations(ctx, 1, testDatetime(8, 25)))
+		},
+		want: []string{
+			"101#2 2>3 10-01 08:12-10-01 08:30 10-01 普通101 35.1,139.1>-,-",
+			"102#1 1>2 10-01 08:20-10-01 08:30 10-01 普通102 35,139>35.1,139.1",
+		},
+	},
+	{
+		// 到着と同時に終着した列車は含めず、停車中の列車は次の区間で返す
+		name: "GetRunningOperations dwelling",
+		run: func(ctx context.Context, repos Repositories) ([]string, error) {
+			return runningSummaries(repos.Operations.GetRunningOperations(ctx, 1, testDatetime(8, 30)))
+		},
+		want: []string{
+			"102#2 2>3 10-01 08:31-10-01 08:50 10-01 普通102 35.1,139.1>-,-",
+		},
+	},
+	{
+		name: "GetRunningOperations across midnight",
+		run: func(ctx context.Context, repos Repositories) ([]string, error) {
+			return runningSummaries(repos.Operations.GetRunningOperations(ctx, 1, time.Date(2024, 10, 2, 0, 10, 0, 0, testLocation)))
+		},
+		want: []string{
+			"104#1 3>4 10-01 23:50-10-02 00:20 10-01 深夜104 -,->-,-",
+		},
+	},
+	{
+		name: "GetTimetableOperations",
+		run: func(ctx context.Context, repos Repositories) ([]string, error) {
+			return operationSummaries(repos.Operations.GetTimetableOperations(ctx, 1, testDatetime(0, 0)))
+		},
+		want: []string{
+			"101#1 1>2 10-01 08:00-10-01 08:10 10-01 nb=false na=false 1>-",
+			"101#2 2>3 10-01 08:12-10-01 08:30 10-01 nb=false na=false ->-",
+			"102#1 1>2 10-01 08:20-10-01 08:30 10-01 nb=false na=false 2>-",
+			"102#2 2>3 10-01 08:31-10-01 08:50 10-01 nb=false na=false ->-",
+			"103#1 1>2 10-01 08:55-10-01 09:00 10-01 nb=false na=false ->-",
+			"103#2 2>4 10-01 09:01-10-01 09:15 10-01 nb=true na=false ->-",
+			"104#1 3>4 10-01 23:50-10-02 00:20 10-01 nb=false na=false ->-",
+			"105#1 2>1 10-01 07:00-10-01 07:10 10-01 nb=false na=true ->-",
+		},
+	},
+	{
+		name: "GetStationDepartures",
+		run: func(ctx context.Context, repos Repositories) ([]string, error) {
+			departures, err := repos.Operations.GetStationDepartures(ctx, 2, testDatetime(8, 15), 3)
+			result := make([]string, 0, len(departures))
+			for _, d := range departures {
+				arrive := "-"
+				if d.ArriveDatetime != nil {
+					arrive = d.ArriveDatetime.Format("01-02 15:04")
+				}
+				result = append(result, fmt.Sprintf("%d#%d %s %s-%s >%d %s p=%t d=%t %s",
+					d.TrainID, d.StopSequence, formatOptional(d.TrainName), arrive, d.DepartDatetime.Format("01-02 15:04"),
+					d.DestinationStationID, d.ServiceDate.Format("01-02"), d.PickupOnly, d.DropOffOnly, formatOptional(d.Platform)))
+			}
+			return result, err
+		},
+		want: []string{
+			"102#2 普通102 10-01 08:30-10-01 08:31 >3 10-01 p=false d=false -",
+			"103#2 快速103 10-01 09:00-10-01 09:01 >4 10-01 p=false d=true -",
+			"105#1 - --10-02 07:00 >1 10-02 p=false d=false -",
+		},
+	},
+}
+
+func stationIDs(stations []Station, err error) ([]string, error) {
+	result := make([]string, 0, len(stations))
+	for _, s := range stations {
+		result = append(result, fmt.Sprint(s.ID))
+	}
+	return result, err
+}
+
+func operationSummaries(operations []Operation, err error) ([]string, error) {
+	result := make([]string, 0, len(operations))
+	for _, op := range operations {
+		result = append(result, operationSummary(op))
+	}
+	return result, err
+}
+
+func runningSummaries(operations []RunningOperation, err error) ([]string, error) {
+	result := make([]string, 0, len(operations))
+	for _, op := range operations {
+		// 走行中の区間移動には、乗降制限・番線を含めない
+		result = append(result, strings.Join([]string{
+			fmt.Sprintf("%d#%d %d>%d %s-%s %s", op.TrainID, op.Order, op.DepartStationID, op.ArriveStationID,
+				op.DepartDatetime.Format("01-02 15:04"), op.ArriveDatetime.Format("01-02 15:04"), op.ServiceDate.Format("01-02")),
+			formatOptional(op.TrainName),
+			formatOptional(op.DepartLat) + "," + formatOptional(op.DepartLon) + ">" + formatOptional(op.ArriveLat) + "," + formatOptional(op.ArriveLon),
+		}, " "))
+	}
+	return result, err
+}
+
+// 駅・時刻表の取得先が、テスト用の駅・時刻表に対して期待する結果を返すか
+func testRepositories(t *testing.T, repos Repositories) {
+	for _, tt := range repositoryCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.run(context.Background(), repos)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("%s:\n got  %q\n want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+// 不正な時刻表からは、メモリ上の駅・時刻表を生成しない
+func TestNewMemoryRepositoriesRejectsInvalidTimetable(t *testing.T) {
+	tests := []struct {
+		name  string
+		train MemoryTrain
+	}{
+		{
+			name: "invalid time",
+			train: testMemoryTrain(1, 1, nil,
+				testStop{stationID: 1, depart: "8:00"},
+				testStop{stationID: 2, arrive: "08:10:00"},
+			),
+		},
+		{
+			name: "missing time",
+			train: testMemoryTrain(1, 1, nil,
+				testStop{stationID: 1, depart: "08:00:00"},
+				testStop{stationID: 2},
+				testStop{stationID: 3, arrive: "08:20:00"},
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewMemoryRepositories(testStations, []MemoryTrain{tt.train}); err == nil {
+				t.Fatal("NewMemoryRepositories succeeded, want error")
+			}
+		})
+	}
+}
@@ -1,16 +1,18 @@
 package models
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
-var (
-	ErrStationIDsMissing = errors.New("invalid station ID")
-)
+// SQLのDBから時刻表を取得する(DBの種類ごとに異なるSQLはqueriesで切り替える)
+type sqlOperationRepository struct {
+	db      *sqlx.DB
+	queries dialectQueries
+}
 
 // 列車での1区間移動に対応する構造体
 type Operation struct {
@@ -20,116 +22,314 @@ type Operation struct {
 	DepartDatetime  time.Time `json:"depart_time"`
 	ArriveStationID uint      `json:"arrive_station_id"`
 	ArriveDatetime  time.Time `json:"arrive_time"`
+	ServiceDate     time.Time `json:"service_date"`    // 列車の運行日(0:00)。運休の判定に用いる
+	NoBoarding      bool      `json:"no_boarding"`     // 出発駅が降車専用のため、この区間から乗車できない
+	NoAlighting     bool      `json:"no_alighting"`    // 到着駅が乗車専用のため、この区間の後に降車できない
+	DepartPlatform  *string   `json:"depart_platform"` // 出発駅の番線(未定の場合はnil)
+	ArrivePlatform  *string   `json:"arrive_platform"` // 到着駅の番線(未定の場合はnil)
 }
 
 // 指定駅から指定時間以降に発車する列車を取得
-// NOTE: 取得は、その駅からの次停車駅を基準にグループ化され、待ち時間が最も短いもののみが取得される
+// NOTE: 取得は、その駅からの次停車駅を基準にグループ化され、グループ内で待ち時間が短い順に並ぶ
+// NOTE: 運休等で先頭の列車が使えない場合に備え、2番目以降の列車も取得する(選択は呼び出し側で行う)
 // NOTE: 「乗換回数が少ないルート」といった基準では取得できない(UNIONでいけるか？)
 // NOTE: sqlxのNamedQueryはなぜか使えなかった(SQLパースエラー)
-func SearchNextDepartOperations(db *sqlx.DB, departStationID uint, fastestDepartDatetime time.Time) ([]Operation, error) {
-	fastestDepartDatetimeString := fastestDepartDatetime.Format("15:04:05")
-	sql := `
-SELECT o1.train_id, o1.op_order, o1.dep_sta_id, o1.dep_time, o1.arr_sta_id, o1.arr_time
-FROM operations o1
-INNER JOIN (
-	SELECT train_id, op_order, dep_time,
-	ROW_NUMBER() OVER (
-		PARTITION BY dep_sta_id, arr_sta_id
-		ORDER BY CASE
-			WHEN dep_time >= ? THEN TIMEDIFF(dep_time, ?)
-			ELSE TIMEDIFF(ADDTIME(dep_time, "24:00:00"), ?)
-		END
-	) dep_order_arr_grouped
-	FROM operations
-	WHERE dep_sta_id = ?
-) o2
-ON o2.train_id = o1.train_id
-AND o2.op_order = o1.op_order
-AND o2.dep_order_arr_grouped = 1
-`
-	rows, err := db.Query(
-		sql,
-		fastestDepartDatetimeString,
-		fastestDepartDatetimeString,
-		fastestDepartDatetimeString,
+// NOTE: operationsビューは全列車の停車駅から区間を導出するため、stop_timesを直接参照する(次停車駅はstop_sequenceが次の連番の行)
+// NOTE: 24:00以降の時刻は、日付を跨いだ時刻として待ち時間を求める
+func (r *sqlOperationRepository) SearchNextDepartOperations(ctx context.Context, departStationID uint, fastestDepartDatetime time.Time) ([]Operation, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		r.db.Rebind(r.queries.nextDepartOperations),
+		secondsOfDay(fastestDepartDatetime),
 		departStationID,
 	)
 	if err != nil {
 		return []Operation{}, err
 	}
+	defer rows.Close()
 
 	operations := make([]Operation, 0, 5)
 	var (
 		op               Operation
 		departTimeString string
 		arriveTimeString string
+		depOrder         uint
 	)
 
 	// 移動先の候補を取得
 	// NOTE: たとえ逆方向でも情報が取得される
 	for rows.Next() {
-		err := rows.Scan(&op.TrainID, &op.Order, &op.DepartStationID, &departTimeString, &op.ArriveStationID, &arriveTimeString)
+		err := rows.Scan(
+			&op.TrainID, &op.Order, &op.DepartStationID, &departTimeString, &op.ArriveStationID, &arriveTimeString,
+			&op.NoBoarding, &op.NoAlighting, &op.DepartPlatform, &op.ArrivePlatform, &depOrder,
+		)
 		if err != nil {
 			return []Operation{}, err
 		}
 
-		// DBは時刻の文字列を返すので、fastestDepartDatetime < departDatetime < arriveDatetime の順になるように変換・調整
-		op.DepartDatetime, err = timeString2DatetimeForward(fastestDepartDatetime, departTimeString)
-		if err != nil {
-			return []Operation{}, fmt.Errorf("updateDepartTimeString: %w", err)
-		}
-		op.ArriveDatetime, err = timeString2DatetimeForward(op.DepartDatetime, arriveTimeString)
+		op, err = setForwardDatetimes(op, fastestDepartDatetime, departTimeString, arriveTimeString)
 		if err != nil {
-			return []Operation{}, fmt.Errorf("updateArriveTimeString: %w", err)
+			return []Operation{}, err
 		}
 
 		operations = append(operations, op)
 	}
+	// NOTE: 走査中にctxの期限切れ・取消が起きた場合、途中までの候補を完全な結果として返さない
+	if err := rows.Err(); err != nil {
+		return []Operation{}, err
+	}
 
 	return operations, nil
 }
 
+// 時刻の文字列から、fastestDepartDatetime < departDatetime < arriveDatetime の順になるように日時・運行日を設定
+func setForwardDatetimes(op Operation, fastestDepartDatetime time.Time, departTimeString string, arriveTimeString string) (Operation, error) {
+	var err error
+	op.DepartDatetime, err = timeString2DatetimeForward(fastestDepartDatetime, departTimeString)
+	if err != nil {
+		return Operation{}, fmt.Errorf("updateDepartTimeString: %w", err)
+	}
+	op.ArriveDatetime, err = timeString2DatetimeForward(op.DepartDatetime, arriveTimeString)
+	if err != nil {
+		return Operation{}, fmt.Errorf("updateArriveTimeString: %w", err)
+	}
+	op.ServiceDate, err = serviceDateOf(op.DepartDatetime, departTimeString)
+	if err != nil {
+		return Operation{}, fmt.Errorf("serviceDateOf: %w", err)
+	}
+	return op, nil
+}
+
 // 順移動探索における到着時刻の変換(string -> time.Time)
+// laterTimeStringは運行日の時刻(24:00以降も可)とし、fasterDatetime以降で最も早い、その時刻の日時を返す
 func timeString2DatetimeForward(fasterDatetime time.Time, laterTimeString string) (time.Time, error) {
-	laterTime, err := time.Parse("15:04:05", laterTimeString)
+	laterTime, err := ParseServiceTime(laterTimeString)
 	if err != nil {
 		return time.Time{}, err
 	}
 
 	// まず、fasterDatetimeとlaterDatetimeが同日前提で変換する
-	laterDatetime := time.Date(
-		fasterDatetime.Year(),
-		fasterDatetime.Month(),
-		fasterDatetime.Day(),
-		laterTime.Hour(),
-		laterTime.Minute(),
-		laterTime.Second(),
-		0,
-		fasterDatetime.Location(),
-	)
+	// 24:00以降の時刻は、翌日以降の時刻(0:00〜)として扱う
+	// NOTE: 時刻はfasterDatetimeの地域の時刻(壁時計)とするため、夏時間の切替も反映される
+	fasterDate := time.Date(fasterDatetime.Year(), fasterDatetime.Month(), fasterDatetime.Day(), 0, 0, 0, 0, fasterDatetime.Location())
+	laterDatetime := ServiceDatetime(fasterDate, laterTime%(24*time.Hour))
 
 	// 出発日時より到着日時が後になるべき
 	// laterDatetime < fasterDatetime の場合、1日後送りにする
 	// これにより、日付を跨いだ運行・乗り換えを可能とする
-	// NOTE: DB側が24時間を超える運転をしないことを前提とする
+	// NOTE: 1区間の移動・乗換が24時間を超えないことを前提とする
+	// NOTE: 夏時間の終了で2回ある時刻は、後の時刻でfasterDatetime以降となればそれを使う
 	if fasterDatetime.After(laterDatetime) {
-		laterDatetime = laterDatetime.AddDate(0, 0, 1)
+		if repeated, isRepeated := repeatedDatetime(laterDatetime); isRepeated && repeated.After(laterDatetime) && !fasterDatetime.After(repeated) {
+			laterDatetime = repeated
+		} else {
+			laterDatetime = ServiceDatetime(fasterDate.AddDate(0, 0, 1), laterTime%(24*time.Hour))
+		}
 	}
 
 	return laterDatetime, nil
 }
 
-// 駅IDの存在チェック
-func CheckExistsStationID(db *sqlx.DB, staID uint) error {
-	var result bool
-	err := db.QueryRow(`SELECT EXISTS(SELECT * FROM stations WHERE id = ?)`, staID).Scan(&result)
+// 逆移動探索における出発時刻の変換(string -> time.Time)
+// laterDatetime以前で、最も遅いearlierTimeStringの日時を返す
+func timeString2DatetimeBackward(laterDatetime time.Time, earlierTimeString string) (time.Time, error) {
+	earlierTime, err := ParseServiceTime(earlierTimeString)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// 日付dateの時刻のうち、laterDatetime以前で最も遅い日時
+	// NOTE: 夏時間の終了で2回ある時刻は(ServiceDatetimeは先の時刻を返すため)、laterDatetime以前であれば後の時刻を使う
+	latestOn := func(date time.Time) time.Time {
+		datetime := ServiceDatetime(date, earlierTime%(24*time.Hour))
+		if repeated, isRepeated := repeatedDatetime(datetime); isRepeated && repeated.After(datetime) && !repeated.After(laterDatetime) {
+			return repeated
+		}
+		return datetime
+	}
+
+	// まず、laterDatetimeと同日前提で変換し、laterDatetimeより後であれば1日前倒しにする
+	laterDate := time.Date(laterDatetime.Year(), laterDatetime.Month(), laterDatetime.Day(), 0, 0, 0, 0, laterDatetime.Location())
+	earlierDatetime := latestOn(laterDate)
+	if earlierDatetime.After(laterDatetime) {
+		earlierDatetime = latestOn(laterDate.AddDate(0, 0, -1))
+	}
+
+	return earlierDatetime, nil
+}
+
+// 走行中の列車の1区間移動(両端駅の座標付き)
+type RunningOperation struct {
+	Operation
+	TrainName *string
+	DepartLat *float64
+	DepartLon *float64
+	ArriveLat *float64
+	ArriveLon *float64
+}
+
+// 指定日時に鉄道網の駅間を走行中、または駅に停車中の列車を取得
+// 停車中の列車は、その駅を出発する区間として返す(出発駅への到着から区間の到着までを、その区間の運行中とする)
+// NOTE: 日付を跨ぐ区間(0:00からの秒数でdep > arr)も考慮する。24:00以降の時刻は、0:00からの秒数に直して比較する
+func (r *sqlOperationRepository) GetRunningOperations(ctx context.Context, networkID uint, datetime time.Time) ([]RunningOperation, error) {
+	seconds := secondsOfDay(datetime)
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(r.queries.runningOperations), networkID, seconds, seconds, seconds, seconds)
+	if err != nil {
+		return nil, fmt.Errorf("executeQuery: %w", err)
+	}
+	defer rows.Close()
+
+	operations := make([]RunningOperation, 0, 10)
+	for rows.Next() {
+		var (
+			op               RunningOperation
+			dwellTimeString  string
+			departTimeString string
+			arriveTimeString string
+		)
+		err := rows.Scan(
+			&op.TrainID, &op.TrainName, &op.Order,
+			&op.DepartStationID, &dwellTimeString, &departTimeString, &op.ArriveStationID, &arriveTimeString,
+			&op.DepartLat, &op.DepartLon, &op.ArriveLat, &op.ArriveLon,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanRecord: %w", err)
+		}
+
+		op, err = setRunningDatetimes(op, datetime, dwellTimeString, departTimeString, arriveTimeString)
+		if err != nil {
+			return nil, err
+		}
+
+		operations = append(operations, op)
+	}
+
+	return operations, rows.Err()
+}
+
+// 時刻の文字列から、datetime以前の直近の出発駅への到着(停車)以降の出発日時と、その後の到着日時・運行日を設定
+// NOTE: 出発駅に停車中の場合、出発日時はdatetimeより後となる
+func setRunningDatetimes(op RunningOperation, datetime time.Time, dwellTimeString string, departTimeString string, arriveTimeString string) (RunningOperation, error) {
+	dwellDatetime, err := timeString2DatetimeBackward(datetime, dwellTimeString)
+	if err != nil {
+		return RunningOperation{}, fmt.Errorf("updateDwellTimeString: %w", err)
+	}
+	op.DepartDatetime, err = timeString2DatetimeForward(dwellDatetime, departTimeString)
+	if err != nil {
+		return RunningOperation{}, fmt.Errorf("updateDepartTimeString: %w", err)
+	}
+	op.ArriveDatetime, err = timeString2DatetimeForward(op.DepartDatetime, arriveTimeString)
+	if err != nil {
+		return RunningOperation{}, fmt.Errorf("updateArriveTimeString: %w", err)
+	}
+	op.ServiceDate, err = serviceDateOf(op.DepartDatetime, departTimeString)
+	if err != nil {
+		return RunningOperation{}, fmt.Errorf("serviceDateOf: %w", err)
+	}
+	return op, nil
+}
+
+// 鉄道網の全列車の区間移動を、列車・運行順に取得
+// 時刻は、列車ごとにbaseDatetime(0:00)の日の始発から連続するよう変換する
+// 運行日は、列車の始発の区間で判定する(日付を跨いでも同じ運行日とする)
+func (r *sqlOperationRepository) GetTimetableOperations(ctx context.Context, networkID uint, baseDatetime time.Time) ([]Operation, error) {
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(`
+SELECT train_id, op_order, dep_sta_id, dep_time, arr_sta_id, arr_time,
+	dep_drop_off_only, arr_pickup_only, dep_platform, arr_platform
+FROM operations
+WHERE network_id = ?
+ORDER BY train_id, op_order
+`), networkID)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("executeQuery: %w", err)
 	}
+	defer rows.Close()
+
+	operations := make([]Operation, 0, 100)
+	for rows.Next() {
+		var (
+			op               Operation
+			departTimeString string
+			arriveTimeString string
+		)
+		err := rows.Scan(
+			&op.TrainID, &op.Order, &op.DepartStationID, &departTimeString, &op.ArriveStationID, &arriveTimeString,
+			&op.NoBoarding, &op.NoAlighting, &op.DepartPlatform, &op.ArrivePlatform,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanRecord: %w", err)
+		}
+
+		operations, err = appendTimetableOperation(operations, op, baseDatetime, departTimeString, arriveTimeString)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return operations, rows.Err()
+}
 
-	if !result {
-		return ErrStationIDsMissing
-	} else {
-		return nil
+// 時刻の文字列から日時・運行日を設定し、列車・運行順に並んだoperationsの末尾に追加
+// 同一列車の直前区間があれば、その到着以降になるよう変換し、運行日を引き継ぐ
+func appendTimetableOperation(operations []Operation, op Operation, baseDatetime time.Time, departTimeString string, arriveTimeString string) ([]Operation, error) {
+	isContinued := len(operations) > 0 && operations[len(operations)-1].TrainID == op.TrainID
+	fasterDatetime := baseDatetime
+	if isContinued {
+		fasterDatetime = operations[len(operations)-1].ArriveDatetime
 	}
+
+	var err error
+	op.DepartDatetime, err = timeString2DatetimeForward(fasterDatetime, departTimeString)
+	if err != nil {
+		return nil, fmt.Errorf("updateDepartTimeString: %w", err)
+	}
+	op.ArriveDatetime, err = timeString2DatetimeForward(op.DepartDatetime, arriveTimeString)
+	if err != nil {
+		return nil, fmt.Errorf("updateArriveTimeString: %w", err)
+	}
+	if isContinued {
+		op.ServiceDate = operations[len(operations)-1].ServiceDate
+	} else if op.ServiceDate, err = serviceDateOf(op.DepartDatetime, departTimeString); err != nil {
+		return nil, fmt.Errorf("serviceDateOf: %w", err)
+	}
+
+	return append(operations, op), nil
+}
+
+// 鉄道網の単線区間の集合を取得(キーは駅IDの小さい順に並べた駅ペア)
+func GetSingleTrackSegments(ctx context.Context, db *sqlx.DB, networkID uint) (map[[2]uint]struct{}, error) {
+	rows, err := db.QueryContext(ctx, db.Rebind(`
+SELECT ts.sta_id_a, ts.sta_id_b
+FROM track_segments ts
+INNER JOIN stations s ON s.id = ts.sta_id_a
+WHERE s.network_id = ? AND ts.single_track = 1
+`), networkID)
+	if err != nil {
+		return nil, fmt.Errorf("executeQuery: %w", err)
+	}
+	defer rows.Close()
+
+	segments := make(map[[2]uint]struct{})
+	for rows.Next() {
+		var staIDA, staIDB uint
+		if err := rows.Scan(&staIDA, &staIDB); err != nil {
+			return nil, fmt.Errorf("scanRecord: %w", err)
+		}
+		segments[SegmentKey(staIDA, staIDB)] = struct{}{}
+	}
+
+	return segments, rows.Err()
+}
+
+// 向きを区別しない駅間のキー
+func SegmentKey(staIDA uint, staIDB uint) [2]uint {
+	if staIDA > staIDB {
+		staIDA, staIDB = staIDB, staIDA
+	}
+	return [2]uint{staIDA, staIDB}
+}
+
+// 日時の0:00からの秒数(SQLでの時刻比較用)
+func secondsOfDay(datetime time.Time) int {
+	return datetime.Hour()*3600 + datetime.Minute()*60 + datetime.Second()
 }
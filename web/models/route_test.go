@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeString2DatetimeForward(t *testing.T) {
+	tests := []struct {
+		name       string
+		faster     time.Time
+		timeString string
+		want       time.Time
+	}{
+		{name: "same time", faster: testDayDatetime(1, 23, 59), timeString: "23:59:00", want: testDayDatetime(1, 23, 59)},
+		{name: "23:59 after 23:00", faster: testDayDatetime(1, 23, 0), timeString: "23:59:00", want: testDayDatetime(1, 23, 59)},
+		{name: "24:00 after 23:59", faster: testDayDatetime(1, 23, 59), timeString: "24:00:00", want: testDayDatetime(2, 0, 0)},
+		{name: "25:30 after 23:59", faster: testDayDatetime(1, 23, 59), timeString: "25:30:00", want: testDayDatetime(2, 1, 30)},
+		{name: "25:30 after 24:10", faster: testDayDatetime(2, 0, 10), timeString: "25:30:00", want: testDayDatetime(2, 1, 30)},
+		{name: "00:10 after 23:59", faster: testDayDatetime(1, 23, 59), timeString: "00:10:00", want: testDayDatetime(2, 0, 10)},
+		{name: "earlier time is next day", faster: testDayDatetime(1, 23, 59), timeString: "23:58:00", want: testDayDatetime(2, 23, 58)},
+		{name: "service day start", faster: testDayDatetime(2, 3, 59), timeString: "04:00:00", want: testDayDatetime(2, 4, 0)},
+		{name: "24:00 search from midnight", faster: testDayDatetime(2, 0, 0), timeString: "24:00:00", want: testDayDatetime(2, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := timeString2DatetimeForward(tt.faster, tt.timeString)
+			if err != nil {
+				t.Fatalf("timeString2DatetimeForward: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("timeString2DatetimeForward(%v, %q) = %v, want %v", tt.faster, tt.timeString, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeString2DatetimeBackward(t *testing.T) {
+	tests := []struct {
+		name       string
+		later      time.Time
+		timeString string
+		want       time.Time
+	}{
+		{name: "same time", later: testDayDatetime(2, 0, 0), timeString: "24:00:00", want: testDayDatetime(2, 0, 0)},
+		{name: "23:59 before 24:00", later: testDayDatetime(2, 0, 0), timeString: "23:59:00", want: testDayDatetime(1, 23, 59)},
+		{name: "24:00 before 25:30", later: testDayDatetime(2, 1, 30), timeString: "24:00:00", want: testDayDatetime(2, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := timeString2DatetimeBackward(tt.later, tt.timeString)
+			if err != nil {
+				t.Fatalf("timeString2DatetimeBackward: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("timeString2DatetimeBackward(%v, %q) = %v, want %v", tt.later, tt.timeString, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// 運行日の開始時刻(0:00からの経過時間)
+// これより前の時刻は前日の運行日に属し、24:00以降の時刻と同じものとして扱う
+// NOTE: 起動時にSetServiceDayStartで設定する
+var serviceDayStart = 4 * time.Hour
+
+// 運行日の開始時刻を設定
+func SetServiceDayStart(start time.Duration) {
+	serviceDayStart = start
+}
+
+// 運行日の開始時刻を返す
+func ServiceDayStart() time.Duration {
+	return serviceDayStart
+}
+
+// datetimeが属する運行日(その日の0:00)を返す
+// NOTE: 夏時間の切替日も正しく判定するため、経過時間ではなくその地域の時刻(壁時計)で比較する
+func ServiceDate(datetime time.Time) time.Time {
+	date := time.Date(datetime.Year(), datetime.Month(), datetime.Day(), 0, 0, 0, 0, datetime.Location())
+	if ServiceTimeOf(date, datetime) < serviceDayStart {
+		date = date.AddDate(0, 0, -1)
+	}
+	return date
+}
+
+// 運行日serviceDateの時刻d(24:00以降も可)を、その地域の時刻(壁時計)として日時に変換
+// NOTE: 夏時間の切替日は、運行日の0:00からの経過時間とdが一致しない場合がある
+// NOTE: 夏時間の開始で存在しない時刻は切替前の時差で解釈し(例: 2:30 EST = 3:30 EDT)、終了で2回ある時刻は先の時刻とする
+func ServiceDatetime(serviceDate time.Time, d time.Duration) time.Time {
+	days := int(d / (24 * time.Hour))
+	d %= 24 * time.Hour
+	hour, minute, second := int(d/time.Hour), int(d/time.Minute%60), int(d/time.Second%60)
+	datetime := time.Date(serviceDate.Year(), serviceDate.Month(), serviceDate.Day()+days, hour, minute, second, 0, serviceDate.Location())
+
+	if h, m, s := datetime.Clock(); h != hour || m != minute || s != second {
+		_, offset := datetime.Add(-3 * time.Hour).Zone()
+		wall := time.Date(serviceDate.Year(), serviceDate.Month(), serviceDate.Day()+days, hour, minute, second, 0, time.UTC)
+		datetime = wall.Add(-time.Duration(offset) * time.Second).In(serviceDate.Location())
+	}
+	return datetime
+}
+
+// 夏時間の終了で同じ時刻(壁時計)が2回ある場合に、datetimeと同じ時刻のもう一方の日時を返す
+func repeatedDatetime(datetime time.Time) (time.Time, bool) {
+	_, offset := datetime.Zone()
+	for _, shift := range []time.Duration{-3 * time.Hour, 3 * time.Hour} {
+		_, other := datetime.Add(shift).Zone()
+		if other == offset {
+			continue
+		}
+		candidate := datetime.Add(time.Duration(offset-other) * time.Second)
+		if candidate.Format(time.DateTime) == datetime.Format(time.DateTime) {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// 日時datetimeを、運行日serviceDateの時刻(24:00以降も可)に変換(ServiceDatetimeの逆変換)
+func ServiceTimeOf(serviceDate time.Time, datetime time.Time) time.Duration {
+	datetime = datetime.In(serviceDate.Location())
+	dateOf := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	days := dateOf(datetime).Sub(dateOf(serviceDate)) / (24 * time.Hour)
+	hour, minute, second := datetime.Clock()
+	return days*24*time.Hour + time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second
+}
+
+// 運行日の時刻(HH:MM:SS、24:00以降も可)を、運行日の0:00からの経過時間に変換
+// NOTE: MySQLのTIME型は、24:00以降の時刻を"25:30:00"のように返す
+func ParseServiceTime(timeString string) (time.Duration, error) {
+	var hours, minutes, seconds uint
+	if _, err := fmt.Sscanf(timeString, "%d:%d:%d", &hours, &minutes, &seconds); err != nil {
+		return 0, fmt.Errorf("parseServiceTime: %w", err)
+	}
+	if minutes >= 60 || seconds >= 60 {
+		return 0, fmt.Errorf("parseServiceTime: out of range: %s", timeString)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second, nil
+}
+
+// 運行日の0:00からの経過時間を、HH:MM:SS形式(24:00以降も可)に変換
+func FormatServiceTime(d time.Duration) string {
+	seconds := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// 運行日の時刻timeStringに対応する日時datetimeから、その列車の運行日を返す
+// 24:00以降の時刻はその日数分前の運行日、24:00未満の時刻は運行日の開始時刻で判定する
+func serviceDateOf(datetime time.Time, timeString string) (time.Time, error) {
+	d, err := ParseServiceTime(timeString)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if days := int(d / (24 * time.Hour)); days > 0 {
+		return time.Date(datetime.Year(), datetime.Month(), datetime.Day()-days, 0, 0, 0, 0, datetime.Location()), nil
+	}
+	return ServiceDate(datetime), nil
+}
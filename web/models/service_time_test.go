@@ -0,0 +1,134 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseServiceTime(t *testing.T) {
+	tests := []struct {
+		timeString string
+		want       time.Duration
+		wantErr    bool
+	}{
+		{timeString: "00:00:00", want: 0},
+		{timeString: "04:00:00", want: 4 * time.Hour},
+		{timeString: "23:59:00", want: 23*time.Hour + 59*time.Minute},
+		{timeString: "23:59:59", want: 24*time.Hour - time.Second},
+		{timeString: "24:00:00", want: 24 * time.Hour},
+		{timeString: "25:30:00", want: 25*time.Hour + 30*time.Minute},
+		{timeString: "48:00:00", want: 48 * time.Hour},
+		{timeString: "23:60:00", wantErr: true},
+		{timeString: "23:59:60", wantErr: true},
+		{timeString: "23:59", wantErr: true},
+		{timeString: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timeString, func(t *testing.T) {
+			got, err := ParseServiceTime(tt.timeString)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseServiceTime(%q) = %v, want error", tt.timeString, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseServiceTime(%q): %v", tt.timeString, err)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseServiceTime(%q) = %v, want %v", tt.timeString, got, tt.want)
+			}
+			// 書式変換で元の文字列に戻る
+			if formatted := FormatServiceTime(got); formatted != tt.timeString {
+				t.Errorf("FormatServiceTime(%v) = %q, want %q", got, formatted, tt.timeString)
+			}
+		})
+	}
+}
+
+func TestServiceDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    time.Duration
+		datetime time.Time
+		want     time.Time
+	}{
+		{name: "23:59", start: 4 * time.Hour, datetime: testDayDatetime(1, 23, 59), want: testDate(1)},
+		{name: "24:00", start: 4 * time.Hour, datetime: testDayDatetime(2, 0, 0), want: testDate(1)},
+		{name: "25:30", start: 4 * time.Hour, datetime: testDayDatetime(2, 1, 30), want: testDate(1)},
+		{name: "just before start", start: 4 * time.Hour, datetime: testDayDatetime(2, 3, 59), want: testDate(1)},
+		{name: "at start", start: 4 * time.Hour, datetime: testDayDatetime(2, 4, 0), want: testDate(2)},
+		{name: "configured start before", start: 2*time.Hour + 30*time.Minute, datetime: testDayDatetime(2, 2, 29), want: testDate(1)},
+		{name: "configured start", start: 2*time.Hour + 30*time.Minute, datetime: testDayDatetime(2, 2, 30), want: testDate(2)},
+		{name: "midnight start", start: 0, datetime: testDayDatetime(2, 0, 0), want: testDate(2)},
+		{name: "midnight start 23:59", start: 0, datetime: testDayDatetime(1, 23, 59), want: testDate(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestServiceDayStart(t, tt.start)
+
+			got := ServiceDate(tt.datetime)
+			if !got.Equal(tt.want) {
+				t.Fatalf("ServiceDate(%v) = %v, want %v", tt.datetime, got, tt.want)
+			}
+			// 運行日の時刻から日時に戻せる
+			if back := ServiceDatetime(got, ServiceTimeOf(got, tt.datetime)); !back.Equal(tt.datetime) {
+				t.Errorf("ServiceDatetime(ServiceTimeOf) = %v, want %v", back, tt.datetime)
+			}
+		})
+	}
+}
+
+func TestServiceDatetime(t *testing.T) {
+	serviceDate := time.Date(2024, 10, 1, 0, 0, 0, 0, testLocation)
+	tests := []struct {
+		name string
+		d    time.Duration
+		want time.Time
+	}{
+		{name: "23:59", d: 23*time.Hour + 59*time.Minute, want: time.Date(2024, 10, 1, 23, 59, 0, 0, testLocation)},
+		{name: "24:00", d: 24 * time.Hour, want: time.Date(2024, 10, 2, 0, 0, 0, 0, testLocation)},
+		{name: "25:30", d: 25*time.Hour + 30*time.Minute, want: time.Date(2024, 10, 2, 1, 30, 0, 0, testLocation)},
+		{name: "end of service day", d: 28 * time.Hour, want: time.Date(2024, 10, 2, 4, 0, 0, 0, testLocation)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ServiceDatetime(serviceDate, tt.d); !got.Equal(tt.want) {
+				t.Fatalf("ServiceDatetime(%v) = %v, want %v", tt.d, got, tt.want)
+			}
+			if got := ServiceTimeOf(serviceDate, tt.want); got != tt.d {
+				t.Errorf("ServiceTimeOf(%v) = %v, want %v", tt.want, got, tt.d)
+			}
+		})
+	}
+}
+
+func TestServiceDateOf(t *testing.T) {
+	setTestServiceDayStart(t, 4*time.Hour)
+	tests := []struct {
+		name       string
+		datetime   time.Time
+		timeString string
+		want       time.Time
+	}{
+		{name: "23:59", datetime: time.Date(2024, 10, 1, 23, 59, 0, 0, testLocation), timeString: "23:59:00", want: testDate(1)},
+		{name: "24:00", datetime: time.Date(2024, 10, 2, 0, 0, 0, 0, testLocation), timeString: "24:00:00", want: testDate(1)},
+		{name: "25:30", datetime: time.Date(2024, 10, 2, 1, 30, 0, 0, testLocation), timeString: "25:30:00", want: testDate(1)},
+		// 24:00未満で開始時刻より前の時刻は、前日の運行日
+		{name: "before start", datetime: time.Date(2024, 10, 2, 3, 59, 0, 0, testLocation), timeString: "03:59:00", want: testDate(1)},
+		{name: "at start", datetime: time.Date(2024, 10, 2, 4, 0, 0, 0, testLocation), timeString: "04:00:00", want: testDate(2)},
+		// 24:00以降の時刻は、開始時刻を過ぎていても前日の運行日
+		{name: "28:30", datetime: time.Date(2024, 10, 2, 4, 30, 0, 0, testLocation), timeString: "28:30:00", want: testDate(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serviceDateOf(tt.datetime, tt.timeString)
+			if err != nil {
+				t.Fatalf("serviceDateOf: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("serviceDateOf(%v, %q) = %v, want %v", tt.datetime, tt.timeString, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
@@ -8,29 +9,42 @@ import (
 
 // DBのstationsスキーマに対応
 type Station struct {
-	ID      uint   `db:"id"`
-	Name    string `db:"name"`
-	EngName string `db:"name_en"`
+	ID      uint     `db:"id"`
+	Name    string   `db:"name"`
+	EngName string   `db:"name_en"`
+	Lat     *float64 `db:"lat"` // 座標未設定の場合はnil
+	Lon     *float64 `db:"lon"`
+	// バリアフリー設備
+	Elevator         bool `db:"elevator"`
+	StepFree         bool `db:"step_free"` // 段差なしでホームまで移動できる
+	AccessibleToilet bool `db:"accessible_toilet"`
 }
 
-// 駅IDからDB問い合わせをし、駅情報を返す
-func GetStationByID(db *sqlx.DB, id uint) (Station, error) {
-	var station Station
-	err := db.QueryRowx(
-		`SELECT id, name, name_en FROM stations WHERE id = ?`,
-		id,
-	).StructScan(&station)
-	return station, err
+// SQLのDBから駅情報を取得する(MySQL・SQLite・PostgreSQL共通)
+type sqlStationRepository struct {
+	db      *sqlx.DB
+	queries dialectQueries
 }
 
-// 駅名から完全一致検索で駅一覧を返す
-func GetStationsByName(db *sqlx.DB, name string) ([]Station, error) {
-	stations := make([]Station, 0, 10)
+// 鉄道網に属する駅の一覧をID順に返す
+func (r *sqlStationRepository) GetStations(ctx context.Context, networkID uint) ([]Station, error) {
+	stations := make([]Station, 0, 100)
 	query := `
-SELECT id, name, name_en FROM stations
-WHERE name = ?
+SELECT id, name, name_en, lat, lon, elevator, step_free, accessible_toilet FROM stations
+WHERE network_id = ?
+ORDER BY id
 `
-	rows, err := db.Queryx(query, name)
+	if err := r.db.SelectContext(ctx, &stations, r.db.Rebind(query), networkID); err != nil {
+		return nil, fmt.Errorf("selectStations: %w", err)
+	}
+	return stations, nil
+}
+
+// キーワードから部分一致検索で駅一覧を返す
+func (r *sqlStationRepository) GetStationsByKeyword(ctx context.Context, networkID uint, keyword string) ([]Station, error) {
+	stations := make([]Station, 0, 10)
+	keywordWithQuery := fmt.Sprintf("%%%s%%", keyword)
+	rows, err := r.db.QueryxContext(ctx, r.db.Rebind(r.queries.stationsByKeyword), networkID, keywordWithQuery, keywordWithQuery)
 	if err != nil {
 		return nil, fmt.Errorf("executeQuery: %w", err)
 	}
@@ -44,30 +58,25 @@ WHERE name = ?
 		stations = append(stations, s)
 	}
 
-	return stations, nil
+	return stations, rows.Err()
 }
 
-// キーワードから部分一致検索で駅一覧を返す
-func GetStationsByKeyword(db *sqlx.DB, keyword string) ([]Station, error) {
-	stations := make([]Station, 0, 10)
-	keywordWithQuery := fmt.Sprintf("%%%s%%", keyword)
-	query := `
-SELECT id, name, name_en FROM stations
-WHERE name LIKE ? OR name_en LIKE ?
-`
-	rows, err := db.Queryx(query, keywordWithQuery, keywordWithQuery)
+// 段差なしでホームまで移動できる駅のID集合を返す
+func (r *sqlStationRepository) GetStepFreeStationIDs(ctx context.Context, networkID uint) (map[uint]struct{}, error) {
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(`SELECT id FROM stations WHERE network_id = ? AND step_free = 1`), networkID)
 	if err != nil {
 		return nil, fmt.Errorf("executeQuery: %w", err)
 	}
 	defer rows.Close()
 
+	stationIDs := make(map[uint]struct{})
 	for rows.Next() {
-		var s Station
-		if err := rows.StructScan(&s); err != nil {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
 			return nil, fmt.Errorf("scanRecord: %w", err)
 		}
-		stations = append(stations, s)
+		stationIDs[id] = struct{}{}
 	}
 
-	return stations, nil
+	return stationIDs, rows.Err()
 }
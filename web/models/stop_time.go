@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DBのstop_timesスキーマに対応(時刻はHH:MM:SS)
+type StopTime struct {
+	TrainID      uint    `db:"train_id"`
+	StopSequence uint    `db:"stop_sequence"`
+	StationID    uint    `db:"station_id"`
+	ArriveTime   *string `db:"arr_time"` // 始発駅はnil
+	DepartTime   *string `db:"dep_time"` // 終着駅はnil
+	PickupOnly   bool    `db:"pickup_only"`
+	DropOffOnly  bool    `db:"drop_off_only"`
+	Platform     *string `db:"platform"`
+}
+
+// 駅の発車案内の1行
+type StationDeparture struct {
+	TrainID              uint
+	TrainName            *string
+	StopSequence         uint
+	ArriveDatetime       *time.Time // 始発駅の場合はnil
+	DepartDatetime       time.Time
+	DestinationStationID uint
+	ServiceDate          time.Time // 列車の運行日(0:00)
+	PickupOnly           bool
+	DropOffOnly          bool
+	Platform             *string
+}
+
+// 指定駅を指定日時以降に発車する列車を、発車の早い順にlimit件取得
+// 到着時刻は、発車時刻以前の直近の日時に変換する
+// NOTE: 24:00以降の時刻は、日付を跨いだ時刻として発車順を求める
+func (r *sqlOperationRepository) GetStationDepartures(ctx context.Context, stationID uint, fastestDepartDatetime time.Time, limit uint) ([]StationDeparture, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		r.db.Rebind(r.queries.stationDepartures),
+		stationID,
+		secondsOfDay(fastestDepartDatetime),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("executeQuery: %w", err)
+	}
+	defer rows.Close()
+
+	departures := make([]StationDeparture, 0, limit)
+	for rows.Next() {
+		var (
+			departure        StationDeparture
+			arriveTimeString *string
+			departTimeString string
+		)
+		err := rows.Scan(
+			&departure.TrainID, &departure.TrainName, &departure.StopSequence,
+			&arriveTimeString, &departTimeString, &departure.PickupOnly, &departure.DropOffOnly, &departure.Platform,
+			&departure.DestinationStationID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanRecord: %w", err)
+		}
+
+		departure, err = setDepartureDatetimes(departure, fastestDepartDatetime, arriveTimeString, departTimeString)
+		if err != nil {
+			return nil, err
+		}
+
+		departures = append(departures, departure)
+	}
+
+	return departures, rows.Err()
+}
+
+// 時刻の文字列から、fastestDepartDatetime以降の発車日時と、発車日時以前の直近の到着日時・運行日を設定
+func setDepartureDatetimes(departure StationDeparture, fastestDepartDatetime time.Time, arriveTimeString *string, departTimeString string) (StationDeparture, error) {
+	var err error
+	departure.DepartDatetime, err = timeString2DatetimeForward(fastestDepartDatetime, departTimeString)
+	if err != nil {
+		return StationDeparture{}, fmt.Errorf("updateDepartTimeString: %w", err)
+	}
+	departure.ServiceDate, err = serviceDateOf(departure.DepartDatetime, departTimeString)
+	if err != nil {
+		return StationDeparture{}, fmt.Errorf("serviceDateOf: %w", err)
+	}
+	if arriveTimeString != nil {
+		arriveDatetime, err := timeString2DatetimeBackward(departure.DepartDatetime, *arriveTimeString)
+		if err != nil {
+			return StationDeparture{}, fmt.Errorf("updateArriveTimeString: %w", err)
+		}
+		departure.ArriveDatetime = &arriveDatetime
+	}
+	return departure, nil
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrTrainIDMissing = errors.New("invalid train ID")
+)
+
+// 鉄道網における列車IDの存在チェック
+func CheckExistsTrainID(ctx context.Context, db *sqlx.DB, networkID uint, trainID uint) error {
+	var result bool
+	err := db.QueryRowContext(ctx, db.Rebind(`SELECT EXISTS(SELECT * FROM trains WHERE id = ? AND network_id = ?)`), trainID, networkID).Scan(&result)
+	if err != nil {
+		return err
+	}
+
+	if !result {
+		return ErrTrainIDMissing
+	}
+	return nil
+}
+
+// 列車IDから、所属する路線IDへの対応を返す(路線未設定の列車は含まない)
+func GetTrainLineIDs(ctx context.Context, db *sqlx.DB, trainIDs []uint) (map[uint]uint, error) {
+	trainLineIDs := make(map[uint]uint, len(trainIDs))
+	if len(trainIDs) == 0 {
+		return trainLineIDs, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT id, line_id FROM trains WHERE id IN (?) AND line_id IS NOT NULL`, trainIDs)
+	if err != nil {
+		return nil, fmt.Errorf("buildQuery: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("executeQuery: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trainID, lineID uint
+		if err := rows.Scan(&trainID, &lineID); err != nil {
+			return nil, fmt.Errorf("scanRecord: %w", err)
+		}
+		trainLineIDs[trainID] = lineID
+	}
+
+	return trainLineIDs, rows.Err()
+}
+
+// 鉄道網の列車のうち、指定した列車種別のいずれかに属する列車のID集合を返す
+func GetTrainIDsByTypeIDs(ctx context.Context, db *sqlx.DB, networkID uint, typeIDs []uint) (map[uint]struct{}, error) {
+	trainIDs := make(map[uint]struct{})
+	if len(typeIDs) == 0 {
+		return trainIDs, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT id FROM trains WHERE network_id = ? AND type_id IN (?)`, networkID, typeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("buildQuery: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("executeQuery: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trainID uint
+		if err := rows.Scan(&trainID); err != nil {
+			return nil, fmt.Errorf("scanRecord: %w", err)
+		}
+		trainIDs[trainID] = struct{}{}
+	}
+
+	return trainIDs, rows.Err()
+}
+
+// 列車IDから、その列車の終着駅IDへの対応を返す
+func GetTrainTerminalStationIDs(ctx context.Context, db *sqlx.DB, trainIDs []uint) (map[uint]uint, error) {
+	terminalStationIDs := make(map[uint]uint, len(trainIDs))
+	if len(trainIDs) == 0 {
+		return terminalStationIDs, nil
+	}
+
+	query, args, err := sqlx.In(`
+SELECT st.train_id, st.station_id
+FROM stop_times st
+WHERE st.train_id IN (?)
+AND st.stop_sequence = (SELECT MAX(stop_sequence) FROM stop_times WHERE train_id = st.train_id)
+`, trainIDs)
+	if err != nil {
+		return nil, fmt.Errorf("buildQuery: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("executeQuery: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trainID, stationID uint
+		if err := rows.Scan(&trainID, &stationID); err != nil {
+			return nil, fmt.Errorf("scanRecord: %w", err)
+		}
+		terminalStationIDs[trainID] = stationID
+	}
+
+	return terminalStationIDs, rows.Err()
+}
+
+// 運行図表用の1区間移動(列車種別の描画色付き)
+type DiagramOperation struct {
+	Operation
+	TrainName *string
+	Color     *string // 列車種別が未設定の場合はnil
+}
+
+// 両端駅がともに路線上にある区間移動を、列車・運行順に取得
+// 他路線に所属する列車は除外する(路線未設定の列車は含める)
+// 時刻は、列車ごとにbaseDatetime(0:00)の日の始発から連続するよう変換する
+func GetLineDiagramOperations(ctx context.Context, db *sqlx.DB, lineID uint, baseDatetime time.Time) ([]DiagramOperation, error) {
+	query := `
+SELECT o.train_id, t.name, tt.color, o.op_order, o.dep_sta_id, o.dep_time, o.arr_sta_id, o.arr_time
+FROM operations o
+INNER JOIN line_stations ls1 ON ls1.line_id = ? AND ls1.station_id = o.dep_sta_id
+INNER JOIN line_stations ls2 ON ls2.line_id = ? AND ls2.station_id = o.arr_sta_id
+INNER JOIN trains t ON t.id = o.train_id
+LEFT JOIN train_types tt ON tt.id = t.type_id
+WHERE t.line_id = ? OR t.line_id IS NULL
+ORDER BY o.train_id, o.op_order
+`
+	rows, err := db.QueryContext(ctx, db.Rebind(query), lineID, lineID, lineID)
+	if err != nil {
+		return nil, fmt.Errorf("executeQuery: %w", err)
+	}
+	defer rows.Close()
+
+	operations := make([]DiagramOperation, 0, 100)
+	for rows.Next() {
+		var (
+			op               DiagramOperation
+			departTimeString string
+			arriveTimeString string
+		)
+		err := rows.Scan(
+			&op.TrainID, &op.TrainName, &op.Color, &op.Order,
+			&op.DepartStationID, &departTimeString, &op.ArriveStationID, &arriveTimeString,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanRecord: %w", err)
+		}
+
+		// 同一列車の直前区間があれば、その到着以降になるよう変換
+		fasterDatetime := baseDatetime
+		if len(operations) > 0 && operations[len(operations)-1].TrainID == op.TrainID {
+			fasterDatetime = operations[len(operations)-1].ArriveDatetime
+		}
+		op.DepartDatetime, err = timeString2DatetimeForward(fasterDatetime, departTimeString)
+		if err != nil {
+			return nil, fmt.Errorf("updateDepartTimeString: %w", err)
+		}
+		op.ArriveDatetime, err = timeString2DatetimeForward(op.DepartDatetime, arriveTimeString)
+		if err != nil {
+			return nil, fmt.Errorf("updateArriveTimeString: %w", err)
+		}
+
+		operations = append(operations, op)
+	}
+
+	return operations, rows.Err()
+}
@@ -0,0 +1,24 @@
+package views
+
+// 事業者情報のレスポンス型
+
+// models.Agencyに対応
+type AgencyView struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	EngName    string `json:"name_en"`
+	URL        string `json:"url"`
+	FareSystem string `json:"fare_system"`
+	Color      string `json:"color"`
+}
+
+type AgenciesView struct {
+	Agencies []AgencyView `json:"agencies"`
+}
+
+// controllers.AgencySectionに対応(経路中で、同じ事業者の列車が連続する区間)
+type AgencySectionView struct {
+	AgencyID       *uint `json:"agency_id"`       // 事業者未設定の列車の区間はnull
+	FirstOperation int   `json:"first_operation"` // operationsの添字
+	LastOperation  int   `json:"last_operation"`
+}
@@ -0,0 +1,29 @@
+package views
+
+import "time"
+
+// models.ServiceAlertに対応
+type AlertView struct {
+	ID          uint                     `json:"id"`
+	Severity    string                   `json:"severity"`
+	Texts       map[string]AlertTextView `json:"texts"` // ロケール(ja・en等)から文言
+	ActiveFrom  *time.Time               `json:"active_from"`
+	ActiveUntil *time.Time               `json:"active_until"`
+	StationIDs  []uint                   `json:"station_ids"`
+	TrainIDs    []uint                   `json:"train_ids"`
+	LineIDs     []uint                   `json:"line_ids"`
+}
+
+// models.AlertTextに対応
+type AlertTextView struct {
+	Header      string `json:"header"`
+	Description string `json:"description"`
+}
+
+type AlertsView struct {
+	Alerts []AlertView `json:"alerts"`
+}
+
+type AlertCreatedView struct {
+	ID uint `json:"id"`
+}
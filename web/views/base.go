@@ -9,9 +9,15 @@ type ErrorView struct {
 
 // models.Stationに対応
 type StationView struct {
-	ID      uint   `json:"id"`
-	Name    string `json:"name"`
-	EngName string `json:"name_en"`
+	ID      uint     `json:"id"`
+	Name    string   `json:"name"`
+	EngName string   `json:"name_en"`
+	Lat     *float64 `json:"lat"`
+	Lon     *float64 `json:"lon"`
+	// バリアフリー設備
+	Elevator         bool `json:"elevator"`
+	StepFree         bool `json:"step_free"`
+	AccessibleToilet bool `json:"accessible_toilet"`
 }
 
 type StationsView struct {
@@ -26,4 +32,16 @@ type OperationView struct {
 	DepartDatetime  time.Time `json:"depart_datetime"`
 	ArriveStationID uint      `json:"arrive_station_id"`
 	ArriveDatetime  time.Time `json:"arrive_datetime"`
+	DepartPlatform  *string   `json:"depart_platform"` // 番線未定の場合はnull
+	ArrivePlatform  *string   `json:"arrive_platform"`
+	AgencyID        *uint     `json:"agency_id"` // 列車を運行する事業者(未設定の場合はnull)
+	// 到着駅で、乗ったまま別の列車に移る場合のみ設定
+	ThroughService *ThroughServiceView `json:"through_service"`
+}
+
+// 乗ったまま移る列車の関係(直通・分割・併合)
+type ThroughServiceView struct {
+	Type                 string `json:"type"`
+	TrainID              uint   `json:"train_id"`               // 移る先の列車
+	DestinationStationID uint   `json:"destination_station_id"` // 移る先の列車の終着駅
 }
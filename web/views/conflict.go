@@ -0,0 +1,23 @@
+package views
+
+// ダイヤ検査結果のレスポンス型
+
+// 時刻表上の1区間移動(時刻はHH:MM:SS)
+type TimetableOperationView struct {
+	TrainID         uint   `json:"train_id"`
+	Order           uint   `json:"order"`
+	DepartStationID uint   `json:"depart_station_id"`
+	DepartTime      string `json:"depart_time"`
+	ArriveStationID uint   `json:"arrive_station_id"`
+	ArriveTime      string `json:"arrive_time"`
+}
+
+type ConflictView struct {
+	Type       string                    `json:"type"`
+	Operations [2]TimetableOperationView `json:"operations"`
+}
+
+type ConflictsView struct {
+	MinHeadwaySeconds uint           `json:"min_headway_seconds"`
+	Conflicts         []ConflictView `json:"conflicts"`
+}
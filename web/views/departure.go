@@ -0,0 +1,22 @@
+package views
+
+import "time"
+
+// 駅の発車案内のレスポンス型
+
+type DepartureView struct {
+	TrainID        uint        `json:"train_id"`
+	TrainName      *string     `json:"train_name"`
+	ArriveDatetime *time.Time  `json:"arrive_datetime"` // 始発駅の場合はnull
+	DepartDatetime time.Time   `json:"depart_datetime"`
+	Destination    StationView `json:"destination"`
+	Platform       *string     `json:"platform"`      // 番線未定の場合はnull
+	PickupOnly     bool        `json:"pickup_only"`   // 乗車のみ(降車不可)
+	DropOffOnly    bool        `json:"drop_off_only"` // 降車のみ(乗車不可)
+	Cancelled      bool        `json:"cancelled"`
+}
+
+type DeparturesView struct {
+	Station    StationView     `json:"station"`
+	Departures []DepartureView `json:"departures"`
+}
@@ -0,0 +1,119 @@
+package views
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// 運行図表(SVG)の描画設定
+const (
+	diagramMarginLeft    = 140.0
+	diagramMarginTop     = 40.0
+	diagramMarginRight   = 20.0
+	diagramMarginBottom  = 20.0
+	diagramPixelsPerHour = 120.0
+	diagramStationPitch  = 40.0 // 駅間の最小描画間隔
+	diagramMinPlotHeight = 400.0
+)
+
+// 運行図表のレスポンス型(SVGとして描画する)
+type DiagramView struct {
+	Title    string
+	From     time.Duration // 0:00からの経過時間
+	To       time.Duration
+	Stations []DiagramStationView
+	Trains   []DiagramTrainView
+}
+
+type DiagramStationView struct {
+	Name     string
+	Position float64
+}
+
+type DiagramTrainView struct {
+	Label  string
+	Color  string
+	Points []DiagramPointView
+}
+
+type DiagramPointView struct {
+	Time     time.Duration
+	Position float64
+}
+
+// 横軸を時刻、縦軸を駅(路線順)としてSVGを描画
+func (d DiagramView) RenderSVG() []byte {
+	// 縦軸の範囲
+	minPosition, maxPosition := 0.0, 1.0
+	if len(d.Stations) > 0 {
+		minPosition, maxPosition = math.Inf(1), math.Inf(-1)
+		for _, sta := range d.Stations {
+			minPosition = min(minPosition, sta.Position)
+			maxPosition = max(maxPosition, sta.Position)
+		}
+		if minPosition == maxPosition {
+			maxPosition = minPosition + 1
+		}
+	}
+
+	plotWidth := d.To.Hours()*diagramPixelsPerHour - d.From.Hours()*diagramPixelsPerHour
+	plotHeight := max(diagramMinPlotHeight, diagramStationPitch*float64(len(d.Stations)-1))
+	width := diagramMarginLeft + plotWidth + diagramMarginRight
+	height := diagramMarginTop + plotHeight + diagramMarginBottom
+
+	x := func(t time.Duration) float64 {
+		return diagramMarginLeft + (t-d.From).Hours()*diagramPixelsPerHour
+	}
+	y := func(position float64) float64 {
+		return diagramMarginTop + (position-minPosition)/(maxPosition-minPosition)*plotHeight
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	fmt.Fprintf(&buf, "<title>%s</title>\n", escapeXML(d.Title))
+	fmt.Fprintf(&buf, `<clipPath id="plot"><rect x="%.1f" y="%.1f" width="%.1f" height="%.1f"/></clipPath>`+"\n", diagramMarginLeft, diagramMarginTop, plotWidth, plotHeight)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n")
+
+	// 時刻の目盛(10分ごと、正時は太線とラベル)
+	for t := d.From.Truncate(10 * time.Minute); t <= d.To; t += 10 * time.Minute {
+		if t < d.From {
+			continue
+		}
+		stroke, strokeWidth := "#e0e0e0", 0.5
+		if t%time.Hour == 0 {
+			stroke, strokeWidth = "#999999", 1
+			fmt.Fprintf(&buf, `<text x="%.1f" y="%.1f" text-anchor="middle">%d:00</text>`+"\n", x(t), diagramMarginTop-10, int(t.Hours()))
+		}
+		fmt.Fprintf(&buf, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%.1f"/>`+"\n", x(t), diagramMarginTop, x(t), diagramMarginTop+plotHeight, stroke, strokeWidth)
+	}
+
+	// 駅の目盛とラベル
+	for _, sta := range d.Stations {
+		fmt.Fprintf(&buf, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#999999" stroke-width="1"/>`+"\n", diagramMarginLeft, y(sta.Position), diagramMarginLeft+plotWidth, y(sta.Position))
+		fmt.Fprintf(&buf, `<text x="%.1f" y="%.1f" text-anchor="end" dominant-baseline="middle">%s</text>`+"\n", diagramMarginLeft-8, y(sta.Position), escapeXML(sta.Name))
+	}
+
+	// 列車の折れ線
+	buf.WriteString(`<g clip-path="url(#plot)" fill="none" stroke-width="1.5">` + "\n")
+	for _, train := range d.Trains {
+		points := make([]string, len(train.Points))
+		for i, point := range train.Points {
+			points[i] = fmt.Sprintf("%.1f,%.1f", x(point.Time), y(point.Position))
+		}
+		fmt.Fprintf(&buf, `<polyline points="%s" stroke="%s"><title>%s</title></polyline>`+"\n", strings.Join(points, " "), escapeXML(train.Color), escapeXML(train.Label))
+	}
+	buf.WriteString("</g>\n</svg>\n")
+
+	return buf.Bytes()
+}
+
+// XMLの文字列・属性値として安全な形にエスケープ
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,31 @@
+package views
+
+import "time"
+
+// 運休・運転見合わせ情報のレスポンス型
+
+// models.TrainCancellationに対応
+type TrainCancellationView struct {
+	TrainID     uint   `json:"train_id"`
+	ServiceDate string `json:"service_date"`
+	Reason      string `json:"reason"`
+}
+
+// models.SegmentSuspensionに対応
+type SegmentSuspensionView struct {
+	ID            uint      `json:"id"`
+	StationIDA    uint      `json:"station_id_a"`
+	StationIDB    uint      `json:"station_id_b"`
+	StartDatetime time.Time `json:"start_datetime"`
+	EndDatetime   time.Time `json:"end_datetime"`
+	Reason        string    `json:"reason"`
+}
+
+type DisruptionsView struct {
+	Cancellations []TrainCancellationView `json:"cancellations"`
+	Suspensions   []SegmentSuspensionView `json:"suspensions"`
+}
+
+type SegmentSuspensionCreatedView struct {
+	ID uint `json:"id"`
+}
@@ -0,0 +1,27 @@
+package views
+
+// 路線情報のレスポンス型
+
+// models.Lineに対応
+type LineView struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	EngName  string `json:"name_en"`
+	AgencyID *uint  `json:"agency_id"` // 事業者未設定の場合はnull
+}
+
+type LinesView struct {
+	Lines []LineView `json:"lines"`
+}
+
+// models.LineStationに対応
+type LineStationView struct {
+	StationView
+	Sequence uint    `json:"sequence"`
+	Km       float64 `json:"km"`
+}
+
+type LineDetailView struct {
+	LineView
+	Stations []LineStationView `json:"stations"`
+}
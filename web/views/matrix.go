@@ -0,0 +1,53 @@
+package views
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"time"
+)
+
+// 所要時間行列のレスポンス型
+
+// 出発駅から到着駅への最早到着(到達できない場合はnull)
+type MatrixCellView struct {
+	ArriveDatetime  time.Time `json:"arrive_datetime"`
+	DurationMinutes int       `json:"duration_minutes"` // 出発日時からの所要時間(分、切り上げ)
+	Transfers       int       `json:"transfers"`
+}
+
+type MatrixView struct {
+	DepartDatetime time.Time           `json:"depart_datetime"`
+	Origins        []StationView       `json:"origins"`
+	Destinations   []StationView       `json:"destinations"`
+	Cells          [][]*MatrixCellView `json:"cells"` // cells[i][j]はorigins[i]からdestinations[j]へ
+	Disruptions    DisruptionsView     `json:"disruptions"`
+	Complete       bool                `json:"complete"`
+}
+
+// 出発駅・到着駅の組ごとに1行のCSVとして出力(到達できない組は時刻・所要時間・乗換回数を空欄とする)
+func (m MatrixView) RenderCSV() []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{
+		"origin_station_id", "origin_station_name", "destination_station_id", "destination_station_name",
+		"arrive_datetime", "duration_minutes", "transfers",
+	})
+	for i, origin := range m.Origins {
+		for j, destination := range m.Destinations {
+			record := []string{
+				strconv.FormatUint(uint64(origin.ID), 10), origin.Name,
+				strconv.FormatUint(uint64(destination.ID), 10), destination.Name,
+				"", "", "",
+			}
+			if cell := m.Cells[i][j]; cell != nil {
+				record[4] = cell.ArriveDatetime.Format(time.RFC3339)
+				record[5] = strconv.Itoa(cell.DurationMinutes)
+				record[6] = strconv.Itoa(cell.Transfers)
+			}
+			w.Write(record)
+		}
+	}
+	w.Flush()
+	return buf.Bytes()
+}
@@ -0,0 +1,27 @@
+package views
+
+import (
+	"testing"
+	"time"
+)
+
+// 出発駅・到着駅の組ごとに1行を出力し、到達できない組は時刻・所要時間・乗換回数を空欄とする
+func TestMatrixViewRenderCSV(t *testing.T) {
+	location := time.FixedZone("JST", 9*60*60)
+	m := MatrixView{
+		DepartDatetime: time.Date(2024, 10, 1, 8, 0, 0, 0, location),
+		Origins:        []StationView{{ID: 1, Name: "出発駅名"}},
+		Destinations:   []StationView{{ID: 3, Name: "到着駅名"}, {ID: 4, Name: "駅名, カンマ入り"}},
+		Cells: [][]*MatrixCellView{{
+			{ArriveDatetime: time.Date(2024, 10, 1, 8, 42, 0, 0, location), DurationMinutes: 42, Transfers: 1},
+			nil,
+		}},
+	}
+
+	want := "origin_station_id,origin_station_name,destination_station_id,destination_station_name,arrive_datetime,duration_minutes,transfers\n" +
+		"1,出発駅名,3,到着駅名,2024-10-01T08:42:00+09:00,42,1\n" +
+		"1,出発駅名,4,\"駅名, カンマ入り\",,,\n"
+	if got := string(m.RenderCSV()); got != want {
+		t.Errorf("RenderCSV() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,14 @@
+package views
+
+// 鉄道網情報のレスポンス型
+
+// models.Networkに対応(管理用の認証情報は含めない)
+type NetworkView struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	TimeZone string `json:"time_zone"`
+}
+
+type NetworksView struct {
+	Networks []NetworkView `json:"networks"`
+}
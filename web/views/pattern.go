@@ -0,0 +1,15 @@
+package views
+
+// 運行パターン展開結果のレスポンス型
+
+type PatternTrainView struct {
+	TrainID    *uint                    `json:"train_id"` // dry_runの場合はnull
+	Name       *string                  `json:"name"`
+	Operations []TimetableOperationView `json:"operations"`
+}
+
+type PatternExpansionView struct {
+	PatternID uint               `json:"pattern_id"`
+	DryRun    bool               `json:"dry_run"`
+	Trains    []PatternTrainView `json:"trains"`
+}
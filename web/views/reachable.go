@@ -0,0 +1,41 @@
+package views
+
+import "time"
+
+// 到達可能な駅の探索結果のレスポンス型
+
+type ReachableStationView struct {
+	Station        StationView `json:"station"`
+	ArriveDatetime time.Time   `json:"arrive_datetime"`
+	TravelMinutes  int         `json:"travel_minutes"` // 出発日時からの所要時間(分、切り上げ)
+	Transfers      int         `json:"transfers"`
+}
+
+type ReachableView struct {
+	Station        StationView            `json:"station"` // 出発駅
+	DepartDatetime time.Time              `json:"depart_datetime"`
+	MaxMinutes     uint                   `json:"max_minutes"`
+	Stations       []ReachableStationView `json:"stations"`
+	Disruptions    DisruptionsView        `json:"disruptions"`
+	Complete       bool                   `json:"complete"` // falseの場合、探索が制限時間内に終わらず、途中までの結果のみを含む
+}
+
+// GeoJSON(RFC 7946)形式のレスポンス型
+// 座標が未設定の駅は含めない
+
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"` // 常に"FeatureCollection"
+	Features []GeoJSONFeature `json:"features"`
+	Complete bool             `json:"complete"` // ReachableViewのcompleteと同じ(RFC 7946の外部メンバー)
+}
+
+type GeoJSONFeature struct {
+	Type       string         `json:"type"` // 常に"Feature"
+	Geometry   GeoJSONPoint   `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type GeoJSONPoint struct {
+	Type        string     `json:"type"`        // 常に"Point"
+	Coordinates [2]float64 `json:"coordinates"` // [経度, 緯度]の順
+}
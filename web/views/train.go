@@ -0,0 +1,22 @@
+package views
+
+import "time"
+
+// 走行中列車の位置
+type TrainPositionView struct {
+	TrainID         uint      `json:"train_id"`
+	TrainName       *string   `json:"train_name"`
+	Order           uint      `json:"order"`
+	DepartStationID uint      `json:"depart_station_id"`
+	DepartDatetime  time.Time `json:"depart_datetime"`
+	ArriveStationID uint      `json:"arrive_station_id"`
+	ArriveDatetime  time.Time `json:"arrive_datetime"`
+	Progress        float64   `json:"progress"`
+	Lat             *float64  `json:"lat"`
+	Lon             *float64  `json:"lon"`
+}
+
+type TrainPositionsView struct {
+	Datetime time.Time           `json:"datetime"`
+	Trains   []TrainPositionView `json:"trains"`
+}
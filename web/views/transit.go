@@ -3,10 +3,18 @@ package views
 // 乗換案内情報のレスポンス型
 
 type TransitSearchView struct {
-	Stations []StationView `json:"stations"`
-	Routes   []RouteView   `json:"routes"`
+	Stations    []StationView   `json:"stations"`
+	Agencies    []AgencyView    `json:"agencies"` // 経路上の列車を運行する事業者
+	Routes      []RouteView     `json:"routes"`
+	Disruptions DisruptionsView `json:"disruptions"` // 各経路が回避した運休・運転見合わせ(全経路の和集合)
+	Complete    bool            `json:"complete"`    // falseの場合、探索が制限時間内に終わらず、途中までの結果のみを含む
 }
 
 type RouteView struct {
-	Operations []OperationView `json:"operations"`
+	Operations     []OperationView     `json:"operations"`
+	Transfers      int                 `json:"transfers"`
+	AgencySections []AgencySectionView `json:"agency_sections"` // 事業者ごとにまとめた区間(経路順)
+	AgencyChanges  int                 `json:"agency_changes"`  // 事業者が変わる回数(他社線への乗換・直通)
+	Alerts         []AlertView         `json:"alerts"`          // 経路上の駅・列車に関するお知らせ
+	Disruptions    DisruptionsView     `json:"disruptions"`     // 経路上の移動を選ぶ際に、より早い列車の代わりに回避した運休・運転見合わせ
 }